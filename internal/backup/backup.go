@@ -0,0 +1,214 @@
+package backup
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+const (
+	// FormatName identifies sshm backup files
+	FormatName = "sshm-backup"
+	// FormatVersion is bumped whenever the archive layout changes incompatibly
+	FormatVersion = 1
+
+	// AutoPrefix marks backups taken automatically before destructive operations
+	AutoPrefix = "auto-"
+	// DefaultKeep is how many automatic backups are kept
+	DefaultKeep = 10
+	// TimestampFormat is used in backup file names
+	TimestampFormat = "20060102-150405"
+)
+
+// SettingsFiles are the files in the data directory saved as settings
+var SettingsFiles = []string{"config.yaml"}
+
+// Archive is a self-describing snapshot of the host database
+type Archive struct {
+	Format    string                 `json:"format"`
+	Version   int                    `json:"version"`
+	CreatedAt time.Time              `json:"created_at"`
+	Reason    string                 `json:"reason,omitempty"` // Set for automatic backups
+	Hosts     []*domain.Host         `json:"hosts"`
+	Tags      []string               `json:"tags"` // All tags in use, for reference
+	History   []*domain.HistoryEntry `json:"history"`
+	Settings  map[string]string      `json:"settings,omitempty"` // File name -> content
+}
+
+// NewArchive creates an empty archive stamped with the current format
+func NewArchive() *Archive {
+	return &Archive{
+		Format:    FormatName,
+		Version:   FormatVersion,
+		CreatedAt: time.Now(),
+		Settings:  make(map[string]string),
+	}
+}
+
+// Write encodes the archive as indented JSON
+func Write(w io.Writer, archive *Archive) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+// Read decodes and validates an archive
+func Read(r io.Reader) (*Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("invalid backup file: %w", err)
+	}
+
+	if archive.Format != FormatName {
+		return nil, fmt.Errorf("not an sshm backup (format %q)", archive.Format)
+	}
+	if archive.Version < 1 || archive.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported backup version %d (this sshm supports up to %d)", archive.Version, FormatVersion)
+	}
+	if archive.Settings == nil {
+		archive.Settings = make(map[string]string)
+	}
+
+	return &archive, nil
+}
+
+// WriteFile writes the archive to path, gzip-compressed if path ends in .gz
+func WriteFile(path string, archive *Archive) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+	if strings.HasSuffix(path, ".gz") {
+		gz := gzip.NewWriter(file)
+		defer gz.Close()
+		w = gz
+	}
+
+	if err := Write(w, archive); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads an archive from path, transparently handling gzip
+func ReadFile(path string) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip backup: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return Read(r)
+}
+
+// GetBackupDir returns the backup directory inside a data directory
+func GetBackupDir(dataDir string) string {
+	return filepath.Join(dataDir, "backups")
+}
+
+// FileName returns a timestamped backup file name
+func FileName(prefix string) string {
+	return fmt.Sprintf("%s%s.json", prefix, time.Now().Format(TimestampFormat))
+}
+
+// AutoFileName returns the file name of an automatic backup taken for reason
+func AutoFileName(reason string) string {
+	slug := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, reason)
+	if slug == "" {
+		return FileName(AutoPrefix)
+	}
+	return FileName(AutoPrefix + slug + "-")
+}
+
+// List returns backup files in dir, newest first
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{filepath.Join(dir, entry.Name()), info.ModTime()})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path > files[j].path
+		}
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// Rotate removes the oldest files in dir starting with prefix, keeping the newest keep
+func Rotate(dir, prefix string, keep int) error {
+	paths, err := List(dir)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, path := range paths {
+		if !strings.HasPrefix(filepath.Base(path), prefix) {
+			continue
+		}
+		kept++
+		if kept <= keep {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	archive := NewArchive()
+	archive.Hosts = []*domain.Host{{Name: "web", Hostname: "web.example.com", Port: 22, Username: "deploy"}}
+	archive.History = []*domain.HistoryEntry{{HostName: "web", ConnectedAt: time.Now().UTC().Truncate(time.Second)}}
+	archive.Settings["config.yaml"] = "default_port: 22\n"
+
+	var buf bytes.Buffer
+	if err := Write(&buf, archive); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(got.Hosts) != 1 || got.Hosts[0].Hostname != "web.example.com" {
+		t.Errorf("hosts not restored: %+v", got.Hosts)
+	}
+	if len(got.History) != 1 || !got.History[0].ConnectedAt.Equal(archive.History[0].ConnectedAt) {
+		t.Errorf("history not restored: %+v", got.History)
+	}
+	if got.Settings["config.yaml"] != "default_port: 22\n" {
+		t.Errorf("settings not restored: %+v", got.Settings)
+	}
+}
+
+func TestReadRejectsForeignFiles(t *testing.T) {
+	if _, err := Read(bytes.NewBufferString(`{"format":"other","version":1}`)); err == nil {
+		t.Error("expected error for foreign format")
+	}
+	if _, err := Read(bytes.NewBufferString(`{"format":"sshm-backup","version":99}`)); err == nil {
+		t.Error("expected error for newer version")
+	}
+}
+
+func TestRotateKeepsNewest(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	for i, name := range []string{"auto-a.json", "auto-b.json", "auto-c.json", "sshm-backup-x.json"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		mtime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Rotate(dir, AutoPrefix, 2); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "auto-a.json")); !os.IsNotExist(err) {
+		t.Error("oldest automatic backup should be removed")
+	}
+	for _, name := range []string{"auto-b.json", "auto-c.json", "sshm-backup-x.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should be kept: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/levanduy/ssh_management/pkg/ssh"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentLifetime string
+	agentConfirm  bool
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Show and load keys in ssh-agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listAgentKeys()
+	},
+}
+
+var agentListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the keys loaded in ssh-agent and the hosts using them",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listAgentKeys()
+	},
+}
+
+var agentAddCmd = &cobra.Command{
+	Use:   "add <key|host>",
+	Short: "Load a key, or the key of a host, into ssh-agent",
+	Long: `Load a key into ssh-agent with ssh-add. The argument is a key in ~/.ssh,
+a key path, or the name of a host whose key should be loaded.
+
+The lifetime and confirm defaults come from the agent.lifetime and
+agent.confirm settings.`,
+	Example: `  sshm agent add id_work
+  sshm agent add web01 --lifetime 1h --confirm`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyPath, err := agentKeyPath(args[0])
+		if err != nil {
+			return err
+		}
+
+		lifetime := appConfig.Agent.LifetimeDuration()
+		if cmd.Flags().Changed("lifetime") {
+			if lifetime, err = time.ParseDuration(agentLifetime); err != nil {
+				return fmt.Errorf("invalid lifetime %q (use e.g. 30m or 8h)", agentLifetime)
+			}
+		}
+		confirm := appConfig.Agent.Confirm
+		if cmd.Flags().Changed("confirm") {
+			confirm = agentConfirm
+		}
+
+		if err := ssh.AddToAgent(keyPath, lifetime, confirm); err != nil {
+			return err
+		}
+		if lifetime > 0 {
+			fmt.Printf("✅ Loaded %s for %s\n", keyPath, lifetime)
+		} else {
+			fmt.Printf("✅ Loaded %s\n", keyPath)
+		}
+		return nil
+	},
+}
+
+func init() {
+	agentAddCmd.Flags().StringVarP(&agentLifetime, "lifetime", "t", "", "Remove the key from the agent after this long, e.g. 1h")
+	agentAddCmd.Flags().BoolVarP(&agentConfirm, "confirm", "c", false, "Make the agent ask before each use of the key")
+
+	agentCmd.AddCommand(agentListCmd, agentAddCmd)
+	rootCmd.AddCommand(agentCmd)
+}
+
+func listAgentKeys() error {
+	entries, err := hostService.ListAgentKeys()
+	if errors.Is(err, ssh.ErrNoAgent) {
+		fmt.Println("No ssh-agent is running (SSH_AUTH_SOCK is not set)")
+		fmt.Println("💡 Start one with: eval \"$(ssh-agent)\"")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("ssh-agent has no keys loaded")
+		fmt.Println("💡 Load one with: sshm agent add <key|host>")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tFINGERPRINT\tCOMMENT\tHOSTS")
+	for _, entry := range entries {
+		name := ""
+		var hostNames []string
+		if entry.Local != nil {
+			name = entry.Local.Key.Name
+			for _, host := range entry.Local.Hosts {
+				hostNames = append(hostNames, host.Name)
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			valueOrDash(name),
+			formatKeyType(entry.Key.Type, entry.Key.Bits),
+			entry.Key.Fingerprint,
+			valueOrDash(entry.Key.Comment),
+			valueOrDash(strings.Join(hostNames, ", ")),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// OpenSSH servers allow 6 authentication attempts by default
+	if len(entries) > 5 {
+		fmt.Printf("\n⚠️  %d keys are offered to every server, which can fail with \"Too many authentication failures\".\n", len(entries))
+		fmt.Println("💡 Turn on IdentitiesOnly for hosts with a key: sshm identity <host> --identities-only")
+	}
+	return nil
+}
+
+// agentKeyPath resolves a key name, key path or host name to a private key path
+func agentKeyPath(arg string) (string, error) {
+	if entry, err := hostService.FindKey(arg); err == nil {
+		if !entry.Key.HasPrivate() {
+			return "", fmt.Errorf("private key for %s not found", entry.Key.Name)
+		}
+		return entry.Key.Path, nil
+	}
+
+	host, err := hostService.GetHostByName(arg)
+	if err != nil {
+		return "", fmt.Errorf("%s is neither a key nor a host", arg)
+	}
+	if host.KeyPath == "" {
+		return "", fmt.Errorf("%s has no key set (pick one with K in the TUI or sshm keys deploy)", host.Name)
+	}
+	return host.KeyPath, nil
+}
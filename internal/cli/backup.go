@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/backup"
+	"github.com/levanduy/ssh_management/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreMode   string
+	restoreDryRun bool
+	restoreYes    bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [file]",
+	Short: "Back up hosts, tags, history and settings",
+	Long: `Write a versioned JSON backup of the host database. Without a file
+argument the backup is stored in ~/.sshm/backups. Use "-" to write to
+stdout and a .gz suffix to compress the file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 && args[0] == "-" {
+			archive, err := hostService.CreateBackup()
+			if err != nil {
+				return err
+			}
+			return backup.Write(os.Stdout, archive)
+		}
+
+		var path string
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		path, err := hostService.SaveBackup(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("💾 Backup written to %s\n", path)
+		return nil
+	},
+}
+
+var backupListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List backups in ~/.sshm/backups",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := backup.List(hostService.GetBackupDir())
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			fmt.Println("No backups found")
+			return nil
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			fmt.Printf("%s  %s\n", info.ModTime().Format("2006-01-02 15:04:05"), filepath.Base(path))
+		}
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore hosts from a backup",
+	Long: `Restore a backup created with "sshm backup".
+
+Modes:
+  merge    add hosts missing locally and update changed ones (default)
+  replace  make the database match the backup, removing other hosts
+
+A relative file name is also looked up in ~/.sshm/backups. An automatic
+backup is taken before any change is applied.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		if _, err := os.Stat(path); err != nil && !filepath.IsAbs(path) {
+			candidate := filepath.Join(hostService.GetBackupDir(), path)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+			}
+		}
+
+		archive, err := backup.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		mode := service.RestoreMode(restoreMode)
+		report, err := hostService.RestoreBackup(archive, mode, true)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Backup from %s (%d hosts, %d history entries)\n\n",
+			archive.CreatedAt.Format("2006-01-02 15:04:05"), len(archive.Hosts), len(archive.History))
+		printRestoreReport(report)
+
+		if restoreDryRun || !restoreHasChanges(report) {
+			return nil
+		}
+
+		if !restoreYes && !confirm(fmt.Sprintf("Apply these changes (%s mode)?", mode)) {
+			fmt.Println("Restore cancelled")
+			return nil
+		}
+
+		report, err = hostService.RestoreBackup(archive, mode, false)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Restore complete (previous state saved to %s)\n", report.SafetyBackup)
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreMode, "mode", string(service.RestoreMerge), "Restore mode: merge or replace")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "Show the changes without applying them")
+	restoreCmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "Apply without asking for confirmation")
+
+	backupCmd.AddCommand(backupListCmd)
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+}
+
+func printRestoreReport(report *service.RestoreReport) {
+	for _, name := range report.Added {
+		fmt.Printf("  + %s\n", name)
+	}
+	for _, change := range report.Updated {
+		fmt.Printf("  ~ %s\n", change.Name)
+		for _, field := range change.Changes {
+			fmt.Printf("      %s: %q → %q\n", field.Field, field.Old, field.New)
+		}
+	}
+	for _, name := range report.Removed {
+		fmt.Printf("  - %s\n", name)
+	}
+	for _, name := range report.Settings {
+		fmt.Printf("  ⚙ %s\n", name)
+	}
+
+	fmt.Printf("\n%d to add, %d to update, %d to remove, %d unchanged",
+		len(report.Added), len(report.Updated), len(report.Removed), report.Unchanged)
+	fmt.Printf("; history: +%d", report.HistoryAdded)
+	if report.HistoryRemoved > 0 {
+		fmt.Printf(" -%d", report.HistoryRemoved)
+	}
+	fmt.Println()
+}
+
+func restoreHasChanges(report *service.RestoreReport) bool {
+	return len(report.Added) > 0 || len(report.Updated) > 0 || len(report.Removed) > 0 ||
+		report.HistoryAdded > 0 || report.HistoryRemoved > 0 || len(report.Settings) > 0
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s (y/N) ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/levanduy/ssh_management/internal/config"
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change sshm settings",
+	Long: `Show and change settings stored in ~/.sshm/config.yaml, or in the
+workspace's own config.yaml when a workspace other than "default" is active.
+
+Settings are layered: built-in defaults, then the config file, then
+environment variables (e.g. SSHM_DEFAULT_USER, SSHM_DISCOVERY_SOURCES),
+then command-line flags. SSHM_CONFIG selects a different config file
+for the default workspace.`,
+	Args: cobra.NoArgs,
+	// Config commands must work even when the config file is invalid,
+	// so they skip the config and database loading done by the root command
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return resolveWorkspace()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listConfig()
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "Show all settings with their effective values",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listConfig()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <setting>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(workspace)
+		if err != nil {
+			return err
+		}
+		value, err := config.Get(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <setting> <value>",
+	Short: "Change a setting in the config file (lists are comma separated)",
+	Example: `  sshm config set default_user deploy
+  sshm config set discovery.sources known_hosts,ssh_config
+  sshm config set keymap.delete d,x
+  sshm config set keymap.delete ""    # back to the built-in keys`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := workspace.ConfigPath()
+		cfg, err := config.LoadFile(workspace)
+		if err != nil {
+			return err
+		}
+		if err := config.Set(cfg, args[0], args[1]); err != nil {
+			return err
+		}
+		if err := config.Save(path, cfg); err != nil {
+			return err
+		}
+
+		value, _ := config.Get(cfg, args[0])
+		fmt.Printf("✅ %s = %s\n", args[0], value)
+		if env := config.EnvName(args[0]); os.Getenv(env) != "" {
+			fmt.Printf("⚠️  %s is set and overrides this value\n", env)
+		}
+		return nil
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $EDITOR and validate it on save",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := workspace.ConfigPath()
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			if err := config.Save(path, workspace.Defaults()); err != nil {
+				return err
+			}
+		}
+
+		for {
+			if err := runEditor(path); err != nil {
+				return err
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			cfg := workspace.Defaults()
+			err = config.Decode(data, cfg)
+			if err == nil {
+				err = config.Validate(cfg)
+			}
+			if err == nil {
+				fmt.Printf("✅ Saved %s\n", path)
+				return nil
+			}
+
+			fmt.Printf("❌ Invalid configuration: %v\n", err)
+			if !confirm("Edit again?") {
+				return fmt.Errorf("%s is invalid; sshm will refuse to start until it is fixed", path)
+			}
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd, configEditCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func listConfig() error {
+	cfg, err := config.Load(workspace)
+	if err != nil {
+		return err
+	}
+	file, err := config.LoadFile(workspace)
+	if err != nil {
+		return err
+	}
+	defaults := workspace.Defaults()
+
+	fmt.Printf("Workspace:   %s\n", workspace.Name)
+	fmt.Printf("Config file: %s\n\n", workspace.ConfigPath())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, key := range config.Keys() {
+		value, _ := config.Get(cfg, key)
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, valueOrDash(value), configSource(key, file, defaults))
+	}
+	return w.Flush()
+}
+
+// configSource reports which layer a setting's effective value comes from
+func configSource(key string, file, defaults *domain.Config) string {
+	if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+		return "env " + config.EnvName(key)
+	}
+	fileValue, _ := config.Get(file, key)
+	defaultValue, _ := config.Get(defaults, key)
+	if fileValue != defaultValue {
+		return "file"
+	}
+	return "default"
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	return nil
+}
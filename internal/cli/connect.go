@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var connectCmd = &cobra.Command{
+	Use:   "connect <host>",
+	Short: "Connect to a host without opening the TUI",
+	Long: `Connect to a host by name, the same way as pressing Enter in the TUI:
+the session is logged in the history, recorded when enabled, and the
+pre- and post-connect hooks matching the host run around it.`,
+	Example: `  sshm connect web01`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := hostService.GetHostByName(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🔗 Connecting to %s (%s@%s)...\n", host.Name, host.Username, host.Hostname)
+		return hostService.Connect(host)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(connectCmd)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var connectorClear bool
+
+var connectorCmd = &cobra.Command{
+	Use:   "connector <host> [system|native]",
+	Short: "Show or set the SSH client used to connect to a host",
+	Long: `Show or set the SSH client backend used for a host:
+
+  system  runs the ssh binary, so ~/.ssh/config and all OpenSSH options apply
+  native  built-in Go client with agent and key authentication, known_hosts
+          verification, keepalives and jump hosts; ~/.ssh/config is not read
+
+Hosts without their own choice use the "connector" setting
+(sshm config set connector native).`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := hostService.GetHostByName(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 && !connectorClear {
+			if host.Connector == "" {
+				fmt.Printf("%s uses the default connector (%s)\n", host.Name, appConfig.Connector)
+			} else {
+				fmt.Printf("%s uses the %s connector\n", host.Name, host.Connector)
+			}
+			return nil
+		}
+
+		name := ""
+		if !connectorClear {
+			name = args[1]
+		}
+		if err := hostService.SetConnector(host, name); err != nil {
+			return err
+		}
+
+		if name == "" {
+			fmt.Printf("✅ %s now uses the default connector (%s)\n", host.Name, appConfig.Connector)
+		} else {
+			fmt.Printf("✅ %s now uses the %s connector\n", host.Name, name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	connectorCmd.Flags().BoolVar(&connectorClear, "clear", false, "Use the default connector again")
+	rootCmd.AddCommand(connectorCmd)
+}
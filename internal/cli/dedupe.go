@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/levanduy/ssh_management/internal/dedupe"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dedupeDryRun bool
+	dedupeYes    bool
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find and merge hosts that are the same machine",
+	Long: `Find hosts that are likely the same machine stored more than once, for
+example as its FQDN, as its IP address and as [host]:2222. Hosts are compared
+by hostname, resolved IP address, short name, port and user; the short
+name only counts when neither host resolves.
+
+Each group is merged into its most used host: fields it lacks are filled in
+from the others, tags are combined, and usage counts and history are moved
+to it. To pick each value yourself, press m in the TUI instead.
+
+Shared hosts are never merged; change them in the shared inventory file.`,
+	Example: `  sshm dedupe --dry-run
+  sshm dedupe --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clusters, err := hostService.FindDuplicates()
+		if err != nil {
+			return err
+		}
+		if len(clusters) == 0 {
+			fmt.Println("✅ No duplicate hosts found")
+			return nil
+		}
+
+		fmt.Printf("Found %d group(s) of likely duplicates\n", len(clusters))
+		merged := 0
+		for _, cluster := range clusters {
+			fmt.Println()
+			printCluster(cluster)
+
+			if dedupeDryRun {
+				continue
+			}
+			keep, remove := cluster.Merge(cluster.Defaults())
+			if !dedupeYes && !confirm(fmt.Sprintf("Merge into %s?", keep.Name)) {
+				fmt.Println("Skipped")
+				continue
+			}
+			safetyBackup, err := hostService.MergeHosts(keep, remove)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("🔀 Merged %d host(s) into %s (safety backup: %s)\n", len(remove), keep.Name, safetyBackup)
+			merged++
+		}
+
+		fmt.Println()
+		if dedupeDryRun {
+			fmt.Println("💡 Dry run: nothing was changed")
+		} else {
+			fmt.Printf("✅ Merged %d of %d group(s)\n", merged, len(clusters))
+		}
+		return nil
+	},
+}
+
+func init() {
+	dedupeCmd.Flags().BoolVar(&dedupeDryRun, "dry-run", false, "Only report the duplicates")
+	dedupeCmd.Flags().BoolVarP(&dedupeYes, "yes", "y", false, "Merge every group without asking")
+	rootCmd.AddCommand(dedupeCmd)
+}
+
+// printCluster shows a group of duplicates and the host they would merge into
+func printCluster(cluster *dedupe.Cluster) {
+	fmt.Printf("🔍 %s confidence (%d): %s\n", dedupe.Confidence(cluster.Score), cluster.Score,
+		strings.Join(cluster.Reasons, "; "))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ID\tNAME\tHOSTNAME\tPORT\tUSER\tUSES")
+	for _, host := range cluster.Hosts {
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%d\t%s\t%d\n", host.ID, host.Name, host.Hostname, host.Port, host.Username, host.UseCount)
+	}
+	w.Flush()
+
+	keep, _ := cluster.Merge(cluster.Defaults())
+	fmt.Printf("  → %s: %s", keep.Name, keep.Endpoint())
+	if keep.Tags != "" {
+		fmt.Printf(" [%s]", keep.Tags)
+	}
+	fmt.Println()
+}
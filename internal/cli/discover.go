@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/discovery"
+	"github.com/levanduy/ssh_management/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	discoverDryRun  bool
+	discoverSources []string
+	discoverYes     bool
+)
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Find hosts on this machine and review them before adding",
+	Long: `Run the discovery sources and show which hosts would be added and which
+existing hosts would be updated, then apply the changes after confirming.
+
+By default the sources enabled in the config are run (discovery.sources).
+Use --source to run others, for example etc_hosts, which is off by default.
+Shared hosts are never changed by discovery.
+
+Available sources:
+` + discoverySourceList(),
+	Example: `  sshm discover --dry-run
+  sshm discover --source ssh_config,known_hosts
+  sshm discover --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sources []string
+		if cmd.Flags().Changed("source") {
+			sources = discoverSources
+		}
+		plan, err := hostService.PlanDiscovery(sources)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🔍 Sources: %s\n", strings.Join(plan.Sources, ", "))
+		for _, err := range plan.Errors {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		if plan.IsEmpty() {
+			fmt.Println("✅ Discovery found nothing new")
+			return nil
+		}
+		return reviewDiscoveryPlan(plan, discoverDryRun, discoverYes)
+	},
+}
+
+func init() {
+	discoverCmd.Flags().BoolVar(&discoverDryRun, "dry-run", false, "Only show what would change")
+	discoverCmd.Flags().StringSliceVar(&discoverSources, "source", nil, "Sources to run (default: the configured ones)")
+	discoverCmd.Flags().BoolVarP(&discoverYes, "yes", "y", false, "Apply the changes without asking")
+	rootCmd.AddCommand(discoverCmd)
+}
+
+// reviewDiscoveryPlan prints a plan's changes and applies them after
+// confirming, unless dryRun is set
+func reviewDiscoveryPlan(plan *service.DiscoveryPlan, dryRun, yes bool) error {
+	fmt.Println()
+	for _, add := range plan.Add {
+		host := add.Host
+		fmt.Printf("  + %s (%s) %s\n", host.Name, host.Endpoint(), strings.Join(add.Sources, ", "))
+		if add.Wanted != "" {
+			fmt.Printf("      %s clashes with another host\n", add.Wanted)
+		}
+	}
+	for _, update := range plan.Update {
+		fmt.Printf("  ~ %s\n", update.Host.Name)
+		for _, change := range update.Changes {
+			fmt.Printf("      %s: %q → %q\n", change.Field, change.Old, change.New)
+		}
+	}
+	fmt.Printf("\n%d to add, %d to update\n", len(plan.Add), len(plan.Update))
+
+	if dryRun {
+		fmt.Println("💡 Dry run: nothing was changed")
+		return nil
+	}
+	if !yes && !confirm("Apply these changes?") {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	report := hostService.ApplyDiscovery(plan)
+	for _, err := range report.Failed {
+		fmt.Printf("❌ %v\n", err)
+	}
+	fmt.Println("✅ " + report.Summary())
+	return nil
+}
+
+// discoverySourceList describes the registered sources, one per line
+func discoverySourceList() string {
+	var b strings.Builder
+	for _, source := range discovery.Sources() {
+		fmt.Fprintf(&b, "  %-20s %s\n", source.Name, source.Description)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/levanduy/ssh_management/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorFix     bool
+	doctorSkipDNS bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Audit SSH keys, permissions, hosts and known_hosts",
+	Long: `Check the whole SSH setup for problems and suggest fixes:
+- keys referenced by hosts: missing files, loose permissions, weak
+  algorithms (DSA, RSA under 2048 bits) and keys without a passphrase
+- permissions of ~/.ssh and ~/.sshm
+- duplicate hosts and hostnames that do not resolve
+- known_hosts inconsistencies
+
+Use --fix to apply the fixes that are safe to automate (file permissions).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		findings, err := hostService.RunDoctor(service.DoctorOptions{SkipDNS: doctorSkipDNS})
+		if err != nil {
+			return err
+		}
+
+		if len(findings) == 0 {
+			fmt.Println("✅ No problems found")
+			return nil
+		}
+
+		counts := make(map[service.Severity]int)
+		fixed := 0
+		for _, finding := range findings {
+			fmt.Printf("%s [%s] %s: %s\n", severityIcon(finding.Severity), finding.Check, finding.Subject, finding.Message)
+
+			if doctorFix && finding.Fixable() {
+				if err := finding.Fix(); err != nil {
+					fmt.Printf("   ❌ Fix failed: %v\n", err)
+				} else {
+					fmt.Printf("   🔧 Fixed: %s\n", finding.Suggestion)
+					fixed++
+					continue
+				}
+			}
+
+			counts[finding.Severity]++
+			if finding.Suggestion != "" {
+				hint := finding.Suggestion
+				if finding.Fixable() {
+					hint += " (or run 'sshm doctor --fix')"
+				}
+				fmt.Printf("   💡 %s\n", hint)
+			}
+		}
+
+		fmt.Printf("\n%d error(s), %d warning(s), %d info", counts[service.SeverityError], counts[service.SeverityWarning], counts[service.SeverityInfo])
+		if fixed > 0 {
+			fmt.Printf(", %d fixed", fixed)
+		}
+		fmt.Println()
+
+		if counts[service.SeverityError] > 0 {
+			return fmt.Errorf("doctor found %d error(s)", counts[service.SeverityError])
+		}
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Apply safe fixes automatically")
+	doctorCmd.Flags().BoolVar(&doctorSkipDNS, "skip-dns", false, "Skip hostname resolution checks")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func severityIcon(severity service.Severity) string {
+	switch severity {
+	case service.SeverityError:
+		return "❌"
+	case service.SeverityWarning:
+		return "⚠️ "
+	default:
+		return "ℹ️ "
+	}
+}
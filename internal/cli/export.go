@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+	exportTag    string
+	exportList   bool
+	exportHost   string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export hosts for other tools",
+}
+
+var exportAnsibleCmd = &cobra.Command{
+	Use:   "ansible",
+	Short: "Export hosts as an Ansible inventory",
+	Long: `Export hosts as an Ansible inventory. Hosts are grouped by their tags and
+group; ansible_host prefers the stored IP address and jump hosts become
+ansible_ssh_common_args.
+
+With --list or --host the command behaves as a dynamic inventory script.
+Save this as an executable file and pass it to ansible with -i:
+
+  #!/bin/sh
+  exec sshm export ansible "$@"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inventory, err := hostService.AnsibleInventory(exportTag)
+		if err != nil {
+			return err
+		}
+
+		// Dynamic inventory mode always writes JSON to stdout
+		if exportList {
+			return inventory.WriteList(os.Stdout)
+		}
+		if cmd.Flags().Changed("host") {
+			return inventory.WriteHost(os.Stdout, exportHost)
+		}
+
+		var w io.Writer = os.Stdout
+		if exportOutput != "" && exportOutput != "-" {
+			file, err := os.OpenFile(exportOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+		}
+
+		switch exportFormat {
+		case "ini":
+			err = inventory.WriteINI(w)
+		case "yaml", "yml":
+			err = inventory.WriteYAML(w)
+		default:
+			return fmt.Errorf("unknown format %q (use ini or yaml)", exportFormat)
+		}
+		if err != nil {
+			return err
+		}
+
+		if w != os.Stdout {
+			fmt.Printf("✅ Wrote Ansible inventory to %s\n", exportOutput)
+		}
+		return nil
+	},
+}
+
+func init() {
+	exportAnsibleCmd.Flags().StringVarP(&exportFormat, "format", "f", "ini", "Inventory format: ini or yaml")
+	exportAnsibleCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+	exportAnsibleCmd.Flags().StringVar(&exportTag, "tag", "", "Only export hosts with this tag")
+	exportAnsibleCmd.Flags().BoolVar(&exportList, "list", false, "Dynamic inventory: print all groups and host variables as JSON")
+	exportAnsibleCmd.Flags().StringVar(&exportHost, "host", "", "Dynamic inventory: print the variables of one host as JSON")
+
+	exportCmd.AddCommand(exportAnsibleCmd)
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var familyCmd = &cobra.Command{
+	Use:   "family <host> [any|inet|inet6]",
+	Short: "Show or set whether a host is reached over IPv4 or IPv6",
+	Long: `Show or set the address family used to connect to a host, like ssh's
+AddressFamily option:
+
+  any    use whichever address the resolver prefers (default)
+  inet   IPv4 only (ssh -4)
+  inet6  IPv6 only (ssh -6)
+
+The host's cached IP address is looked up again in the chosen family.`,
+	Example: `  sshm family web1 inet6
+  sshm family web1 any`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := hostService.GetHostByName(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			fmt.Printf("%s uses address family %s\n", host.Name, familyName(host.AddressFamily))
+			return nil
+		}
+
+		family := args[1]
+		if family == "any" {
+			family = ""
+		}
+		if err := hostService.SetAddressFamily(host, family); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s now uses address family %s\n", host.Name, familyName(family))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(familyCmd)
+}
+
+// familyName describes an address family, "any" when unset
+func familyName(family string) string {
+	if family == "" {
+		return "any"
+	}
+	return family
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+	"github.com/spf13/cobra"
+)
+
+var (
+	identityForwardAgent   bool
+	identityIdentitiesOnly bool
+)
+
+var identityCmd = &cobra.Command{
+	Use:   "identity <host>",
+	Short: "Show or set how a host authenticates and uses ssh-agent",
+	Long: `Show a host's key, whether it is loaded in ssh-agent, and its agent options:
+
+  --forward-agent    make the local agent available on the host (ssh -A)
+  --identities-only  offer only the host's key instead of every agent key,
+                     avoiding "Too many authentication failures"`,
+	Example: `  sshm identity web01 --identities-only
+  sshm identity web01 --forward-agent=false`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := hostService.GetHostByName(args[0])
+		if err != nil {
+			return err
+		}
+
+		var forwardAgent, identitiesOnly *bool
+		if cmd.Flags().Changed("forward-agent") {
+			forwardAgent = &identityForwardAgent
+		}
+		if cmd.Flags().Changed("identities-only") {
+			identitiesOnly = &identityIdentitiesOnly
+		}
+		if forwardAgent != nil || identitiesOnly != nil {
+			if err := hostService.SetAgentOptions(host, forwardAgent, identitiesOnly); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Updated %s\n", host.Name)
+		}
+
+		printIdentity(host)
+		return nil
+	},
+}
+
+func init() {
+	identityCmd.Flags().BoolVar(&identityForwardAgent, "forward-agent", false, "Forward the local ssh-agent to the host")
+	identityCmd.Flags().BoolVar(&identityIdentitiesOnly, "identities-only", false, "Offer only the host's own key")
+	rootCmd.AddCommand(identityCmd)
+}
+
+func printIdentity(host *domain.Host) {
+	fmt.Printf("Host:            %s\n", host.Name)
+	fmt.Printf("Key:             %s\n", valueOrDash(host.KeyPath))
+	fmt.Printf("In agent:        %s\n", agentStatus(host))
+	fmt.Printf("Forward agent:   %s\n", yesNo(host.ForwardAgent))
+	fmt.Printf("Identities only: %s\n", yesNo(host.IdentitiesOnly))
+	if host.IdentitiesOnly && host.KeyPath == "" {
+		fmt.Println("⚠️  IdentitiesOnly has no effect until the host has a key")
+	}
+}
+
+func agentStatus(host *domain.Host) string {
+	if host.KeyPath == "" {
+		return "-"
+	}
+	status, err := hostService.AgentKeyStatus([]*domain.Host{host})
+	if errors.Is(err, ssh.ErrNoAgent) {
+		return "no agent running"
+	}
+	if err != nil {
+		return "unknown (" + err.Error() + ")"
+	}
+	loaded, ok := status[host.KeyPath]
+	switch {
+	case !ok:
+		return "unknown (cannot read key)"
+	case loaded:
+		return "yes"
+	}
+	return fmt.Sprintf("no (load it with: sshm agent add %s)", host.Name)
+}
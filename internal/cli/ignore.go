@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var ignoreNote string
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage hosts that discovery must not add",
+	Long: `Keep hosts out of discovery, for example hosts you deleted whose
+known_hosts line is still there. Every discovery source honors the list.
+
+A pattern is one of:
+  web1                 an exact host name, hostname or IP address
+  *.lab.example.com    a hostname glob (* and ? wildcards)
+  10.0.0.0/8           a CIDR, matched against discovered IP addresses
+
+Patterns are checked against the name sshm would give a host, its hostname
+and its IP address. Hosts already in sshm are not affected. Deleting a host
+in the TUI can add it to the list too ("delete and ignore").`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listIgnoreRules()
+	},
+}
+
+var ignoreAddCmd = &cobra.Command{
+	Use:   "add <pattern>...",
+	Short: "Add patterns to the ignore list",
+	Example: `  sshm ignore add old-db.example.com
+  sshm ignore add '*.lab.example.com' 192.168.56.0/24 --note "vagrant boxes"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, pattern := range args {
+			if err := hostService.AddIgnoreRule(pattern, ignoreNote); err != nil {
+				return err
+			}
+			fmt.Printf("🚫 Ignoring %s\n", pattern)
+		}
+		return nil
+	},
+}
+
+var ignoreListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the ignore list",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listIgnoreRules()
+	},
+}
+
+var ignoreRemoveCmd = &cobra.Command{
+	Use:     "remove <pattern>...",
+	Aliases: []string{"rm"},
+	Short:   "Let discovery find hosts matching the patterns again",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, pattern := range args {
+			if err := hostService.RemoveIgnoreRule(pattern); err != nil {
+				return err
+			}
+			fmt.Printf("✅ No longer ignoring %s\n", pattern)
+		}
+		return nil
+	},
+}
+
+func init() {
+	ignoreAddCmd.Flags().StringVar(&ignoreNote, "note", "", "Why the pattern is ignored")
+	ignoreCmd.AddCommand(ignoreAddCmd, ignoreListCmd, ignoreRemoveCmd)
+	rootCmd.AddCommand(ignoreCmd)
+}
+
+func listIgnoreRules() error {
+	rules, err := hostService.IgnoreRules()
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		fmt.Println("No ignored hosts. Add one with: sshm ignore add <pattern>")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATTERN\tADDED\tNOTE")
+	for _, rule := range rules {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", rule.Pattern, rule.CreatedAt.Format("2006-01-02"), valueOrDash(rule.Note))
+	}
+	return w.Flush()
+}
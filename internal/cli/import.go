@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/levanduy/ssh_management/internal/importer"
+	"github.com/levanduy/ssh_management/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFormat   string
+	importConflict string
+	importDryRun   bool
+	importYes      bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|dir>...",
+	Short: "Import hosts from other SSH managers and inventories",
+	Long: `Import hosts from exports of other tools. The format is detected
+automatically unless --format is given. Directories are scanned for files
+(useful for ~/.local/share/remmina).
+
+Imported hosts are tagged with their source, e.g. "putty-imported".
+Hosts imported from Terraform state or vagrant ssh-config before are
+updated with their new addresses when the file is imported again; they
+are recognized by resource address or Vagrant project and machine;
+the project is the directory of the Vagrantfile the file is saved next
+to when the machine's key does not tell it.
+A preview is shown before anything is written.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := expandImportPaths(args)
+		if err != nil {
+			return err
+		}
+
+		// Entries are planned per source rather than per file, so that names
+		// in one-host-per-file formats (Remmina) are checked against each other
+		var sources []string
+		entriesBySource := make(map[string][]*importer.Entry)
+		for _, file := range files {
+			entries, parser, err := importer.ParseFile(file, importFormat)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				continue
+			}
+			if _, ok := entriesBySource[parser.Name()]; !ok {
+				sources = append(sources, parser.Name())
+			}
+			entriesBySource[parser.Name()] = append(entriesBySource[parser.Name()], entries...)
+		}
+
+		var plans []*service.ImportPlan
+		for _, source := range sources {
+			plan, err := hostService.PlanImport(entriesBySource[source], source, service.ImportConflict(importConflict))
+			if err != nil {
+				return err
+			}
+			plans = append(plans, plan)
+		}
+
+		if len(plans) == 0 {
+			fmt.Println("No SSH hosts found to import")
+			return nil
+		}
+
+		toWrite := 0
+		for _, plan := range plans {
+			printImportPlan(plan)
+			toWrite += plan.Count(service.ImportActionAdd) + plan.Count(service.ImportActionRename) + plan.Count(service.ImportActionUpdate)
+		}
+
+		if importDryRun || toWrite == 0 {
+			return nil
+		}
+		if !importYes && !confirm(fmt.Sprintf("Import %d host(s)?", toWrite)) {
+			fmt.Println("Import cancelled")
+			return nil
+		}
+
+		totalAdded, totalUpdated := 0, 0
+		for _, plan := range plans {
+			added, updated, errs := hostService.ApplyImport(plan)
+			totalAdded += added
+			totalUpdated += updated
+			for _, err := range errs {
+				fmt.Printf("   ❌ %v\n", err)
+			}
+		}
+		fmt.Printf("📥 Imported %d new host(s), updated %d\n", totalAdded, totalUpdated)
+		return nil
+	},
+}
+
+func init() {
+	var formats []string
+	for _, parser := range importer.Parsers() {
+		formats = append(formats, fmt.Sprintf("%s (%s)", parser.Name(), parser.Description()))
+	}
+
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "Input format: "+strings.Join(formats, ", "))
+	importCmd.Flags().StringVar(&importConflict, "on-conflict", string(service.ImportSkip), "When a name exists: skip, rename or update")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show the preview without importing")
+	importCmd.Flags().BoolVarP(&importYes, "yes", "y", false, "Import without asking for confirmation")
+	rootCmd.AddCommand(importCmd)
+}
+
+// expandImportPaths replaces directories with the regular files they contain
+func expandImportPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+func printImportPlan(plan *service.ImportPlan) {
+	fmt.Printf("Source: %s (tag %q)\n\n", plan.Source, service.SourceTag(plan.Source))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tNAME\tTARGET\tGROUP\tNOTE")
+	for _, item := range plan.Items {
+		host := item.Host
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			item.Action, host.Name, host.Endpoint(), valueOrDash(host.Group), item.Note)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d to add, %d renamed, %d to update, %d skipped\n\n",
+		plan.Count(service.ImportActionAdd), plan.Count(service.ImportActionRename),
+		plan.Count(service.ImportActionUpdate), plan.Count(service.ImportActionSkip))
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var jumpClear bool
+
+var jumpCmd = &cobra.Command{
+	Use:   "jump <host> [jump-host[,jump-host...]]",
+	Short: "Show or set the jump host (ProxyJump) used to reach a host",
+	Long: `Show or set the jump hosts used to reach a host. Each hop is either the
+name of another sshm host or an ssh destination such as user@bastion:2222.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := hostService.GetHostByName(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 && !jumpClear {
+			if host.ProxyJump == "" {
+				fmt.Printf("%s is reached directly\n", host.Name)
+				return nil
+			}
+			resolved, err := hostService.ResolveProxyJump(host)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s is reached via %s\n", host.Name, host.ProxyJump)
+			if resolved != host.ProxyJump {
+				fmt.Printf("   ssh -J %s\n", resolved)
+			}
+			return nil
+		}
+
+		spec := ""
+		if !jumpClear {
+			spec = args[1]
+		}
+		if err := hostService.SetProxyJump(host, spec); err != nil {
+			return err
+		}
+
+		if spec == "" {
+			fmt.Printf("✅ %s is now reached directly\n", host.Name)
+		} else {
+			fmt.Printf("✅ %s is now reached via %s\n", host.Name, host.ProxyJump)
+		}
+		return nil
+	},
+}
+
+func init() {
+	jumpCmd.Flags().BoolVar(&jumpClear, "clear", false, "Remove the jump host")
+	rootCmd.AddCommand(jumpCmd)
+}
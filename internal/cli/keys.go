@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	keyComment       string
+	keyAskPassphrase bool
+	deployHosts      []string
+	deployTags       []string
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "List and manage SSH keys in ~/.ssh",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listKeys()
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List SSH keys and the hosts using them",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listKeys()
+	},
+}
+
+var keysShowCmd = &cobra.Command{
+	Use:   "show <key>",
+	Short: "Show details of an SSH key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entry, err := hostService.FindKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		key := entry.Key
+		fmt.Printf("Name:        %s\n", key.Name)
+		fmt.Printf("Type:        %s\n", formatKeyType(key.Type, key.Bits))
+		fmt.Printf("Fingerprint: %s\n", valueOrDash(key.Fingerprint))
+		fmt.Printf("Comment:     %s\n", valueOrDash(key.Comment))
+		fmt.Printf("Private key: %s\n", valueOrDash(key.Path))
+		fmt.Printf("Public key:  %s\n", valueOrDash(key.PublicKeyPath))
+		fmt.Printf("Passphrase:  %s\n", yesNo(key.Encrypted))
+
+		if len(entry.Hosts) == 0 {
+			fmt.Println("Used by:     no hosts")
+			return nil
+		}
+		fmt.Println("Used by:")
+		for _, host := range entry.Hosts {
+			fmt.Printf("  • %s (%s)\n", host.Name, host.Endpoint())
+		}
+		return nil
+	},
+}
+
+var keysGenerateCmd = &cobra.Command{
+	Use:   "generate <name>",
+	Short: "Generate a new ed25519 key in ~/.ssh",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var passphrase string
+		if keyAskPassphrase {
+			var err error
+			passphrase, err = readNewPassphrase()
+			if err != nil {
+				return err
+			}
+		}
+
+		key, err := hostService.GenerateKey(args[0], keyComment, passphrase)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🔑 Generated %s key %s\n", formatKeyType(key.Type, key.Bits), key.Path)
+		fmt.Printf("   Fingerprint: %s\n", key.Fingerprint)
+		return nil
+	},
+}
+
+var keysDeployCmd = &cobra.Command{
+	Use:   "deploy <key>",
+	Short: "Install a public key on hosts and switch them to key-based login",
+	Long: `Append the public key to ~/.ssh/authorized_keys on each selected host,
+fixing directory and file permissions as needed. Hosts that already have the
+key are left unchanged. After deployment, key-based login is verified in
+batch mode and the host's key path is updated on success.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(deployHosts) == 0 && len(deployTags) == 0 {
+			return fmt.Errorf("select hosts with --host or --tag")
+		}
+
+		entry, err := hostService.FindKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		hosts, err := selectHosts(deployHosts, deployTags)
+		if err != nil {
+			return err
+		}
+		if len(hosts) == 0 {
+			return fmt.Errorf("no hosts matched")
+		}
+
+		failed := 0
+		for _, host := range hosts {
+			fmt.Printf("🔑 Deploying %s to %s (%s@%s)\n", entry.Key.Name, host.Name, host.Username, host.Hostname)
+			added, err := hostService.DeployKey(host, entry.Key)
+			if err != nil {
+				fmt.Printf("   ❌ %v\n", err)
+				failed++
+				continue
+			}
+			if added {
+				fmt.Println("   ✅ Key added and login verified")
+			} else {
+				fmt.Println("   ✅ Key already present, login verified")
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("deployment failed on %d of %d host(s)", failed, len(hosts))
+		}
+		return nil
+	},
+}
+
+func init() {
+	keysDeployCmd.Flags().StringSliceVar(&deployHosts, "host", nil, "Host name to deploy to (repeatable)")
+	keysDeployCmd.Flags().StringSliceVar(&deployTags, "tag", nil, "Deploy to all hosts with this tag (repeatable)")
+
+	keysGenerateCmd.Flags().StringVarP(&keyComment, "comment", "C", defaultKeyComment(), "Key comment")
+	keysGenerateCmd.Flags().BoolVarP(&keyAskPassphrase, "passphrase", "P", false, "Prompt for a passphrase to protect the key")
+
+	keysCmd.AddCommand(keysListCmd, keysShowCmd, keysGenerateCmd, keysDeployCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+func listKeys() error {
+	entries, err := hostService.ListKeys()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No SSH keys found in ~/.ssh")
+		fmt.Println("💡 Create one with: sshm keys generate id_ed25519")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tFINGERPRINT\tPASSPHRASE\tCOMMENT\tHOSTS")
+	for _, entry := range entries {
+		key := entry.Key
+		var hostNames []string
+		for _, host := range entry.Hosts {
+			hostNames = append(hostNames, host.Name)
+		}
+
+		name := key.Name
+		if !key.HasPrivate() {
+			name += " (public only)"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			name,
+			formatKeyType(key.Type, key.Bits),
+			key.Fingerprint,
+			yesNo(key.Encrypted),
+			valueOrDash(key.Comment),
+			valueOrDash(strings.Join(hostNames, ", ")),
+		)
+	}
+	return w.Flush()
+}
+
+// selectHosts resolves host names and tags into a de-duplicated host list
+func selectHosts(names, tags []string) ([]*domain.Host, error) {
+	var hosts []*domain.Host
+	seen := make(map[int]bool)
+
+	for _, name := range names {
+		host, err := hostService.GetHostByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[host.ID] {
+			seen[host.ID] = true
+			hosts = append(hosts, host)
+		}
+	}
+
+	for _, tag := range tags {
+		tagged, err := hostService.GetHostsByTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range tagged {
+			if !seen[host.ID] {
+				seen[host.ID] = true
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	return hosts, nil
+}
+
+func readNewPassphrase() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("a terminal is required to enter a passphrase")
+	}
+
+	fmt.Print("Enter passphrase: ")
+	first, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Print("Confirm passphrase: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	if string(first) != string(second) {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return string(first), nil
+}
+
+func defaultKeyComment() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return getCurrentUsername()
+	}
+	return getCurrentUsername() + "@" + hostname
+}
+
+func getCurrentUsername() string {
+	if appConfig != nil && appConfig.DefaultUser != "" {
+		return appConfig.DefaultUser
+	}
+	if username := os.Getenv("USER"); username != "" {
+		return username
+	}
+	if username := os.Getenv("USERNAME"); username != "" {
+		return username
+	}
+	return "user" // Fallback
+}
+
+func formatKeyType(keyType string, bits int) string {
+	if keyType == "" {
+		return "-"
+	}
+	if bits > 0 {
+		return fmt.Sprintf("%s-%d", keyType, bits)
+	}
+	return keyType
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record <host> [on|off]",
+	Short: "Show or set whether sessions to a host are recorded",
+	Long: `Show or set whether interactive sessions to a host are recorded.
+
+Recordings are asciicast v2 files in the recordings directory, linked to the
+session's history entry; play them back with "sshm replay". To record every
+session, turn recording on globally (sshm config set recording.enabled true).
+
+Recordings capture everything shown in the terminal, including any secrets
+displayed during the session. They are only readable by you.`,
+	Example: `  sshm record db01 on
+  sshm record db01 off`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, err := hostService.GetHostByName(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 2 {
+			var record bool
+			switch args[1] {
+			case "on":
+				record = true
+			case "off":
+				record = false
+			default:
+				return fmt.Errorf("expected on or off, got %q", args[1])
+			}
+			if err := hostService.SetRecord(host, record); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Updated %s\n", host.Name)
+		}
+
+		switch {
+		case host.Record:
+			fmt.Printf("Sessions to %s are recorded\n", host.Name)
+		case appConfig.Recording.Enabled:
+			fmt.Printf("Sessions to %s are recorded (recording is on for all hosts)\n", host.Name)
+		default:
+			fmt.Printf("Sessions to %s are not recorded\n", host.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+}
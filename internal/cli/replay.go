@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/recording"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replaySpeed   float64
+	replayMaxIdle time.Duration
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [id]",
+	Short: "List recorded sessions or play one back",
+	Long: `Without arguments, list the recorded sessions from the history.
+With a history ID, play that session back in the terminal.
+
+The files are standard asciicast v2 recordings, so asciinema can play them too.`,
+	Example: `  sshm replay
+  sshm replay 42 --speed 2 --max-idle 1s`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return listRecordings()
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid history ID %q", args[0])
+		}
+		if replaySpeed <= 0 {
+			return fmt.Errorf("speed must be greater than 0")
+		}
+		entry, err := hostService.GetHistoryEntry(id)
+		if err != nil {
+			return err
+		}
+		if entry.RecordingPath == "" {
+			return fmt.Errorf("session %d to %s was not recorded", entry.ID, entry.HostName)
+		}
+		rec, err := recording.ReadFile(entry.RecordingPath)
+		if err != nil {
+			return fmt.Errorf("cannot read recording: %w", err)
+		}
+
+		fmt.Printf("▶️  Replaying session to %s from %s (%s)\n\n",
+			entry.HostName, entry.ConnectedAt.Local().Format("2006-01-02 15:04:05"), formatDuration(rec.Duration()))
+		if err := recording.Play(os.Stdout, rec, recording.PlayOptions{Speed: replaySpeed, MaxIdle: replayMaxIdle}); err != nil {
+			return err
+		}
+		fmt.Printf("\n\n⏹️  End of recording\n")
+		return nil
+	},
+}
+
+func init() {
+	replayCmd.Flags().Float64VarP(&replaySpeed, "speed", "s", 1, "Playback speed (2 plays twice as fast)")
+	replayCmd.Flags().DurationVar(&replayMaxIdle, "max-idle", 0, "Shorten pauses longer than this (e.g. 2s)")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func listRecordings() error {
+	entries, err := hostService.GetHistory(0, 0)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	found := false
+	for _, entry := range entries {
+		if entry.RecordingPath == "" {
+			continue
+		}
+		if !found {
+			fmt.Fprintln(w, "ID\tHOST\tDATE\tDURATION\tFILE")
+			found = true
+		}
+		duration := "missing"
+		if rec, err := recording.ReadFile(entry.RecordingPath); err == nil {
+			duration = formatDuration(rec.Duration())
+		} else if !os.IsNotExist(err) {
+			duration = "unreadable"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", entry.ID, entry.HostName,
+			entry.ConnectedAt.Local().Format("2006-01-02 15:04:05"), duration, filepath.Base(entry.RecordingPath))
+	}
+	w.Flush()
+
+	if !found {
+		fmt.Println("No recorded sessions")
+		fmt.Println("💡 Record a host's sessions with: sshm record <host> on")
+	}
+	return nil
+}
+
+// formatDuration rounds a duration to whole seconds for display
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
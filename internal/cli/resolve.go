@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	resolveForce   bool
+	resolveHistory int
+)
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve [host]",
+	Short: "Look up host addresses and show the address cache",
+	Long: `Look up the hostnames whose cached addresses expired and show every
+A and AAAA record of each host. Lookups run concurrently, each limited by
+resolver.timeout; results are reused for resolver.ttl, and the TUI
+refreshes expired ones in the background.
+
+When a hostname's addresses change, the change is recorded; give a host
+name to see where it pointed and when it moved.`,
+	Example: `  sshm resolve                  # Refresh expired lookups and list all hosts
+  sshm resolve --force          # Look up every hostname now
+  sshm resolve web1             # Addresses and address history of web1`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			return showHostAddresses(args[0])
+		}
+
+		report, err := hostService.RefreshAddresses(resolveForce)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🌐 %s\n", report.Summary())
+		if len(report.Failed) > 0 {
+			fmt.Printf("⚠️  Not resolved: %s\n", strings.Join(report.Failed, ", "))
+		}
+		fmt.Println()
+		return listHostAddresses()
+	},
+}
+
+func init() {
+	resolveCmd.Flags().BoolVarP(&resolveForce, "force", "f", false, "Look up every hostname, even if its cached addresses are fresh")
+	resolveCmd.Flags().IntVar(&resolveHistory, "history", 10, "Number of address changes to show for a host")
+	rootCmd.AddCommand(resolveCmd)
+}
+
+func listHostAddresses() error {
+	hosts, err := hostService.GetAllHosts()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOSTNAME\tIP ADDRESS\tALL ADDRESSES\tLOOKED UP\tSTATUS")
+	for _, host := range hosts {
+		record, err := hostService.DNSRecord(host)
+		if err != nil {
+			return err
+		}
+		all, lookedUp, status := "-", "-", "-"
+		if record != nil {
+			all = valueOrDash(strings.Join(record.Addresses, ", "))
+			lookedUp = record.ResolvedAt.Format("2006-01-02 15:04")
+			status = "ok"
+			if record.Error != "" {
+				status = record.Error
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", host.Name, host.Hostname, valueOrDash(host.IPAddress), all, lookedUp, status)
+	}
+	return w.Flush()
+}
+
+func showHostAddresses(name string) error {
+	host, err := hostService.GetHostByName(name)
+	if err != nil {
+		return err
+	}
+	record, err := hostService.DNSRecord(host)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Host:       %s (%s)\n", host.Name, host.Hostname)
+	fmt.Printf("IP address: %s (family %s)\n", valueOrDash(host.IPAddress), familyName(host.AddressFamily))
+	if record == nil {
+		fmt.Println("Not looked up yet. Run: sshm resolve")
+		return nil
+	}
+	fmt.Printf("Addresses:  %s\n", valueOrDash(strings.Join(record.Addresses, ", ")))
+	fmt.Printf("Looked up:  %s, refreshed after %s\n", record.ResolvedAt.Format("2006-01-02 15:04:05"), record.ExpiresAt.Format("2006-01-02 15:04:05"))
+	if record.Error != "" {
+		fmt.Printf("Last error: %s\n", record.Error)
+	}
+
+	changes, err := hostService.AddressHistory(host, resolveHistory)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SINCE\tADDRESSES\tBEFORE")
+	for _, change := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", change.ChangedAt.Format("2006-01-02 15:04"), strings.Join(change.Addresses, ", "), valueOrDash(strings.Join(change.Previous, ", ")))
+	}
+	return w.Flush()
+}
@@ -3,8 +3,11 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/levanduy/ssh_management/internal/config"
+	"github.com/levanduy/ssh_management/internal/domain"
 	"github.com/levanduy/ssh_management/internal/repo"
 	"github.com/levanduy/ssh_management/internal/service"
 	"github.com/levanduy/ssh_management/internal/ui"
@@ -14,6 +17,9 @@ import (
 var (
 	dbPath        string
 	hostService   *service.HostService
+	appConfig     *domain.Config
+	workspace     *config.Workspace
+	workspaceName string
 	autoDiscovery bool = true // Enable auto-discovery by default
 )
 
@@ -21,16 +27,21 @@ var rootCmd = &cobra.Command{
 	Use:   "sshm",
 	Short: "SSH Manager - TUI-based SSH host management",
 	Long: `SSH Manager (sshm) is a terminal-based tool for managing SSH connections.
-It automatically discovers SSH hosts from ~/.ssh/known_hosts and provides
-an interactive TUI interface for browsing and connecting to hosts.
+It automatically discovers SSH hosts from known_hosts, ~/.ssh/config and
+shell history, and provides an interactive TUI interface for browsing and
+connecting to hosts.
 
 Features:
-- Auto-discovery from ~/.ssh/known_hosts
+- Auto-discovery from known_hosts, ssh config and shell history
 - Interactive TUI for browsing hosts
 - Quick SSH connection with usage tracking
 - Lightweight and simple`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(cmd); err != nil {
+			return err
+		}
 		initializeService()
+		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Auto-discovery: Check for new SSH hosts automatically
@@ -49,8 +60,50 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&dbPath, "db", service.GetDefaultDatabasePath(), "Database file path")
-	rootCmd.PersistentFlags().BoolVar(&autoDiscovery, "auto-discovery", true, "Enable automatic SSH host discovery from known_hosts")
+	// Errors are printed once by Execute; usage is only shown for flag errors
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	rootCmd.PersistentFlags().StringVarP(&workspaceName, "workspace", "w", "", "Workspace to use instead of the current one")
+	rootCmd.PersistentFlags().StringVar(&dbPath, "db", config.GetDefaultDatabasePath(), "Database file path (overrides database_path in config)")
+	rootCmd.PersistentFlags().BoolVar(&autoDiscovery, "auto-discovery", true, "Enable automatic SSH host discovery")
+}
+
+// resolveWorkspace selects the workspace from --workspace, SSHM_WORKSPACE or the saved current one
+func resolveWorkspace() error {
+	ws, missing, err := config.CurrentWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	if missing != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  Current workspace %q no longer exists, using %q (switch with: sshm workspace use <name>)\n", missing, ws.Name)
+	}
+	workspace = ws
+	return nil
+}
+
+// loadConfig layers the workspace config file and SSHM_* variables under any flags given
+func loadConfig(cmd *cobra.Command) error {
+	if err := resolveWorkspace(); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(workspace)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w\n💡 Fix it with: sshm config edit", err)
+	}
+
+	if cmd.Flags().Changed("db") {
+		cfg.DatabasePath = dbPath
+	}
+	if cmd.Flags().Changed("auto-discovery") {
+		cfg.Discovery.Enabled = autoDiscovery
+	}
+	dbPath = cfg.DatabasePath
+	autoDiscovery = cfg.Discovery.Enabled
+
+	appConfig = cfg
+	return nil
 }
 
 func initializeService() {
@@ -60,29 +113,19 @@ func initializeService() {
 		os.Exit(1)
 	}
 
-	hostService = service.NewHostService(repo)
+	hostService = service.NewHostService(repo, filepath.Dir(dbPath), appConfig)
 }
 
-// autoDiscoverHosts automatically discovers SSH hosts from known_hosts
+// autoDiscoverHosts adds hosts found by the enabled discovery sources
 func autoDiscoverHosts() {
 	if !autoDiscovery {
 		return // Auto-discovery disabled
 	}
 
-	// Count hosts before discovery
-	hostsBefore, _ := hostService.GetAllHosts()
-	beforeCount := len(hostsBefore)
-
-	// Detect from known_hosts - silent mode
-	detectFromSSHFilesSilent()
-
-	// Count hosts after discovery and show notification if new hosts found
-	hostsAfter, _ := hostService.GetAllHosts()
-	afterCount := len(hostsAfter)
-
-	if afterCount > beforeCount {
-		newHosts := afterCount - beforeCount
-		fmt.Printf("🔍 Auto-discovered %d new SSH host(s)\n", newHosts)
+	// Same pipeline as the TUI refresh; errors are ignored in silent mode
+	report, err := hostService.AutoDiscover()
+	if err == nil && report.HasChanges() {
+		fmt.Printf("🔍 Auto-discovery: %s\n", report.Summary())
 	}
 }
 
@@ -100,7 +143,7 @@ func launchTUI() {
 		fmt.Println("")
 		fmt.Println("No SSH hosts found yet.")
 		fmt.Println("💡 Connect to some SSH hosts first, then run 'sshm' again.")
-		fmt.Println("   SSH Manager will auto-discover hosts from ~/.ssh/known_hosts")
+		fmt.Println("   SSH Manager will auto-discover hosts from ~/.ssh/known_hosts and ~/.ssh/config")
 		return
 	}
 
@@ -109,7 +152,7 @@ func launchTUI() {
 }
 
 func launchTUIInterface() {
-	model := ui.NewModel(hostService)
+	model := ui.NewModel(hostService, workspace.Name)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/scan"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scanPorts   string
+	scanRate    int
+	scanTimeout time.Duration
+	scanWorkers int
+	scanResolve bool
+	scanDryRun  bool
+	scanYes     bool
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan <cidr|range|address>...",
+	Short: "Find SSH servers on a network and add them as hosts",
+	Long: `Probe a network for SSH servers, for lab networks whose machines are in
+no known_hosts file yet. Each port is connected to and the identification
+line every SSH server sends first is read; nothing is logged into.
+
+Targets are addresses, CIDRs (10.20.0.0/24) or ranges (10.20.0.10-50 or
+10.20.0.10-10.20.0.50), at most 65536 addresses per scan. Probes run
+concurrently, started at no more than --rate per second.
+
+Responding servers are then offered as new hosts, tagged ssh-scanned and
+with their software version, e.g. OpenSSH_9.6p1. Hosts already in sshm
+and hosts on the ignore list are not added again.
+
+Only scan networks you are allowed to.`,
+	Example: `  sshm scan 10.20.0.0/24 --ports 22,2222
+  sshm scan 192.168.56.10-30 --resolve --dry-run
+  sshm scan 127.0.0.1 --ports 2200-2210 --yes`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addrs, err := scan.ParseTargets(args)
+		if err != nil {
+			return err
+		}
+		ports, err := scan.ParsePorts(scanPorts)
+		if err != nil {
+			return err
+		}
+		if scanRate < 0 || scanWorkers < 1 || scanTimeout <= 0 {
+			return fmt.Errorf("--rate must not be negative, --workers must be positive and --timeout must be set")
+		}
+
+		scanner := scan.New()
+		scanner.Rate = scanRate
+		scanner.Workers = scanWorkers
+		scanner.Timeout = scanTimeout
+		scanner.Resolve = scanResolve
+
+		// Ctrl-C stops probing and keeps what was found so far
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		fmt.Printf("📡 Scanning %d address(es) on port(s) %s\n", len(addrs), scanPorts)
+		start := time.Now()
+		results := scanner.Scan(ctx, addrs, ports)
+		stop()
+		fmt.Printf("Found %d SSH server(s) in %s\n", len(results), time.Since(start).Round(time.Millisecond))
+		if len(results) == 0 {
+			return nil
+		}
+
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tPORT\tNAME\tSOFTWARE\tBANNER")
+		for _, result := range results {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", result.Address, result.Port, valueOrDash(result.Name), valueOrDash(result.Software), strings.TrimSpace(result.Banner))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		plan, err := hostService.PlanScan(results)
+		if err != nil {
+			return err
+		}
+		if plan.IsEmpty() {
+			fmt.Println("\n✅ Every server found is already in sshm or ignored")
+			return nil
+		}
+		return reviewDiscoveryPlan(plan, scanDryRun, scanYes)
+	},
+}
+
+func init() {
+	scanCmd.Flags().StringVarP(&scanPorts, "ports", "p", "22", "Ports to probe, e.g. 22,2222 or 2200-2210")
+	scanCmd.Flags().IntVar(&scanRate, "rate", scan.DefaultRate, "Probes started per second (0 for no limit)")
+	scanCmd.Flags().DurationVar(&scanTimeout, "timeout", scan.DefaultTimeout, "Time allowed to connect and read the banner")
+	scanCmd.Flags().IntVar(&scanWorkers, "workers", scan.DefaultWorkers, "Probes running at once")
+	scanCmd.Flags().BoolVar(&scanResolve, "resolve", false, "Look up reverse DNS names and use them as hostnames")
+	scanCmd.Flags().BoolVar(&scanDryRun, "dry-run", false, "Only show what would be added")
+	scanCmd.Flags().BoolVarP(&scanYes, "yes", "y", false, "Add the hosts without asking")
+	rootCmd.AddCommand(scanCmd)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/levanduy/ssh_management/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var syncDryRun bool
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Pull the team-shared inventory and update shared hosts",
+	Long: `Update shared hosts from the inventory file set with
+'sshm config set shared.path <file>'. The file's git checkout is pulled
+first unless shared.pull is false.
+
+Shared hosts are read-only: discovery and imports never change them and they
+are deleted by removing them from the file. Your own user, key and
+favorites for a shared host are kept as personal overlays.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if appConfig.Shared.Path == "" {
+			return fmt.Errorf("no shared inventory configured\n💡 Set one with: sshm config set shared.path ~/team/infra/hosts.yaml")
+		}
+
+		report, err := hostService.SyncShared(syncDryRun)
+		if err != nil {
+			return err
+		}
+
+		if report.Pulled != "" {
+			fmt.Printf("🔄 %s\n", report.Pulled)
+		}
+		fmt.Printf("Shared inventory: %s\n\n", report.Source)
+		printSyncReport(report)
+
+		switch {
+		case syncDryRun:
+			fmt.Println("\n💡 Dry run: nothing was pulled or changed")
+		case report.HasChanges():
+			fmt.Printf("\n✅ Shared hosts updated (safety backup: %s)\n", report.SafetyBackup)
+		default:
+			fmt.Println("\n✅ Shared hosts are up to date")
+		}
+		return nil
+	},
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Show what would change without pulling or writing")
+	rootCmd.AddCommand(syncCmd)
+}
+
+func printSyncReport(report *service.SyncReport) {
+	for _, name := range report.Added {
+		fmt.Printf("  + %s\n", name)
+	}
+	for _, name := range report.Adopted {
+		fmt.Printf("  ⇄ %s (local host now shared)\n", name)
+	}
+	for _, change := range report.Updated {
+		fmt.Printf("  ~ %s\n", change.Name)
+		for _, field := range change.Changes {
+			fmt.Printf("      %s: %q → %q\n", field.Field, field.Old, field.New)
+		}
+	}
+	for _, name := range report.Removed {
+		fmt.Printf("  - %s\n", name)
+	}
+
+	if report.HasChanges() {
+		fmt.Println()
+	}
+	fmt.Printf("%d added, %d adopted, %d changed, %d removed, %d unchanged\n",
+		len(report.Added), len(report.Adopted), len(report.Updated), len(report.Removed), report.Unchanged)
+}
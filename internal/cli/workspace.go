@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/levanduy/ssh_management/internal/config"
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workspaceUseAfterCreate bool
+	workspaceCreateFrom     string
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:     "workspace",
+	Aliases: []string{"ws"},
+	Short:   "Manage workspaces with separate host databases",
+	Long: `Workspaces keep separate host inventories, e.g. work, personal and
+client-x. Each has its own database, config and backups under
+~/.sshm/workspaces/<name>; the "default" workspace lives in ~/.sshm.
+
+The current workspace is remembered between runs. Override it for a single
+command with --workspace or SSHM_WORKSPACE.`,
+	Args: cobra.NoArgs,
+	// Workspace commands do not open a database
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return resolveWorkspace()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listWorkspaces()
+	},
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List workspaces",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listWorkspaces()
+	},
+}
+
+var workspaceCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a workspace",
+	Long: `Create a workspace with default settings. Discovery is off in new
+workspaces and no shared inventory is attached, so hosts of this machine
+and the team stay out of them. --from copies all settings of another
+workspace instead.`,
+	Example: `  sshm workspace create client-x --use
+  sshm workspace create work-eu --from work`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var base *domain.Config
+		if workspaceCreateFrom != "" {
+			from, err := config.FindWorkspace(workspaceCreateFrom)
+			if err != nil {
+				return err
+			}
+			if base, err = config.LoadFile(from); err != nil {
+				return err
+			}
+		}
+
+		created, err := config.CreateWorkspace(args[0], base)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Created workspace %s in %s\n", created.Name, created.Dir)
+
+		if !workspaceUseAfterCreate {
+			fmt.Printf("💡 Switch to it with: sshm workspace use %s\n", created.Name)
+			return nil
+		}
+		if err := config.UseWorkspace(created); err != nil {
+			return err
+		}
+		fmt.Printf("🔀 Now using workspace %s\n", created.Name)
+		return nil
+	},
+}
+
+var workspaceUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the current workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := config.FindWorkspace(args[0])
+		if err != nil {
+			return err
+		}
+		if err := config.UseWorkspace(target); err != nil {
+			return err
+		}
+		fmt.Printf("🔀 Now using workspace %s\n", target.Name)
+		if os.Getenv(config.EnvWorkspace) != "" {
+			fmt.Printf("⚠️  %s is set and overrides the current workspace\n", config.EnvWorkspace)
+		}
+		return nil
+	},
+}
+
+func init() {
+	workspaceCreateCmd.Flags().BoolVar(&workspaceUseAfterCreate, "use", false, "Switch to the new workspace")
+	workspaceCreateCmd.Flags().StringVar(&workspaceCreateFrom, "from", "", "Copy the settings of this workspace")
+
+	workspaceCmd.AddCommand(workspaceListCmd, workspaceCreateCmd, workspaceUseCmd)
+	rootCmd.AddCommand(workspaceCmd)
+}
+
+func listWorkspaces() error {
+	workspaces, err := config.ListWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tNAME\tHOSTS\tDATABASE")
+	for _, ws := range workspaces {
+		marker := ""
+		if ws.Name == workspace.Name {
+			marker = "*"
+		}
+
+		database := ws.DatabasePath()
+		if cfg, err := config.LoadFile(ws); err == nil {
+			database = cfg.DatabasePath
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, ws.Name, countHosts(database), database)
+	}
+	return w.Flush()
+}
+
+// countHosts reports the number of hosts in a database without creating it
+func countHosts(database string) string {
+	if _, err := os.Stat(database); err != nil {
+		return "0"
+	}
+	count, err := repo.CountHosts(database)
+	if err != nil {
+		return "?"
+	}
+	return fmt.Sprint(count)
+}
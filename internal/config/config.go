@@ -0,0 +1,538 @@
+// Package config loads sshm settings from ~/.sshm/config.yaml.
+//
+// Values are layered: built-in defaults, then the config file, then SSHM_*
+// environment variables, then command-line flags (applied by the CLI).
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/discovery"
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileName is the config file inside the sshm directory
+	FileName = "config.yaml"
+	// EnvPrefix starts every environment variable that overrides a setting
+	EnvPrefix = "SSHM_"
+	// EnvConfig selects a different config file
+	EnvConfig = EnvPrefix + "CONFIG"
+)
+
+// DiscoverySources are the registered places auto-discovery can read hosts from
+var DiscoverySources = discovery.Names()
+
+// Themes are the built-in TUI color schemes; auto picks dark or light to
+// suit the terminal background
+var Themes = []string{"auto", "dark", "light", "high-contrast"}
+
+// KeymapActions are the TUI actions whose keys can be rebound
+var KeymapActions = []string{"search", "connect", "delete", "set_key", "favorite", "merge", "discover", "refresh", "details", "back", "quit"}
+
+// GetDefaultDir returns the directory holding the database, config and backups
+func GetDefaultDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".sshm"
+	}
+	return filepath.Join(homeDir, ".sshm")
+}
+
+// GetDefaultDatabasePath returns the default database file path
+func GetDefaultDatabasePath() string {
+	return filepath.Join(GetDefaultDir(), "hosts.db")
+}
+
+// GetDefaultPath returns the config file path, honoring SSHM_CONFIG
+func GetDefaultPath() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return expandHome(path)
+	}
+	return filepath.Join(GetDefaultDir(), FileName)
+}
+
+// Default returns the built-in configuration
+func Default() *domain.Config {
+	return &domain.Config{
+		DatabasePath: GetDefaultDatabasePath(),
+		DefaultPort:  22,
+		Discovery: domain.DiscoveryConfig{
+			Enabled: true,
+			Sources: discovery.DefaultNames(),
+			Naming:  discovery.NamingSuffix,
+		},
+		Shared:    domain.SharedConfig{Pull: true},
+		Connector: ssh.ConnectorSystem,
+		Agent:     domain.AgentConfig{OfferAdd: true},
+		Resolver:  domain.ResolverConfig{Timeout: "2s", TTL: "1h"},
+		Theme:     "auto",
+	}
+}
+
+// Load reads a workspace's config file over its defaults and applies
+// environment overrides. A missing file is not an error.
+func Load(workspace *Workspace) (*domain.Config, error) {
+	cfg, err := LoadFile(workspace)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadFile reads a workspace's config file over its defaults without
+// environment overrides, as needed when editing the file
+func LoadFile(workspace *Workspace) (*domain.Config, error) {
+	cfg := workspace.Defaults()
+	path := workspace.ConfigPath()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config: %w", err)
+	}
+
+	if err := Decode(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.DatabasePath = expandHome(cfg.DatabasePath)
+	cfg.Shared.Path = expandHome(cfg.Shared.Path)
+	return cfg, nil
+}
+
+// Decode parses YAML into cfg, rejecting unknown settings
+func Decode(data []byte, cfg *domain.Config) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// Save writes cfg to path, creating the directory if needed
+func Save(path string, cfg *domain.Config) error {
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+
+	// The default database path (next to the config) is left out so the file stays portable
+	stored := *cfg
+	if stored.DatabasePath == filepath.Join(filepath.Dir(path), "hosts.db") {
+		stored.DatabasePath = ""
+	}
+	data, err := yaml.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	header := "# sshm configuration - see 'sshm config --help'\n"
+	return os.WriteFile(path, append([]byte(header), data...), 0600)
+}
+
+// Validate checks that every setting has a usable value
+func Validate(cfg *domain.Config) error {
+	if cfg.DatabasePath == "" {
+		return fmt.Errorf("database_path must not be empty")
+	}
+	if cfg.DefaultPort < 1 || cfg.DefaultPort > 65535 {
+		return fmt.Errorf("default_port must be between 1 and 65535, got %d", cfg.DefaultPort)
+	}
+	for _, source := range cfg.Discovery.Sources {
+		if !contains(DiscoverySources, source) {
+			return fmt.Errorf("unknown discovery source %q (available: %s)", source, strings.Join(DiscoverySources, ", "))
+		}
+	}
+	if !contains(discovery.NamingStrategies, cfg.Discovery.Naming) {
+		return fmt.Errorf("unknown discovery.naming %q (available: %s)", cfg.Discovery.Naming, strings.Join(discovery.NamingStrategies, ", "))
+	}
+	if cfg.Discovery.Naming == discovery.NamingTemplate && cfg.Discovery.NameTemplate == "" {
+		return fmt.Errorf("discovery.name_template must be set to use the template naming strategy")
+	}
+	if cfg.Discovery.NameTemplate != "" {
+		if err := discovery.CheckTemplate(cfg.Discovery.NameTemplate); err != nil {
+			return fmt.Errorf("discovery.name_template: %w", err)
+		}
+	}
+	if !contains(ssh.Connectors, cfg.Connector) {
+		return fmt.Errorf("unknown connector %q (available: %s)", cfg.Connector, strings.Join(ssh.Connectors, ", "))
+	}
+	if cfg.Agent.Lifetime != "" {
+		if lifetime, err := time.ParseDuration(cfg.Agent.Lifetime); err != nil || lifetime < time.Second {
+			return fmt.Errorf("agent.lifetime must be a duration such as 30m or 8h, got %q", cfg.Agent.Lifetime)
+		}
+	}
+	if timeout, err := time.ParseDuration(cfg.Resolver.Timeout); err != nil || timeout < 100*time.Millisecond {
+		return fmt.Errorf("resolver.timeout must be a duration of at least 100ms, such as 2s, got %q", cfg.Resolver.Timeout)
+	}
+	if ttl, err := time.ParseDuration(cfg.Resolver.TTL); err != nil || ttl < time.Minute {
+		return fmt.Errorf("resolver.ttl must be a duration of at least 1m, such as 1h, got %q", cfg.Resolver.TTL)
+	}
+	for i, hook := range cfg.Hooks {
+		if strings.TrimSpace(hook.Pre) == "" && strings.TrimSpace(hook.Post) == "" {
+			return fmt.Errorf("hooks[%d] must have a pre or post command", i)
+		}
+	}
+	for _, name := range sortedKeys(cfg.Themes) {
+		if contains(Themes, name) {
+			return fmt.Errorf("themes.%s: built-in themes cannot be redefined, use another name with base: %s", name, name)
+		}
+		theme := cfg.Themes[name]
+		if theme.Base != "" && !contains(Themes, theme.Base) {
+			return fmt.Errorf("themes.%s.base must be a built-in theme (available: %s), got %q", name, strings.Join(Themes, ", "), theme.Base)
+		}
+		colors := theme.Colors()
+		for _, role := range sortedKeys(colors) {
+			if colors[role] != "" && !validColor(colors[role]) {
+				return fmt.Errorf("themes.%s.%s must be a #rrggbb color or an ANSI color number 0-255, got %q", name, role, colors[role])
+			}
+		}
+	}
+	if !contains(Themes, cfg.Theme) && !hasTheme(cfg.Themes, cfg.Theme) {
+		available := append(append([]string{}, Themes...), sortedKeys(cfg.Themes)...)
+		return fmt.Errorf("unknown theme %q (available: %s)", cfg.Theme, strings.Join(available, ", "))
+	}
+
+	boundTo := make(map[string]string)
+	for _, action := range sortedKeys(cfg.Keymap) {
+		if !contains(KeymapActions, action) {
+			return fmt.Errorf("unknown keymap action %q (available: %s)", action, strings.Join(KeymapActions, ", "))
+		}
+		if len(cfg.Keymap[action]) == 0 {
+			return fmt.Errorf("keymap.%s must list at least one key", action)
+		}
+		for _, k := range cfg.Keymap[action] {
+			if other, ok := boundTo[k]; ok {
+				return fmt.Errorf("key %q is bound to both %s and %s", k, other, action)
+			}
+			boundTo[k] = action
+		}
+	}
+	return nil
+}
+
+// setting is one dotted config key that can be read and written as a string
+type setting struct {
+	key string
+	get func(cfg *domain.Config) string
+	set func(cfg *domain.Config, value string) error
+}
+
+func settings() []setting {
+	list := []setting{
+		{
+			key: "database_path",
+			get: func(cfg *domain.Config) string { return cfg.DatabasePath },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.DatabasePath = expandHome(value)
+				return nil
+			},
+		},
+		{
+			key: "default_port",
+			get: func(cfg *domain.Config) string { return strconv.Itoa(cfg.DefaultPort) },
+			set: func(cfg *domain.Config, value string) error {
+				port, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("invalid port %q", value)
+				}
+				cfg.DefaultPort = port
+				return nil
+			},
+		},
+		{
+			key: "default_user",
+			get: func(cfg *domain.Config) string { return cfg.DefaultUser },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.DefaultUser = value
+				return nil
+			},
+		},
+		{
+			key: "discovery.enabled",
+			get: func(cfg *domain.Config) string { return strconv.FormatBool(cfg.Discovery.Enabled) },
+			set: func(cfg *domain.Config, value string) error {
+				enabled, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid boolean %q", value)
+				}
+				cfg.Discovery.Enabled = enabled
+				return nil
+			},
+		},
+		{
+			key: "discovery.sources",
+			get: func(cfg *domain.Config) string { return strings.Join(cfg.Discovery.Sources, ",") },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Discovery.Sources = splitList(value)
+				return nil
+			},
+		},
+		{
+			key: "discovery.naming",
+			get: func(cfg *domain.Config) string { return cfg.Discovery.Naming },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Discovery.Naming = value
+				return nil
+			},
+		},
+		{
+			key: "discovery.name_template",
+			get: func(cfg *domain.Config) string { return cfg.Discovery.NameTemplate },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Discovery.NameTemplate = value
+				return nil
+			},
+		},
+		{
+			key: "shared.path",
+			get: func(cfg *domain.Config) string { return cfg.Shared.Path },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Shared.Path = expandHome(value)
+				return nil
+			},
+		},
+		{
+			key: "shared.pull",
+			get: func(cfg *domain.Config) string { return strconv.FormatBool(cfg.Shared.Pull) },
+			set: func(cfg *domain.Config, value string) error {
+				pull, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid boolean %q", value)
+				}
+				cfg.Shared.Pull = pull
+				return nil
+			},
+		},
+		{
+			key: "connector",
+			get: func(cfg *domain.Config) string { return cfg.Connector },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Connector = value
+				return nil
+			},
+		},
+		{
+			key: "agent.offer_add",
+			get: func(cfg *domain.Config) string { return strconv.FormatBool(cfg.Agent.OfferAdd) },
+			set: func(cfg *domain.Config, value string) error {
+				offer, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid boolean %q", value)
+				}
+				cfg.Agent.OfferAdd = offer
+				return nil
+			},
+		},
+		{
+			key: "agent.lifetime",
+			get: func(cfg *domain.Config) string { return cfg.Agent.Lifetime },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Agent.Lifetime = value
+				return nil
+			},
+		},
+		{
+			key: "agent.confirm",
+			get: func(cfg *domain.Config) string { return strconv.FormatBool(cfg.Agent.Confirm) },
+			set: func(cfg *domain.Config, value string) error {
+				confirm, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid boolean %q", value)
+				}
+				cfg.Agent.Confirm = confirm
+				return nil
+			},
+		},
+		{
+			key: "recording.enabled",
+			get: func(cfg *domain.Config) string { return strconv.FormatBool(cfg.Recording.Enabled) },
+			set: func(cfg *domain.Config, value string) error {
+				enabled, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid boolean %q", value)
+				}
+				cfg.Recording.Enabled = enabled
+				return nil
+			},
+		},
+		{
+			key: "resolver.timeout",
+			get: func(cfg *domain.Config) string { return cfg.Resolver.Timeout },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Resolver.Timeout = value
+				return nil
+			},
+		},
+		{
+			key: "resolver.ttl",
+			get: func(cfg *domain.Config) string { return cfg.Resolver.TTL },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Resolver.TTL = value
+				return nil
+			},
+		},
+		{
+			key: "theme",
+			get: func(cfg *domain.Config) string { return cfg.Theme },
+			set: func(cfg *domain.Config, value string) error {
+				cfg.Theme = value
+				return nil
+			},
+		},
+	}
+
+	for _, action := range KeymapActions {
+		action := action
+		list = append(list, setting{
+			key: "keymap." + action,
+			get: func(cfg *domain.Config) string { return strings.Join(cfg.Keymap[action], ",") },
+			set: func(cfg *domain.Config, value string) error {
+				keys := splitList(value)
+				if len(keys) == 0 {
+					delete(cfg.Keymap, action) // Back to the built-in keys
+					return nil
+				}
+				if cfg.Keymap == nil {
+					cfg.Keymap = make(map[string][]string)
+				}
+				cfg.Keymap[action] = keys
+				return nil
+			},
+		})
+	}
+	return list
+}
+
+// Keys returns every setting name accepted by Get and Set
+func Keys() []string {
+	var keys []string
+	for _, s := range settings() {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// Get returns a setting as a string
+func Get(cfg *domain.Config, key string) (string, error) {
+	s, err := lookup(key)
+	if err != nil {
+		return "", err
+	}
+	return s.get(cfg), nil
+}
+
+// Set parses value into a setting. Lists are comma separated.
+func Set(cfg *domain.Config, key, value string) error {
+	s, err := lookup(key)
+	if err != nil {
+		return err
+	}
+	return s.set(cfg, value)
+}
+
+// EnvName returns the environment variable that overrides a setting,
+// e.g. SSHM_DISCOVERY_SOURCES for discovery.sources
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func applyEnv(cfg *domain.Config) error {
+	for _, s := range settings() {
+		name := EnvName(s.key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func lookup(key string) (setting, error) {
+	for _, s := range settings() {
+		if s.key == key {
+			return s, nil
+		}
+	}
+	return setting{}, fmt.Errorf("unknown setting %q (available: %s)", key, strings.Join(Keys(), ", "))
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, path[1:])
+		}
+	}
+	return path
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validColor reports whether a theme color is #rgb, #rrggbb or 0-255
+func validColor(color string) bool {
+	if strings.HasPrefix(color, "#") {
+		if len(color) != 4 && len(color) != 7 {
+			return false
+		}
+		_, err := strconv.ParseUint(color[1:], 16, 32)
+		return err == nil
+	}
+	n, err := strconv.Atoi(color)
+	return err == nil && n >= 0 && n <= 255
+}
+
+func hasTheme(themes map[string]domain.ThemeConfig, name string) bool {
+	_, ok := themes[name]
+	return ok
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+const (
+	// DefaultWorkspace keeps its database and config directly in ~/.sshm
+	DefaultWorkspace = "default"
+	// EnvWorkspace selects the workspace for a single command
+	EnvWorkspace = EnvPrefix + "WORKSPACE"
+
+	workspacesDir        = "workspaces"
+	currentWorkspaceFile = "current_workspace"
+)
+
+var workspaceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Workspace is a named, separate set of hosts with its own database and config
+type Workspace struct {
+	Name string
+	Dir  string
+}
+
+// IsDefault reports whether this is the workspace stored directly in ~/.sshm
+func (w *Workspace) IsDefault() bool {
+	return w.Name == DefaultWorkspace
+}
+
+// ConfigPath returns the workspace's config file
+func (w *Workspace) ConfigPath() string {
+	if w.IsDefault() {
+		return GetDefaultPath()
+	}
+	return filepath.Join(w.Dir, FileName)
+}
+
+// DatabasePath returns the workspace's default database file
+func (w *Workspace) DatabasePath() string {
+	return filepath.Join(w.Dir, "hosts.db")
+}
+
+// Defaults returns the built-in configuration for this workspace. Discovery
+// is off outside the default workspace: the known_hosts, history and
+// /etc/hosts it reads belong to this machine, not to a client's inventory.
+func (w *Workspace) Defaults() *domain.Config {
+	cfg := Default()
+	cfg.DatabasePath = w.DatabasePath()
+	if !w.IsDefault() {
+		cfg.Discovery.Enabled = false
+	}
+	return cfg
+}
+
+// GetWorkspace returns the named workspace without checking that it exists
+func GetWorkspace(name string) *Workspace {
+	if name == DefaultWorkspace {
+		return &Workspace{Name: name, Dir: GetDefaultDir()}
+	}
+	return &Workspace{Name: name, Dir: filepath.Join(GetDefaultDir(), workspacesDir, name)}
+}
+
+// ValidateWorkspaceName checks that a name can be used as a directory name
+func ValidateWorkspaceName(name string) error {
+	if !workspaceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid workspace name %q (use lowercase letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// ListWorkspaces returns the default workspace followed by all others, sorted
+func ListWorkspaces() ([]*Workspace, error) {
+	workspaces := []*Workspace{GetWorkspace(DefaultWorkspace)}
+
+	entries, err := os.ReadDir(filepath.Join(GetDefaultDir(), workspacesDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return workspaces, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateWorkspaceName(entry.Name()) == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		workspaces = append(workspaces, GetWorkspace(name))
+	}
+	return workspaces, nil
+}
+
+// FindWorkspace returns an existing workspace
+func FindWorkspace(name string) (*Workspace, error) {
+	workspace := GetWorkspace(name)
+	if workspace.IsDefault() {
+		return workspace, nil
+	}
+	if err := ValidateWorkspaceName(name); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(workspace.Dir); err != nil {
+		return nil, fmt.Errorf("workspace %q does not exist (create it with: sshm workspace create %s)", name, name)
+	}
+	return workspace, nil
+}
+
+// CreateWorkspace creates a workspace directory. The new workspace's config
+// starts with its defaults, or as a copy of base if it is not nil, with the
+// database path reset to the workspace's own.
+func CreateWorkspace(name string, base *domain.Config) (*Workspace, error) {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return nil, err
+	}
+	if name == DefaultWorkspace {
+		return nil, fmt.Errorf("the %q workspace always exists", DefaultWorkspace)
+	}
+
+	workspace := GetWorkspace(name)
+	if _, err := os.Stat(workspace.Dir); err == nil {
+		return nil, fmt.Errorf("workspace %q already exists", name)
+	}
+	if err := os.MkdirAll(workspace.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	cfg := workspace.Defaults()
+	if base != nil {
+		copied := *base
+		copied.DatabasePath = workspace.DatabasePath()
+		cfg = &copied
+	}
+	if err := Save(workspace.ConfigPath(), cfg); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// CurrentWorkspace returns the workspace selected by name, SSHM_WORKSPACE or
+// the persisted current workspace, in that order. A persisted workspace that
+// no longer exists falls back to the default one and is returned as missing,
+// so that commands, including switching workspaces, keep working.
+func CurrentWorkspace(name string) (workspace *Workspace, missing string, err error) {
+	if name == "" {
+		name = os.Getenv(EnvWorkspace)
+	}
+	if name != "" {
+		workspace, err = FindWorkspace(name)
+		return workspace, "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(GetDefaultDir(), currentWorkspaceFile))
+	if err == nil {
+		name = strings.TrimSpace(string(data))
+	}
+	if name == "" {
+		return GetWorkspace(DefaultWorkspace), "", nil
+	}
+	if workspace, err = FindWorkspace(name); err != nil {
+		return GetWorkspace(DefaultWorkspace), name, nil
+	}
+	return workspace, "", nil
+}
+
+// UseWorkspace makes a workspace current for later commands
+func UseWorkspace(workspace *Workspace) error {
+	path := filepath.Join(GetDefaultDir(), currentWorkspaceFile)
+	if workspace.IsDefault() {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(GetDefaultDir(), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(workspace.Name+"\n"), 0600)
+}
@@ -0,0 +1,326 @@
+// Package dedupe finds hosts that are likely the same machine stored under
+// different names, such as its FQDN, its IP address and [host]:2222, and
+// plans merging them into one host.
+package dedupe
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// Threshold is the lowest score at which two hosts are reported as duplicates
+const Threshold = 50
+
+// Resolver returns the IP addresses a host is reachable at
+type Resolver func(host *domain.Host) []string
+
+// Cluster is a group of hosts that are likely the same machine
+type Cluster struct {
+	Hosts   []*domain.Host // Most used first
+	Score   int            // Score of the weakest link in the group, 0-100
+	Reasons []string
+}
+
+// Confidence describes a score in words
+func Confidence(score int) string {
+	if score >= 80 {
+		return "high"
+	}
+	return "medium"
+}
+
+// Score rates how likely two hosts are the same machine, from 0 to 100, and
+// explains why. A shared short name only counts when neither host resolved:
+// web.eu.example.com and web.us.example.com are different machines.
+func Score(a, b *domain.Host, addrsA, addrsB []string) (int, []string) {
+	score := 0
+	var reasons []string
+
+	nameA, nameB := normalizeHostname(a.Hostname), normalizeHostname(b.Hostname)
+	switch {
+	case nameA == nameB:
+		score += 70
+		reasons = append(reasons, "same hostname "+nameA)
+	case sharedAddress(addrsA, addrsB) != "":
+		score += 60
+		reasons = append(reasons, "both resolve to "+sharedAddress(addrsA, addrsB))
+	case len(addrsA) > 0 || len(addrsB) > 0:
+		return 0, nil // Addresses are known and none is shared
+	case shortName(nameA) != "" && shortName(nameA) == shortName(nameB):
+		score += 40
+		reasons = append(reasons, "same short name "+shortName(nameA))
+	default:
+		return 0, nil
+	}
+
+	if a.Port == b.Port {
+		score += 20
+	} else {
+		score -= 10
+		reasons = append(reasons, fmt.Sprintf("ports differ (%d, %d)", a.Port, b.Port))
+	}
+	if a.Username == b.Username {
+		score += 10
+	}
+
+	if score > 100 {
+		score = 100
+	}
+	return score, reasons
+}
+
+// Find groups hosts scoring at least Threshold with one another. Clusters
+// are returned most likely first.
+func Find(hosts []*domain.Host, resolve Resolver) []*Cluster {
+	addrs := make([][]string, len(hosts))
+	for i, host := range hosts {
+		addrs[i] = resolve(host)
+	}
+
+	// Union-find over the pairs that look like duplicates
+	parent := make([]int, len(hosts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+
+	weakest := make(map[int]int)
+	reasons := make(map[int][]string)
+	for i := range hosts {
+		for j := i + 1; j < len(hosts); j++ {
+			score, why := Score(hosts[i], hosts[j], addrs[i], addrs[j])
+			if score < Threshold {
+				continue
+			}
+
+			ri, rj := root(i), root(j)
+			if ri != rj {
+				parent[rj] = ri
+				weakest[ri] = minScore(weakest, ri, rj, score)
+				reasons[ri] = appendUnique(reasons[ri], reasons[rj]...)
+				delete(weakest, rj)
+				delete(reasons, rj)
+			}
+			reasons[ri] = appendUnique(reasons[ri], why...)
+		}
+	}
+
+	groups := make(map[int][]*domain.Host)
+	for i, host := range hosts {
+		groups[root(i)] = append(groups[root(i)], host)
+	}
+
+	var clusters []*Cluster
+	for r, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			if members[i].UseCount != members[j].UseCount {
+				return members[i].UseCount > members[j].UseCount
+			}
+			return members[i].ID < members[j].ID
+		})
+		clusters = append(clusters, &Cluster{Hosts: members, Score: weakest[r], Reasons: reasons[r]})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Score != clusters[j].Score {
+			return clusters[i].Score > clusters[j].Score
+		}
+		return clusters[i].Hosts[0].Name < clusters[j].Hosts[0].Name
+	})
+	return clusters
+}
+
+// minScore returns the lower of a new link's score and the weakest links of
+// the two groups it joins
+func minScore(weakest map[int]int, a, b, score int) int {
+	for _, r := range []int{a, b} {
+		if w, ok := weakest[r]; ok && w < score {
+			score = w
+		}
+	}
+	return score
+}
+
+// Field is a host setting chosen from one of the duplicates when merging
+type Field struct {
+	Label string
+	get   func(*domain.Host) string
+	set   func(*domain.Host, string)
+}
+
+// Fields are the host settings picked when merging, in display order. The
+// host whose name is chosen is the one kept.
+var Fields = []Field{
+	{"Name", func(h *domain.Host) string { return h.Name }, func(h *domain.Host, v string) { h.Name = v }},
+	{"Hostname", func(h *domain.Host) string { return h.Hostname }, func(h *domain.Host, v string) { h.Hostname = v }},
+	{"Port", func(h *domain.Host) string { return strconv.Itoa(h.Port) }, func(h *domain.Host, v string) { h.Port, _ = strconv.Atoi(v) }},
+	{"User", func(h *domain.Host) string { return h.Username }, func(h *domain.Host, v string) { h.Username = v }},
+	{"Key", func(h *domain.Host) string { return h.KeyPath }, func(h *domain.Host, v string) { h.KeyPath = v }},
+	{"Group", func(h *domain.Host) string { return h.Group }, func(h *domain.Host, v string) { h.Group = v }},
+	{"Tags", func(h *domain.Host) string { return h.Tags }, func(h *domain.Host, v string) { h.Tags = v }},
+	{"Jump", func(h *domain.Host) string { return h.ProxyJump }, func(h *domain.Host, v string) { h.ProxyJump = v }},
+	{"Description", func(h *domain.Host) string { return h.Description }, func(h *domain.Host, v string) { h.Description = v }},
+}
+
+// Options returns the distinct values set for a field across the cluster,
+// most used host first. Tags also offer all tags combined.
+func (c *Cluster) Options(field Field) []string {
+	var options, tags []string
+	for _, host := range c.Hosts {
+		value := field.get(host)
+		if field.Label == "Tags" {
+			hostTags := splitTags(value)
+			tags = appendUnique(tags, hostTags...)
+			value = strings.Join(hostTags, ", ")
+		}
+		if value != "" {
+			options = appendUnique(options, value)
+		}
+	}
+	if len(tags) > 0 {
+		options = appendUnique(options, strings.Join(tags, ", "))
+	}
+	if len(options) == 0 {
+		return []string{""}
+	}
+	return options
+}
+
+// Defaults returns the option picked for each field unless the user
+// chooses otherwise: the most used host's value or, for tags, all of them
+func (c *Cluster) Defaults() []int {
+	choices := make([]int, len(Fields))
+	for i, field := range Fields {
+		if field.Label == "Tags" {
+			choices[i] = len(c.Options(field)) - 1
+		}
+	}
+	return choices
+}
+
+// Merge builds the merged host from one option index per field. It returns
+// the host to keep, updated with the chosen values and the combined usage,
+// and the hosts to remove.
+func (c *Cluster) Merge(choices []int) (*domain.Host, []*domain.Host) {
+	values := make([]string, len(Fields))
+	for i, field := range Fields {
+		options := c.Options(field)
+		choice := 0
+		if i < len(choices) && choices[i] >= 0 && choices[i] < len(options) {
+			choice = choices[i]
+		}
+		values[i] = options[choice]
+	}
+
+	keep := c.Hosts[0]
+	for _, host := range c.Hosts {
+		if host.Name == values[0] {
+			keep = host
+		}
+	}
+
+	merged := *keep
+	for i, field := range Fields {
+		field.set(&merged, values[i])
+	}
+
+	var remove []*domain.Host
+	for _, host := range c.Hosts {
+		if host == keep {
+			continue
+		}
+		remove = append(remove, host)
+
+		merged.UseCount += host.UseCount
+		if host.LastUsed.After(merged.LastUsed) {
+			merged.LastUsed = host.LastUsed
+		}
+		if !host.CreatedAt.IsZero() && host.CreatedAt.Before(merged.CreatedAt) {
+			merged.CreatedAt = host.CreatedAt
+		}
+		if merged.IPAddress == "" {
+			merged.IPAddress = host.IPAddress
+		}
+		if merged.Connector == "" {
+			merged.Connector = host.Connector
+		}
+		if merged.AddressFamily == "" {
+			merged.AddressFamily = host.AddressFamily
+		}
+		if merged.SourceID == "" {
+			merged.SourceID, merged.SourceScope = host.SourceID, host.SourceScope
+		}
+		merged.ForwardAgent = merged.ForwardAgent || host.ForwardAgent
+		merged.IdentitiesOnly = merged.IdentitiesOnly || host.IdentitiesOnly
+		merged.Record = merged.Record || host.Record
+		merged.Favorite = merged.Favorite || host.Favorite
+	}
+	return &merged, remove
+}
+
+// normalizeHostname lowercases a hostname and strips brackets and the root dot
+func normalizeHostname(hostname string) string {
+	hostname = strings.ToLower(strings.TrimSpace(hostname))
+	hostname = strings.TrimSuffix(strings.TrimPrefix(hostname, "["), "]")
+	return strings.TrimSuffix(hostname, ".")
+}
+
+// shortName returns the first label of a DNS name, or "" for IP addresses
+func shortName(hostname string) string {
+	if _, err := netip.ParseAddr(hostname); err == nil {
+		return ""
+	}
+	label, _, _ := strings.Cut(hostname, ".")
+	return label
+}
+
+// sharedAddress returns an address found in both lists
+func sharedAddress(a, b []string) string {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return x
+			}
+		}
+	}
+	return ""
+}
+
+func splitTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,81 @@
+package dedupe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+func TestFindClustersFQDNAddressAndPort(t *testing.T) {
+	hosts := []*domain.Host{
+		{ID: 1, Name: "web01", Hostname: "web01.example.com", Port: 22, Username: "deploy", UseCount: 2, Tags: "web"},
+		{ID: 2, Name: "10-0-0-5", Hostname: "10.0.0.5", Port: 22, Username: "deploy", UseCount: 7, KeyPath: "~/.ssh/web"},
+		{ID: 3, Name: "web01-2222", Hostname: "[web01.example.com]", Port: 2222, Username: "deploy", Tags: "ssh-detected"},
+		{ID: 4, Name: "db01", Hostname: "db01.example.com", Port: 22, Username: "deploy"},
+	}
+	resolve := func(h *domain.Host) []string {
+		switch h.Hostname {
+		case "web01.example.com", "10.0.0.5":
+			return []string{"10.0.0.5"}
+		}
+		return nil
+	}
+
+	clusters := Find(hosts, resolve)
+	if len(clusters) != 1 {
+		t.Fatalf("got %d clusters, want 1", len(clusters))
+	}
+	cluster := clusters[0]
+	if len(cluster.Hosts) != 3 || cluster.Hosts[0].ID != 2 {
+		t.Fatalf("cluster hosts = %v, want 3 hosts with the most used first", cluster.Hosts)
+	}
+	if cluster.Score != 70 {
+		t.Errorf("score = %d, want the weakest link's 70", cluster.Score)
+	}
+	if !strings.Contains(strings.Join(cluster.Reasons, "; "), "both resolve to 10.0.0.5") {
+		t.Errorf("reasons = %v, want the shared address", cluster.Reasons)
+	}
+}
+
+func TestScoreShortNameNeedsUnresolvedHosts(t *testing.T) {
+	eu := &domain.Host{Hostname: "web.eu.example.com", Port: 22, Username: "deploy"}
+	us := &domain.Host{Hostname: "web.us.example.com", Port: 22, Username: "deploy"}
+
+	if score, reasons := Score(eu, us, []string{"10.0.0.1"}, []string{"10.9.9.9"}); score != 0 {
+		t.Errorf("score = %d (%v), want 0 for different addresses", score, reasons)
+	}
+	if score, _ := Score(eu, us, []string{"10.0.0.1"}, nil); score != 0 {
+		t.Errorf("score = %d, want 0 when only one host resolved", score)
+	}
+	if score, _ := Score(eu, us, nil, nil); score != 70 {
+		t.Errorf("score = %d, want 70 for a shared short name without addresses", score)
+	}
+}
+
+func TestMergeCombinesUsageAndPicksValues(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(48 * time.Hour)
+	cluster := &Cluster{Hosts: []*domain.Host{
+		{ID: 2, Name: "ip", Hostname: "10.0.0.5", Port: 22, UseCount: 7, LastUsed: older, CreatedAt: newer, Tags: "a"},
+		{ID: 1, Name: "web01", Hostname: "web01.example.com", Port: 22, UseCount: 2, LastUsed: newer, CreatedAt: older, Tags: "b", KeyPath: "k", Favorite: true},
+	}}
+
+	choices := cluster.Defaults()
+	choices[0] = 1 // Keep the name web01
+	keep, remove := cluster.Merge(choices)
+
+	if keep.ID != 1 || keep.Name != "web01" {
+		t.Errorf("kept %d %q, want the host whose name was chosen", keep.ID, keep.Name)
+	}
+	if keep.Hostname != "10.0.0.5" || keep.KeyPath != "k" || keep.Tags != "a, b" {
+		t.Errorf("merged values = %q %q %q", keep.Hostname, keep.KeyPath, keep.Tags)
+	}
+	if keep.UseCount != 9 || !keep.LastUsed.Equal(newer) || !keep.CreatedAt.Equal(older) || !keep.Favorite {
+		t.Errorf("usage not combined: %d %v %v %v", keep.UseCount, keep.LastUsed, keep.CreatedAt, keep.Favorite)
+	}
+	if len(remove) != 1 || remove[0].ID != 2 {
+		t.Errorf("remove = %v, want host 2", remove)
+	}
+}
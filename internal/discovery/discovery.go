@@ -0,0 +1,234 @@
+// Package discovery finds SSH hosts on this machine through pluggable
+// sources such as known_hosts, ~/.ssh/config and shell history.
+package discovery
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Candidate is a host found by one or more discovery sources
+type Candidate struct {
+	Name      string // Suggested sshm name; empty to derive it from Hostname
+	Hostname  string
+	Address   string // IP address seen alongside the hostname, if any
+	Port      int
+	Username  string
+	KeyPath   string
+	ProxyJump string
+	Family    string   // inet or inet6 when the host is reached over one address family only
+	Detail    string   // Source-specific note, e.g. the host key type
+	Tags      []string // Tags for the stored host instead of ssh-detected
+	Sources   []string // Sources that reported the host, best first
+	Alias     bool     // Name is an ssh_config alias that ssh resolves itself
+	Uses      int      // Times seen, for sources that count connections
+	// Tentative candidates, such as one-off commands in shell history, are
+	// only kept when another source reports the host or after MinUses uses
+	Tentative bool
+}
+
+// MinUses is how often a tentative host must be seen to be discovered
+const MinUses = 2
+
+// Key identifies the machine and port a candidate refers to
+func (c *Candidate) Key() string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(c.Hostname), c.Port)
+}
+
+// Discoverer is a discovery source
+type Discoverer interface {
+	// Name returns the source name used in config and attribution
+	Name() string
+	// Discover returns the hosts the source knows about. A missing file is
+	// not an error.
+	Discover() ([]Candidate, error)
+}
+
+// Env describes where sources look for their files
+type Env struct {
+	Home string // User home directory
+	Root string // Prefix for system files such as /etc/hosts; empty for /
+}
+
+// DefaultEnv returns the environment of the current user
+func DefaultEnv() Env {
+	home, _ := os.UserHomeDir()
+	return Env{Home: home}
+}
+
+// path returns a system path under the environment's root
+func (e Env) path(name string) string {
+	return e.Root + name
+}
+
+// Source is a registered discovery source
+type Source struct {
+	Name        string
+	Description string
+	Default     bool // Enabled unless the config says otherwise
+	New         func(env Env) Discoverer
+}
+
+var registry []Source
+
+// Register adds a source. Sources registered first take precedence when
+// merging their results.
+func Register(source Source) {
+	registry = append(registry, source)
+}
+
+// Sources returns the registered sources in precedence order
+func Sources() []Source {
+	return append([]Source(nil), registry...)
+}
+
+// Names returns the names of all registered sources
+func Names() []string {
+	var names []string
+	for _, source := range registry {
+		names = append(names, source.Name)
+	}
+	return names
+}
+
+// DefaultNames returns the names of the sources enabled by default
+func DefaultNames() []string {
+	var names []string
+	for _, source := range registry {
+		if source.Default {
+			names = append(names, source.Name)
+		}
+	}
+	return names
+}
+
+// Lookup returns a registered source by name
+func Lookup(name string) (Source, bool) {
+	for _, source := range registry {
+		if source.Name == name {
+			return source, true
+		}
+	}
+	return Source{}, false
+}
+
+// SourceError is a failure of one source; the others still run
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+// Run runs the named sources and merges what they found, dropping hosts on
+// the ignore list, which may be nil. Unknown names are skipped. Failing
+// sources are reported alongside the results.
+func Run(env Env, names []string, ignore *IgnoreList) ([]Candidate, []error) {
+	var found []Candidate
+	var errs []error
+	for _, source := range registry {
+		if !contains(names, source.Name) {
+			continue
+		}
+		candidates, err := source.New(env).Discover()
+		if err != nil {
+			errs = append(errs, &SourceError{Source: source.Name, Err: err})
+			continue
+		}
+		found = append(found, candidates...)
+	}
+
+	var result []Candidate
+	for _, c := range Merge(found) {
+		if ignore.Match(c) != "" {
+			continue
+		}
+		if !c.Tentative || c.Uses >= MinUses {
+			result = append(result, c)
+		}
+	}
+	return result, errs
+}
+
+// Merge combines candidates for the same hostname and port. Values from
+// candidates earlier in the list win; their sources are all kept. Hostnames
+// that are ssh_config aliases are folded into the aliased host.
+func Merge(candidates []Candidate) []Candidate {
+	aliases := make(map[string]string) // alias -> key of the host it names
+	for _, c := range candidates {
+		if c.Alias && c.Name != "" {
+			if _, ok := aliases[strings.ToLower(c.Name)]; !ok {
+				aliases[strings.ToLower(c.Name)] = c.Key()
+			}
+		}
+	}
+
+	var order []string
+	merged := make(map[string]*Candidate)
+	for _, c := range candidates {
+		key := c.Key()
+		if target, ok := aliases[strings.ToLower(c.Hostname)]; ok && !c.Alias {
+			key = target
+		}
+
+		existing, ok := merged[key]
+		if !ok {
+			copied := c
+			copied.Sources = append([]string(nil), c.Sources...)
+			merged[key] = &copied
+			order = append(order, key)
+			continue
+		}
+		existing.fill(c)
+	}
+
+	result := make([]Candidate, 0, len(order))
+	for _, key := range order {
+		result = append(result, *merged[key])
+	}
+	return result
+}
+
+// fill copies the values c has and the candidate lacks
+func (m *Candidate) fill(c Candidate) {
+	if m.Name == "" {
+		m.Name = c.Name
+	}
+	if m.Address == "" {
+		m.Address = c.Address
+	}
+	if m.Username == "" {
+		m.Username = c.Username
+	}
+	if m.KeyPath == "" {
+		m.KeyPath = c.KeyPath
+	}
+	if m.ProxyJump == "" {
+		m.ProxyJump = c.ProxyJump
+	}
+	if m.Family == "" {
+		m.Family = c.Family
+	}
+	if m.Detail == "" {
+		m.Detail = c.Detail
+	}
+	m.Uses += c.Uses
+	m.Tentative = m.Tentative && c.Tentative
+	for _, source := range c.Sources {
+		if !contains(m.Sources, source) {
+			m.Sources = append(m.Sources, source)
+		}
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
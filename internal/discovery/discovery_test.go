@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunMergesSources(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".ssh", "config"), `
+Host web
+    HostName web01.example.com
+    User deploy
+    Port 2222
+Host *.internal
+    User nobody
+`)
+	writeFile(t, filepath.Join(home, ".ssh", "known_hosts"), `
+[web01.example.com]:2222,[10.0.0.5]:2222 ssh-ed25519 AAAA
+db01.example.com ssh-rsa AAAA
+|1|hashed= ssh-rsa AAAA
+@cert-authority *.example.com ssh-rsa AAAA
+`)
+	writeFile(t, filepath.Join(home, ".bash_history"), `ssh web
+ssh -p 2200 -l admin once.example.com
+ssh -v ops@db01.example.com
+`)
+
+	candidates, errs := Run(Env{Home: home, Root: home}, []string{"ssh_config", "known_hosts", "shell_history"}, nil)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2: %+v", len(candidates), candidates)
+	}
+
+	web := candidates[0]
+	if web.Name != "web" || web.Hostname != "web01.example.com" || web.Port != 2222 || web.Username != "deploy" {
+		t.Errorf("web = %+v", web)
+	}
+	if web.Address != "10.0.0.5" || web.Detail != "ssh-ed25519" {
+		t.Errorf("web address and detail = %q %q", web.Address, web.Detail)
+	}
+	if want := []string{"ssh_config", "known_hosts", "shell_history"}; !reflect.DeepEqual(web.Sources, want) {
+		t.Errorf("web sources = %v, want %v", web.Sources, want)
+	}
+
+	db := candidates[1]
+	if db.Hostname != "db01.example.com" || db.Username != "ops" || db.Port != 22 {
+		t.Errorf("db = %+v", db)
+	}
+}
+
+func TestIgnoreList(t *testing.T) {
+	ignore, err := NewIgnoreList([]string{"old-db", "*.lab.example.com", "192.168.56.0/24", "fe80::/10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		candidate Candidate
+		want      string
+	}{
+		{Candidate{Hostname: "old-db.example.com"}, "old-db"},
+		{Candidate{Name: "web", Hostname: "web.LAB.example.com"}, "*.lab.example.com"},
+		{Candidate{Hostname: "box.local", Address: "192.168.56.10"}, "192.168.56.0/24"},
+		{Candidate{Hostname: "192.168.57.10"}, ""},
+		{Candidate{Hostname: "fe80::1%eth0"}, "fe80::/10"},
+		{Candidate{Name: "db", Hostname: "old-db.example.com"}, ""},
+	}
+	for _, tt := range tests {
+		if got := ignore.Match(tt.candidate); got != tt.want {
+			t.Errorf("Match(%+v) = %q, want %q", tt.candidate, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "10.0.0.0/33", "[a-"} {
+		if err := CheckIgnorePattern(bad); err == nil {
+			t.Errorf("CheckIgnorePattern(%q) accepted", bad)
+		}
+	}
+}
+
+func TestRunIgnoresDeletedHostByAddress(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".ssh", "known_hosts"), `
+10.0.0.7 ssh-ed25519 AAAA
+db01.example.com ssh-rsa AAAA
+`)
+	writeFile(t, filepath.Join(home, ".bash_history"), `ssh ops@10.0.0.7
+ssh ops@10.0.0.7
+`)
+
+	// The rules delete and ignore adds for old-db.example.com, cached as 10.0.0.7
+	ignore, err := NewIgnoreList([]string{"old-db.example.com", "10.0.0.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates, errs := Run(Env{Home: home, Root: home}, []string{"known_hosts", "shell_history"}, ignore)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(candidates) != 1 || candidates[0].Hostname != "db01.example.com" {
+		t.Errorf("candidates = %+v, want only db01.example.com", candidates)
+	}
+}
+
+func TestAssignNames(t *testing.T) {
+	candidates := []Candidate{
+		{Hostname: "web.eu.example.com", Port: 22},
+		{Hostname: "web.us.example.com", Port: 22},
+		{Hostname: "db.example.com", Port: 22},
+		{Hostname: "10.0.0.5", Port: 22},
+		{Name: "db", Hostname: "db02.example.com", Port: 22},
+		{Hostname: "web.eu.example.com", Port: 2222},
+	}
+	taken := map[string]string{"db": "db01.example.com"}
+
+	tests := []struct {
+		naming     Naming
+		want       []string
+		collisions int
+	}{
+		{Naming{Strategy: NamingShort}, []string{"web", "web-2", "db-2", "10-0-0-5", "db-3", "web-2222"}, 4},
+		{Naming{Strategy: NamingSuffix}, []string{"web-eu", "web-us", "db-example", "10-0-0-5", "db-2", "web-eu-2222"}, 5},
+		{Naming{Strategy: NamingFQDN}, []string{"web.eu.example.com", "web.us.example.com", "db.example.com", "10.0.0.5", "db-2", "web.eu.example.com-2222"}, 2},
+		{Naming{Strategy: NamingTemplate, Template: "{label}-{domain}"}, []string{"web-eu.example.com", "web-us.example.com", "db-example.com", "10.0.0.5", "db-2", "web-eu.example.com-2222"}, 2},
+	}
+	for _, tt := range tests {
+		names, collisions := tt.naming.AssignNames(candidates, taken)
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%s: names = %v, want %v", tt.naming.Strategy, names, tt.want)
+		}
+		if len(collisions) != tt.collisions {
+			t.Errorf("%s: %d collisions, want %d: %+v", tt.naming.Strategy, len(collisions), tt.collisions, collisions)
+		}
+	}
+
+	if err := CheckTemplate("{label}-{zone}"); err == nil {
+		t.Error("CheckTemplate accepted an unknown placeholder")
+	}
+}
+
+func TestParseSSHCommands(t *testing.T) {
+	tests := []struct {
+		line string
+		want []Candidate
+	}{
+		{"ssh -vp 2222 -i ~/.ssh/id_work -J bastion ops@web.example.com uptime", []Candidate{
+			{Hostname: "web.example.com", Port: 2222, Username: "ops", KeyPath: expandHome("~/.ssh/id_work"), ProxyJump: "bastion"},
+		}},
+		{`ssh -o User=deploy -o "Port 2200" -oProxyJump=jump db.example.com`, []Candidate{
+			{Hostname: "db.example.com", Port: 2200, Username: "deploy", ProxyJump: "jump"},
+		}},
+		{"ssh -l admin root@legacy", []Candidate{{Hostname: "legacy", Port: 22, Username: "admin"}}},
+		{"ssh ssh://alice@[2001:db8::1]:2022", []Candidate{{Hostname: "2001:db8::1", Port: 2022, Username: "alice"}}},
+		{": 1700000000:0;cd /tmp && sudo ssh -- app01 'ls -la'", []Candidate{{Hostname: "app01", Port: 22}}},
+		{"ssh-keygen -R old; ssh $HOST; ssh a | ssh b", []Candidate{{Hostname: "a", Port: 22}, {Hostname: "b", Port: 22}}},
+		{"ssh -6 -l root fe80::1%eth0", []Candidate{{Hostname: "fe80::1%eth0", Port: 22, Username: "root", Family: "inet6"}}},
+		{"ssh -p2222 -l root 10.0.0.1 uptime", []Candidate{{Hostname: "10.0.0.1", Port: 2222, Username: "root"}}},
+		{"ssh-keygen -t ed25519", nil},
+		{"scp file web:/tmp", nil},
+	}
+	for _, tt := range tests {
+		got := parseSSHCommands(tt.line)
+		for i := range got {
+			got[i].Sources, got[i].Uses, got[i].Tentative = nil, 0, false
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSSHCommands(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+
+	if command, ok := fishCommand(`- cmd: ssh -p 2222 fish.example.com`); !ok || command != "ssh -p 2222 fish.example.com" {
+		t.Errorf("fishCommand = %q, %v", command, ok)
+	}
+}
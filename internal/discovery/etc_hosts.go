@@ -0,0 +1,52 @@
+package discovery
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// EtcHosts reads names from a hosts(5) file. Loopback, unspecified and
+// multicast addresses are skipped, as are entries blocking ad domains.
+type EtcHosts struct {
+	Path string
+}
+
+func (e *EtcHosts) Name() string {
+	return "etc_hosts"
+}
+
+func (e *EtcHosts) Discover() ([]Candidate, error) {
+	file, err := os.Open(e.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var candidates []Candidate
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip, err := netip.ParseAddr(fields[0])
+		if err != nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() {
+			continue
+		}
+
+		// The canonical name comes first, aliases after it
+		candidates = append(candidates, Candidate{
+			Hostname: fields[1],
+			Address:  ip.String(),
+			Port:     22,
+			Sources:  []string{"etc_hosts"},
+		})
+	}
+	return candidates, scanner.Err()
+}
@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+)
+
+// IgnoreList holds hosts discovery must never report: exact names and
+// hostnames, hostname globs such as *.lab.example.com, and CIDRs such as
+// 10.0.0.0/8
+type IgnoreList struct {
+	globs []string
+	nets  []netip.Prefix
+}
+
+// CheckIgnorePattern reports whether pattern can be used in an ignore list
+func CheckIgnorePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("ignore pattern must not be empty")
+	}
+	if strings.Contains(pattern, "/") {
+		if _, err := netip.ParsePrefix(pattern); err != nil {
+			return fmt.Errorf("invalid CIDR %q", pattern)
+		}
+		return nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// NewIgnoreList parses ignore patterns
+func NewIgnoreList(patterns []string) (*IgnoreList, error) {
+	list := &IgnoreList{}
+	for _, pattern := range patterns {
+		if err := CheckIgnorePattern(pattern); err != nil {
+			return nil, err
+		}
+		if strings.Contains(pattern, "/") {
+			network, _ := netip.ParsePrefix(pattern)
+			list.nets = append(list.nets, network.Masked())
+			continue
+		}
+		list.globs = append(list.globs, strings.ToLower(pattern))
+	}
+	return list, nil
+}
+
+// Match returns the pattern that ignores a candidate, or "" if none does.
+// Patterns are checked against the candidate's name, the name sshm would
+// give it, its hostname and its address.
+func (l *IgnoreList) Match(c Candidate) string {
+	if l == nil {
+		return ""
+	}
+
+	values := []string{c.Name, c.Hostname, c.Address}
+	if c.Name == "" {
+		values = append(values, NameFor(c.Hostname))
+	}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		value = strings.ToLower(value)
+		for _, glob := range l.globs {
+			if ok, _ := path.Match(glob, value); ok {
+				return glob
+			}
+		}
+		if ip, err := netip.ParseAddr(value); err == nil {
+			// Zones are not part of a network: fe80::1%eth0 is in fe80::/10
+			ip = ip.WithZone("").Unmap()
+			for _, network := range l.nets {
+				if network.Contains(ip) {
+					return network.String()
+				}
+			}
+		}
+	}
+	return ""
+}
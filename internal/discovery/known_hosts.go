@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// KnownHosts reads an OpenSSH known_hosts file. Hashed entries, wildcards
+// and @revoked or @cert-authority lines are skipped.
+type KnownHosts struct {
+	Source string
+	Path   string
+}
+
+func (k *KnownHosts) Name() string {
+	return k.Source
+}
+
+func (k *KnownHosts) Discover() ([]Candidate, error) {
+	file, err := os.Open(k.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var candidates []Candidate
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
+			continue
+		}
+
+		// hostnames keytype key [comment]
+		parts := strings.Fields(line)
+		if len(parts) < 3 || strings.HasPrefix(parts[0], "|") {
+			continue
+		}
+
+		// One line may list a host under several names and addresses
+		var hostnames, addresses []string
+		port := 22
+		for _, pattern := range strings.Split(parts[0], ",") {
+			if strings.ContainsAny(pattern, "*?!") {
+				continue
+			}
+			host, p := ssh.SplitKnownHostsPattern(pattern)
+			if host == "" {
+				continue
+			}
+			port = p
+			if ssh.IsIPLiteral(host) {
+				addresses = append(addresses, host)
+			} else {
+				hostnames = append(hostnames, host)
+			}
+		}
+
+		candidate := Candidate{Port: port, Detail: parts[1], Sources: []string{k.Source}}
+		switch {
+		case len(hostnames) > 0:
+			candidate.Hostname = hostnames[0]
+			if len(addresses) > 0 {
+				candidate.Address = addresses[0]
+			}
+		case len(addresses) > 0:
+			candidate.Hostname = addresses[0]
+		default:
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, scanner.Err()
+}
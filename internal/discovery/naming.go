@@ -0,0 +1,211 @@
+package discovery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// Naming strategies for discovered hosts
+const (
+	NamingShort    = "short"    // First DNS label: web.eu.example.com -> web
+	NamingSuffix   = "suffix"   // First label, plus the next labels when names clash: web-eu
+	NamingFQDN     = "fqdn"     // The full hostname
+	NamingTemplate = "template" // A template such as "{label}-{port}"
+)
+
+// NamingStrategies lists the accepted naming strategies
+var NamingStrategies = []string{NamingShort, NamingSuffix, NamingFQDN, NamingTemplate}
+
+// TemplateFields are the placeholders a name template may use
+var TemplateFields = []string{"{label}", "{domain}", "{host}", "{user}", "{port}"}
+
+// Naming chooses names for discovered hosts
+type Naming struct {
+	Strategy string
+	Template string // Used by NamingTemplate
+}
+
+// Collision is a discovered host that could not get its preferred name
+type Collision struct {
+	Hostname string
+	Wanted   string
+	Name     string
+}
+
+// Characters dropped from generated names
+var nameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9\-]`)
+
+// Characters replaced in templated names
+var templateUnsafe = regexp.MustCompile(`[^a-zA-Z0-9._\-]+`)
+
+// NameFor derives a short sshm host name from a hostname: its first DNS
+// label, or the address with dashes for IP addresses
+func NameFor(hostname string) string {
+	if ssh.IsIPLiteral(hostname) {
+		return nameUnsafe.ReplaceAllString(strings.NewReplacer(".", "-", ":", "-", "%", "-").Replace(hostname), "")
+	}
+	if name := nameUnsafe.ReplaceAllString(strings.Split(hostname, ".")[0], ""); name != "" {
+		return name
+	}
+
+	// Fallback to hostname with dots replaced by hyphens
+	return strings.ReplaceAll(hostname, ".", "-")
+}
+
+// CheckTemplate reports whether a name template can be used
+func CheckTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("name template must not be empty")
+	}
+	rest := template
+	for _, field := range TemplateFields {
+		rest = strings.ReplaceAll(rest, field, "")
+	}
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("unknown placeholder in name template %q (available: %s)", template, strings.Join(TemplateFields, ", "))
+	}
+	return nil
+}
+
+// AssignNames gives every candidate a name that is unique among them and
+// not in taken, which maps the lowercased names already in use to the
+// hostnames holding them. Candidates with a Name from ssh_config keep it
+// when they can. Hosts that did not get their preferred name are reported
+// as collisions.
+func (n Naming) AssignNames(candidates []Candidate, taken map[string]string) ([]string, []Collision) {
+	options := make([][]string, len(candidates))
+	wanted := make(map[string]map[string]bool) // Preferred name -> hostnames wanting it
+	for i, c := range candidates {
+		options[i] = n.options(c)
+		preferred := strings.ToLower(options[i][0])
+		if wanted[preferred] == nil {
+			wanted[preferred] = make(map[string]bool)
+		}
+		wanted[preferred][strings.ToLower(c.Hostname)] = true
+	}
+
+	// Hosts on the standard port pick first, so the same machine on
+	// another port is the one told apart by its port
+	order := make([]int, 0, len(candidates))
+	for _, pass := range []bool{true, false} {
+		for i, c := range candidates {
+			if (c.Port == 22) == pass {
+				order = append(order, i)
+			}
+		}
+	}
+
+	used := make(map[string]string, len(taken)) // Name -> hostname holding it
+	for name, hostname := range taken {
+		used[name] = strings.ToLower(hostname)
+	}
+
+	names := make([]string, len(candidates))
+	var collisions []Collision
+	for _, i := range order {
+		c := candidates[i]
+		hostname := strings.ToLower(c.Hostname)
+		choices := options[i]
+		preferred := choices[0]
+
+		// With suffixes, every machine sharing a label is told apart by its
+		// domain, not just the ones after the first. The same machine on
+		// another port is told apart by its port instead.
+		holder, held := used[strings.ToLower(preferred)]
+		contested := len(wanted[strings.ToLower(preferred)]) > 1 || (held && holder != hostname)
+		if n.Strategy == NamingSuffix && c.Name == "" && contested && len(choices) > 1 {
+			choices = choices[1:]
+		}
+
+		name := ""
+		for _, choice := range choices {
+			holder, held := used[strings.ToLower(choice)]
+			if !held {
+				name = choice
+				break
+			}
+			ported := fmt.Sprintf("%s-%d", choice, c.Port)
+			if _, portHeld := used[strings.ToLower(ported)]; holder == hostname && !portHeld {
+				name = ported
+				break
+			}
+		}
+		for k := 2; name == ""; k++ {
+			candidate := fmt.Sprintf("%s-%d", preferred, k)
+			if _, held := used[strings.ToLower(candidate)]; !held {
+				name = candidate
+			}
+		}
+
+		used[strings.ToLower(name)] = hostname
+		names[i] = name
+		if name != preferred {
+			collisions = append(collisions, Collision{Hostname: c.Hostname, Wanted: preferred, Name: name})
+		}
+	}
+	return names, collisions
+}
+
+// options returns the names a candidate may get, best first
+func (n Naming) options(c Candidate) []string {
+	var options []string
+	switch {
+	case c.Name != "":
+		options = []string{c.Name}
+	case n.Strategy == NamingFQDN:
+		options = []string{strings.ToLower(c.Hostname)}
+	case n.Strategy == NamingTemplate:
+		if name := n.render(c); name != "" {
+			options = []string{name}
+		}
+	case n.Strategy == NamingSuffix:
+		options = labelNames(c.Hostname)
+	}
+	if len(options) == 0 {
+		options = []string{NameFor(c.Hostname)}
+	}
+	return options
+}
+
+// labelNames returns a hostname's first label followed by longer and
+// longer runs of its labels: web, web-eu, web-eu-example, web-eu-example-com
+func labelNames(hostname string) []string {
+	if ssh.IsIPLiteral(hostname) {
+		return []string{NameFor(hostname)}
+	}
+
+	var names []string
+	var labels []string
+	for _, label := range strings.Split(hostname, ".") {
+		if label = nameUnsafe.ReplaceAllString(label, ""); label == "" {
+			continue
+		}
+		labels = append(labels, label)
+		names = append(names, strings.Join(labels, "-"))
+	}
+	return names
+}
+
+// render fills in the name template for a candidate
+func (n Naming) render(c Candidate) string {
+	label, domain := c.Hostname, ""
+	if !ssh.IsIPLiteral(c.Hostname) {
+		if dot := strings.Index(c.Hostname, "."); dot >= 0 {
+			label, domain = c.Hostname[:dot], c.Hostname[dot+1:]
+		}
+	}
+
+	name := strings.NewReplacer(
+		"{label}", label,
+		"{domain}", domain,
+		"{host}", c.Hostname,
+		"{user}", c.Username,
+		"{port}", strconv.Itoa(c.Port),
+	).Replace(n.Template)
+	name = templateUnsafe.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-.")
+}
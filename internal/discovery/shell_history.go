@@ -0,0 +1,331 @@
+package discovery
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// ShellHistory finds ssh commands in bash, zsh and fish history. A host
+// seen only here must have been connected to at least MinUses times.
+type ShellHistory struct {
+	Home string
+}
+
+// historyFile is a history file and how its lines are read
+type historyFile struct {
+	path string
+	fish bool
+}
+
+// History files read, relative to the home directory
+var historyFiles = []historyFile{
+	{path: ".zsh_history"},
+	{path: ".bash_history"},
+	{path: ".history"},
+	{path: filepath.Join(".local", "share", "fish", "fish_history"), fish: true},
+}
+
+// ssh options that take an argument, from ssh(1)
+const sshArgFlags = "BbcDEeFIiJLlmOoPpQRSWw"
+
+func (h *ShellHistory) Name() string {
+	return "shell_history"
+}
+
+// Discover reads each history file once into an index of hosts
+func (h *ShellHistory) Discover() ([]Candidate, error) {
+	index := newHistoryIndex()
+	for _, history := range historyFiles {
+		file, err := os.Open(filepath.Join(h.Home, history.path))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if history.fish {
+				var ok bool
+				if line, ok = fishCommand(line); !ok {
+					continue
+				}
+			}
+			for _, candidate := range parseSSHCommands(line) {
+				index.add(candidate)
+			}
+		}
+		file.Close()
+	}
+	return index.candidates(), nil
+}
+
+// historyIndex folds every use of a host into one candidate
+type historyIndex struct {
+	order []string
+	hosts map[string]*historyEntry
+}
+
+// historyEntry is a host seen in history. Later commands are newer, so
+// their key and jump host win; the user is the one used most often.
+type historyEntry struct {
+	candidate Candidate
+	users     map[string]int
+}
+
+func newHistoryIndex() *historyIndex {
+	return &historyIndex{hosts: make(map[string]*historyEntry)}
+}
+
+func (x *historyIndex) add(c Candidate) {
+	key := c.Key()
+	entry, ok := x.hosts[key]
+	if !ok {
+		entry = &historyEntry{candidate: c, users: make(map[string]int)}
+		entry.candidate.Uses = 0
+		x.hosts[key] = entry
+		x.order = append(x.order, key)
+	}
+
+	entry.candidate.Uses++
+	if c.Username != "" {
+		entry.users[c.Username]++
+		if entry.users[c.Username] >= entry.users[entry.candidate.Username] {
+			entry.candidate.Username = c.Username
+		}
+	}
+	if c.KeyPath != "" {
+		entry.candidate.KeyPath = c.KeyPath
+	}
+	if c.ProxyJump != "" {
+		entry.candidate.ProxyJump = c.ProxyJump
+	}
+	if c.Family != "" {
+		entry.candidate.Family = c.Family
+	}
+}
+
+func (x *historyIndex) candidates() []Candidate {
+	candidates := make([]Candidate, 0, len(x.order))
+	for _, key := range x.order {
+		candidates = append(candidates, x.hosts[key].candidate)
+	}
+	return candidates
+}
+
+// fishCommand returns the command of a "- cmd: ..." line of fish history
+func fishCommand(line string) (string, bool) {
+	command, ok := strings.CutPrefix(line, "- cmd: ")
+	if !ok {
+		return "", false
+	}
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(command), true
+}
+
+// parseSSHCommands returns the hosts of the ssh commands in a history line,
+// which may hold several commands joined with ;, && or pipes. zsh's extended
+// ": <time>:<duration>;" prefix is skipped.
+func parseSSHCommands(line string) []Candidate {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, ": ") {
+		if _, command, ok := strings.Cut(line, ";"); ok {
+			line = command
+		}
+	}
+
+	var candidates []Candidate
+	for _, args := range splitCommands(line) {
+		if candidate, ok := parseSSHArgs(args); ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// splitCommands splits a shell line into the words of each command,
+// honoring quotes and backslashes. Anything it cannot follow, such as
+// command substitution, ends the line.
+func splitCommands(line string) [][]string {
+	var commands [][]string
+	var words []string
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+			words = nil
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			endWord()
+		case ch == ';' || ch == '|' || ch == '&':
+			endCommand()
+		case ch == '`' || ch == '(' || ch == ')' || ch == '$' && i+1 < len(line) && line[i+1] == '(':
+			endCommand()
+			return commands
+		case ch == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(line[i+1:], ch)
+			if end < 0 {
+				endCommand()
+				return commands // Unterminated quote
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands
+}
+
+// parseSSHArgs reads the destination, user, port, key and jump host of an
+// ssh command, given as words. Wrappers such as sudo and variable
+// assignments before the command are skipped.
+func parseSSHArgs(args []string) (Candidate, bool) {
+	for len(args) > 0 {
+		if args[0] == "sudo" || args[0] == "exec" || args[0] == "command" || args[0] == "env" ||
+			(strings.Contains(args[0], "=") && !strings.HasPrefix(args[0], "-")) {
+			args = args[1:]
+			continue
+		}
+		break
+	}
+	if len(args) < 2 || filepath.Base(args[0]) != "ssh" {
+		return Candidate{}, false
+	}
+
+	candidate := Candidate{Sources: []string{"shell_history"}, Uses: 1, Tentative: true}
+	port := ""
+	destination := ""
+	for i := 1; i < len(args) && destination == ""; i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				destination = args[i+1]
+			}
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || len(arg) < 2 {
+			destination = arg
+			break
+		}
+
+		// Flags may be grouped, as in -vp 2222 or -Ai key
+		for j := 1; j < len(arg); j++ {
+			flag := arg[j]
+			switch flag {
+			case '4':
+				candidate.Family = "inet"
+			case '6':
+				candidate.Family = "inet6"
+			}
+			if !strings.ContainsRune(sshArgFlags, rune(flag)) {
+				continue // Boolean flags such as -v or -A
+			}
+			value := arg[j+1:]
+			if value == "" && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			switch flag {
+			case 'p':
+				port = value
+			case 'l':
+				if candidate.Username == "" {
+					candidate.Username = value
+				}
+			case 'i':
+				candidate.KeyPath = expandHome(value)
+			case 'J':
+				candidate.ProxyJump = value
+			case 'o':
+				applySSHOption(&candidate, &port, value)
+			}
+			break
+		}
+	}
+
+	// As in ssh, a user or port given as an option wins over one in the
+	// destination: ssh://[user@]host[:port] or user@host
+	if strings.HasPrefix(destination, "ssh://") {
+		uri, err := url.Parse(destination)
+		if err != nil || uri.Hostname() == "" {
+			return Candidate{}, false
+		}
+		if candidate.Username == "" && uri.User != nil {
+			candidate.Username = uri.User.Username()
+		}
+		if port == "" {
+			port = uri.Port()
+		}
+		destination = uri.Hostname()
+	} else if at := strings.LastIndex(destination, "@"); at >= 0 {
+		if candidate.Username == "" {
+			candidate.Username = destination[:at]
+		}
+		destination = destination[at+1:]
+	}
+	destination = strings.TrimSuffix(strings.TrimPrefix(destination, "["), "]")
+
+	if destination == "" || strings.ContainsAny(destination, "$`'\"*?/") {
+		return Candidate{}, false
+	}
+	if strings.Contains(destination, ":") && !ssh.IsIPLiteral(destination) {
+		return Candidate{}, false // Not a hostname, e.g. an scp-style path
+	}
+	candidate.Hostname = destination
+
+	candidate.Port = 22
+	if p, err := strconv.Atoi(port); err == nil && p > 0 && p <= 65535 {
+		candidate.Port = p
+	}
+	return candidate, true
+}
+
+// applySSHOption applies an -o option, "Key=value" or "Key value". Like
+// ssh, the first user and port given win.
+func applySSHOption(candidate *Candidate, port *string, option string) {
+	keyword, value := splitDirective(option)
+	switch keyword {
+	case "user":
+		if candidate.Username == "" {
+			candidate.Username = value
+		}
+	case "port":
+		if *port == "" {
+			*port = value
+		}
+	case "identityfile":
+		candidate.KeyPath = expandHome(value)
+	case "proxyjump":
+		if !strings.EqualFold(value, "none") {
+			candidate.ProxyJump = value
+		}
+	case "addressfamily":
+		candidate.Family = addressFamily(value)
+	}
+}
@@ -0,0 +1,42 @@
+package discovery
+
+import "path/filepath"
+
+// The built-in sources, in precedence order: ssh_config names and users win
+// over what other sources report for the same host
+func init() {
+	Register(Source{
+		Name:        "ssh_config",
+		Description: "Host entries in ~/.ssh/config",
+		Default:     true,
+		New:         func(env Env) Discoverer { return &SSHConfig{Path: filepath.Join(env.Home, ".ssh", "config")} },
+	})
+	Register(Source{
+		Name:        "known_hosts",
+		Description: "Hosts you have connected to, from ~/.ssh/known_hosts",
+		Default:     true,
+		New: func(env Env) Discoverer {
+			return &KnownHosts{Source: "known_hosts", Path: filepath.Join(env.Home, ".ssh", "known_hosts")}
+		},
+	})
+	Register(Source{
+		Name:        "system_known_hosts",
+		Description: "Host keys installed for all users in /etc/ssh/ssh_known_hosts",
+		Default:     true,
+		New: func(env Env) Discoverer {
+			return &KnownHosts{Source: "system_known_hosts", Path: env.path("/etc/ssh/ssh_known_hosts")}
+		},
+	})
+	Register(Source{
+		Name:        "shell_history",
+		Description: "ssh commands in bash, zsh and fish history",
+		Default:     true,
+		New:         func(env Env) Discoverer { return &ShellHistory{Home: env.Home} },
+	})
+	Register(Source{
+		Name:        "etc_hosts",
+		Description: "Names in /etc/hosts (off by default: most are not SSH servers)",
+		Default:     false,
+		New:         func(env Env) Discoverer { return &EtcHosts{Path: env.path("/etc/hosts")} },
+	})
+}
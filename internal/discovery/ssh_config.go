@@ -0,0 +1,162 @@
+package discovery
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Include depth after which ssh_config files are no longer followed
+const maxIncludeDepth = 8
+
+// SSHConfig reads Host entries from an OpenSSH client config file,
+// following Include. Wildcard patterns and Match blocks are skipped.
+type SSHConfig struct {
+	Path string
+}
+
+func (c *SSHConfig) Name() string {
+	return "ssh_config"
+}
+
+func (c *SSHConfig) Discover() ([]Candidate, error) {
+	var candidates []Candidate
+	if err := c.parse(c.Path, 0, &candidates); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (c *SSHConfig) parse(path string, depth int, candidates *[]Candidate) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Candidates of the current Host block
+	var block []*Candidate
+	flush := func() {
+		for _, candidate := range block {
+			if candidate.Hostname == "" {
+				candidate.Hostname = candidate.Name
+			}
+			if candidate.Port == 0 {
+				candidate.Port = 22
+			}
+			*candidates = append(*candidates, *candidate)
+		}
+		block = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		keyword, value := splitDirective(scanner.Text())
+		if keyword == "" {
+			continue
+		}
+
+		switch keyword {
+		case "host":
+			flush()
+			for _, pattern := range strings.Fields(value) {
+				if strings.ContainsAny(pattern, "*?!") {
+					continue
+				}
+				block = append(block, &Candidate{Name: pattern, Alias: true, Sources: []string{"ssh_config"}})
+			}
+		case "match":
+			flush()
+		case "include":
+			flush()
+			for _, pattern := range strings.Fields(value) {
+				if depth >= maxIncludeDepth {
+					break
+				}
+				pattern = expandHome(pattern)
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(c.Path), pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, match := range matches {
+					if err := c.parse(match, depth+1, candidates); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			// ssh uses the first value given for each option
+			for _, candidate := range block {
+				switch keyword {
+				case "hostname":
+					if candidate.Hostname == "" {
+						candidate.Hostname = value
+					}
+				case "user":
+					if candidate.Username == "" {
+						candidate.Username = value
+					}
+				case "port":
+					if port, err := strconv.Atoi(value); err == nil && candidate.Port == 0 {
+						candidate.Port = port
+					}
+				case "identityfile":
+					if candidate.KeyPath == "" {
+						candidate.KeyPath = expandHome(value)
+					}
+				case "proxyjump":
+					if candidate.ProxyJump == "" && !strings.EqualFold(value, "none") {
+						candidate.ProxyJump = value
+					}
+				case "addressfamily":
+					if candidate.Family == "" {
+						candidate.Family = addressFamily(value)
+					}
+				}
+			}
+		}
+	}
+	flush()
+	return scanner.Err()
+}
+
+// addressFamily returns the family of an AddressFamily value, "" for any
+func addressFamily(value string) string {
+	switch value = strings.ToLower(value); value {
+	case "inet", "inet6":
+		return value
+	}
+	return ""
+}
+
+// splitDirective parses "Keyword value" or "Keyword=value", lowercasing the
+// keyword and dropping comments and quotes
+func splitDirective(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return "", ""
+	}
+	keyword := strings.ToLower(line[:end])
+	value := strings.TrimSpace(line[end:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	return keyword, strings.Trim(value, `"`)
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
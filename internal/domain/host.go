@@ -1,24 +1,144 @@
 package domain
 
 import (
+	"fmt"
+	"net/netip"
 	"time"
 )
 
 // Host represents an SSH host configuration
 type Host struct {
+	ID             int       `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	Hostname       string    `json:"hostname" db:"hostname"`
+	IPAddress      string    `json:"ip_address" db:"ip_address"`
+	Port           int       `json:"port" db:"port"`
+	Username       string    `json:"username" db:"username"`
+	KeyPath        string    `json:"key_path" db:"key_path"`
+	Description    string    `json:"description" db:"description"`
+	Tags           string    `json:"tags" db:"tags"`
+	Group          string    `json:"group,omitempty" db:"group_name"`
+	ProxyJump      string    `json:"proxy_jump,omitempty" db:"proxy_jump"`
+	Origin         string    `json:"origin,omitempty" db:"origin"`       // Empty for local hosts, OriginShared for the team inventory
+	Connector      string    `json:"connector,omitempty" db:"connector"` // SSH client backend; empty uses the configured default
+	ForwardAgent   bool      `json:"forward_agent,omitempty" db:"forward_agent"`
+	IdentitiesOnly bool      `json:"identities_only,omitempty" db:"identities_only"` // Offer only the host's key, not every agent key
+	Record         bool      `json:"record,omitempty" db:"record"`                   // Record sessions even when recording is off globally
+	AddressFamily  string    `json:"address_family,omitempty" db:"address_family"`   // inet or inet6 to use only IPv4 or IPv6; empty for either
+	SourceID       string    `json:"source_id,omitempty" db:"source_id"`             // Machine in the file it was imported from, e.g. a Terraform resource
+	SourceScope    string    `json:"source_scope,omitempty" db:"source_scope"`       // Tells apart equal SourceIDs, e.g. the Terraform state lineage
+	Favorite       bool      `json:"favorite,omitempty"`                             // From the personal overlay
+	LastUsed       time.Time `json:"last_used" db:"last_used"`
+	UseCount       int       `json:"use_count" db:"use_count"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// OriginShared marks hosts owned by the team-shared inventory file
+const OriginShared = "shared"
+
+// Address families a host can be limited to, named as in ssh_config
+const (
+	FamilyInet  = "inet"
+	FamilyInet6 = "inet6"
+)
+
+// Endpoint returns user@host:port for display, with IPv6 addresses in
+// brackets: deploy@[2001:db8::1]:22
+func (h *Host) Endpoint() string {
+	hostname := h.Hostname
+	if addr, err := netip.ParseAddr(hostname); err == nil && addr.Is6() {
+		hostname = "[" + hostname + "]"
+	}
+	return fmt.Sprintf("%s@%s:%d", h.Username, hostname, h.Port)
+}
+
+// IsShared reports whether the host comes from the shared inventory and is read-only
+func (h *Host) IsShared() bool {
+	return h.Origin == OriginShared
+}
+
+// Overlay holds personal settings layered over a host. For shared hosts it
+// is the only place local changes are kept.
+type Overlay struct {
+	HostName string `json:"host_name"`
+	Username string `json:"username,omitempty"`
+	KeyPath  string `json:"key_path,omitempty"`
+	Favorite bool   `json:"favorite,omitempty"`
+}
+
+// IsEmpty reports whether the overlay changes nothing
+func (o *Overlay) IsEmpty() bool {
+	return o.Username == "" && o.KeyPath == "" && !o.Favorite
+}
+
+// IgnoreRule keeps hosts out of discovery: an exact name or hostname, a
+// hostname glob or a CIDR
+type IgnoreRule struct {
+	Pattern   string    `json:"pattern"`
+	Note      string    `json:"note,omitempty"` // Why it was added, e.g. the deleted host
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DNSRecord is the cached lookup of a hostname
+type DNSRecord struct {
+	Hostname   string    `json:"hostname"`
+	Addresses  []string  `json:"addresses"`       // Every A and AAAA record, preferred first
+	Error      string    `json:"error,omitempty"` // Why the last lookup failed; Addresses are from the last one that worked
+	ResolvedAt time.Time `json:"resolved_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the record should be looked up again
+func (r *DNSRecord) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// SameAddresses reports whether addresses holds the record's addresses in any order
+func (r *DNSRecord) SameAddresses(addresses []string) bool {
+	if len(addresses) != len(r.Addresses) {
+		return false
+	}
+	have := make(map[string]bool)
+	for _, address := range r.Addresses {
+		have[address] = true
+	}
+	for _, address := range addresses {
+		if !have[address] {
+			return false
+		}
+	}
+	return true
+}
+
+// AddressChange records the addresses a hostname resolved to from a point
+// in time, so moves can be traced
+type AddressChange struct {
+	ID        int       `json:"id"`
+	Hostname  string    `json:"hostname"`
+	Addresses []string  `json:"addresses"`
+	Previous  []string  `json:"previous,omitempty"` // Empty the first time the hostname resolved
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// HistoryEntry records a single connection to a host
+type HistoryEntry struct {
 	ID          int       `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Hostname    string    `json:"hostname" db:"hostname"`
-	IPAddress   string    `json:"ip_address" db:"ip_address"`
-	Port        int       `json:"port" db:"port"`
-	Username    string    `json:"username" db:"username"`
-	KeyPath     string    `json:"key_path" db:"key_path"`
-	Description string    `json:"description" db:"description"`
-	Tags        string    `json:"tags" db:"tags"`
-	LastUsed    time.Time `json:"last_used" db:"last_used"`
-	UseCount    int       `json:"use_count" db:"use_count"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	HostID      int       `json:"host_id" db:"host_id"` // 0 once the host was deleted
+	HostName    string    `json:"host_name" db:"host_name"`
+	ConnectedAt time.Time `json:"connected_at" db:"connected_at"`
+	// asciicast file of the session, empty if it was not recorded
+	RecordingPath string `json:"recording_path,omitempty" db:"recording_path"`
+}
+
+// Restore is a backup to apply to the database in one step. Hosts are
+// matched by name, as IDs differ between databases.
+type Restore struct {
+	Remove         []string        // Names of the hosts to delete
+	Update         []*Host         // Overwrite the hosts of the same name, including usage statistics
+	Add            []*Host         // Inserted as-is, keeping timestamps and usage statistics
+	ReplaceHistory bool            // Drop the whole history before adding History
+	History        []*HistoryEntry // Linked to the host with their HostName
 }
 
 // Repository interface for host operations
@@ -31,11 +151,148 @@ type HostRepository interface {
 	Delete(id int) error
 	Search(query string) ([]*Host, error)
 	IncrementUseCount(id int) error
+	Merge(keep *Host, removeIDs []int) error
+	Restore(restore *Restore) error
+
+	AddHistory(entry *HistoryEntry) error
+	GetHistory(hostID int, limit int) ([]*HistoryEntry, error)
+	GetHistoryEntry(id int) (*HistoryEntry, error)
+
+	GetShared() ([]*Host, error)
+	GetOverlay(hostName string) (*Overlay, error)
+	SetOverlay(overlay *Overlay) error
+	DeleteOverlay(hostName string) error
+
+	GetIgnoreRules() ([]*IgnoreRule, error)
+	AddIgnoreRule(rule *IgnoreRule) error
+	DeleteIgnoreRule(pattern string) error
+
+	GetDNSRecord(hostname string) (*DNSRecord, error)
+	GetDNSRecords() ([]*DNSRecord, error)
+	SaveDNSRecord(record *DNSRecord) error
+	GetAddressHistory(hostname string, limit int) ([]*AddressChange, error)
 }
 
 // Config represents application configuration
 type Config struct {
-	DatabasePath string `json:"database_path"`
-	DefaultPort  int    `json:"default_port"`
-	DefaultUser  string `json:"default_user"`
+	DatabasePath string                 `json:"database_path" yaml:"database_path,omitempty"`
+	DefaultPort  int                    `json:"default_port" yaml:"default_port"`
+	DefaultUser  string                 `json:"default_user" yaml:"default_user"` // Empty means the current OS user
+	Discovery    DiscoveryConfig        `json:"discovery" yaml:"discovery"`
+	Shared       SharedConfig           `json:"shared" yaml:"shared"`
+	Connector    string                 `json:"connector" yaml:"connector"` // Default SSH client backend: system or native
+	Agent        AgentConfig            `json:"agent" yaml:"agent"`
+	Recording    RecordingConfig        `json:"recording" yaml:"recording"`
+	Resolver     ResolverConfig         `json:"resolver" yaml:"resolver"`
+	Hooks        []HookConfig           `json:"hooks,omitempty" yaml:"hooks,omitempty"`   // Commands run around connections
+	Theme        string                 `json:"theme" yaml:"theme"`                       // Built-in or user theme name; auto follows the terminal background
+	Themes       map[string]ThemeConfig `json:"themes,omitempty" yaml:"themes,omitempty"` // User themes by name
+	Keymap       map[string][]string    `json:"keymap,omitempty" yaml:"keymap,omitempty"` // TUI action -> keys
+}
+
+// DiscoveryConfig controls automatic host discovery
+type DiscoveryConfig struct {
+	Enabled      bool     `json:"enabled" yaml:"enabled"`
+	Sources      []string `json:"sources" yaml:"sources"`
+	Naming       string   `json:"naming" yaml:"naming"`                                   // How discovered hosts are named: short, suffix, fqdn or template
+	NameTemplate string   `json:"name_template,omitempty" yaml:"name_template,omitempty"` // For the template strategy, e.g. "{label}-{port}"
+}
+
+// SharedConfig points at the team-shared inventory file
+type SharedConfig struct {
+	Path string `json:"path,omitempty" yaml:"path,omitempty"` // YAML or JSON file, usually in a git checkout
+	Pull bool   `json:"pull" yaml:"pull"`                     // Run git pull before syncing
+}
+
+// AgentConfig controls loading host keys into ssh-agent before connecting
+type AgentConfig struct {
+	OfferAdd bool   `json:"offer_add" yaml:"offer_add"`                   // Offer ssh-add when the host's key is not loaded
+	Lifetime string `json:"lifetime,omitempty" yaml:"lifetime,omitempty"` // ssh-add -t, e.g. 1h; empty keeps keys until the agent exits
+	Confirm  bool   `json:"confirm" yaml:"confirm"`                       // ssh-add -c: confirm each use of the key
+}
+
+// RecordingConfig controls session recording
+type RecordingConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"` // Record every session, not just hosts marked for recording
+}
+
+// ResolverConfig controls DNS lookups and the address cache
+type ResolverConfig struct {
+	Timeout string `json:"timeout" yaml:"timeout"` // Longest wait for one lookup, e.g. 2s
+	TTL     string `json:"ttl" yaml:"ttl"`         // How long looked up addresses are used before refreshing, e.g. 1h
+}
+
+// ThemeConfig is a user TUI color scheme. Colors are #rrggbb hex values or
+// ANSI color numbers (0-255); colors left out come from the base theme.
+type ThemeConfig struct {
+	Base       string `json:"base,omitempty" yaml:"base,omitempty"`               // Built-in theme to start from, auto if empty
+	Header     string `json:"header,omitempty" yaml:"header,omitempty"`           // Background of titles
+	HeaderText string `json:"header_text,omitempty" yaml:"header_text,omitempty"` // Text of titles
+	Accent     string `json:"accent,omitempty" yaml:"accent,omitempty"`           // Selected items and messages
+	Secondary  string `json:"secondary,omitempty" yaml:"secondary,omitempty"`     // Selected descriptions and tags
+	Warning    string `json:"warning,omitempty" yaml:"warning,omitempty"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+	Muted      string `json:"muted,omitempty" yaml:"muted,omitempty"` // Descriptions, help and labels
+	Text       string `json:"text,omitempty" yaml:"text,omitempty"`
+}
+
+// Colors returns the theme's colors by setting name, empty when unset
+func (t ThemeConfig) Colors() map[string]string {
+	return map[string]string{
+		"header":      t.Header,
+		"header_text": t.HeaderText,
+		"accent":      t.Accent,
+		"secondary":   t.Secondary,
+		"warning":     t.Warning,
+		"error":       t.Error,
+		"muted":       t.Muted,
+		"text":        t.Text,
+	}
+}
+
+// HookConfig is a pair of shell commands run before and after connecting to
+// the hosts it matches. A hook without hosts or tags matches every host.
+type HookConfig struct {
+	Name  string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Hosts []string `json:"hosts,omitempty" yaml:"hosts,omitempty"` // Host names
+	Tags  []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Pre   string   `json:"pre,omitempty" yaml:"pre,omitempty"`   // A failure aborts the connection
+	Post  string   `json:"post,omitempty" yaml:"post,omitempty"` // Runs when the session ends, even if it failed
+}
+
+// LifetimeDuration returns the parsed key lifetime, zero if unset or invalid
+func (a AgentConfig) LifetimeDuration() time.Duration {
+	lifetime, err := time.ParseDuration(a.Lifetime)
+	if err != nil {
+		return 0
+	}
+	return lifetime
+}
+
+// TimeoutDuration returns the parsed lookup timeout, zero if unset or invalid
+func (r ResolverConfig) TimeoutDuration() time.Duration {
+	timeout, err := time.ParseDuration(r.Timeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// TTLDuration returns the parsed cache lifetime, zero if unset or invalid
+func (r ResolverConfig) TTLDuration() time.Duration {
+	ttl, err := time.ParseDuration(r.TTL)
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
+
+// SourceEnabled reports whether discovery may use the named source
+func (c *Config) SourceEnabled(source string) bool {
+	for _, s := range c.Discovery.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,222 @@
+// Package exporter writes sshm hosts in formats understood by other tools
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// AnsibleInventory is an Ansible inventory built from sshm hosts
+type AnsibleInventory struct {
+	Groups   map[string][]string               // Group name -> inventory host names
+	HostVars map[string]map[string]interface{} // Inventory host name -> variables
+	hosts    []string                          // Inventory host names in input order
+}
+
+var invalidGroupChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// AnsibleGroupName turns a tag or group into a valid Ansible group name
+func AnsibleGroupName(name string) string {
+	name = invalidGroupChars.ReplaceAllString(strings.TrimSpace(name), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// NewAnsibleInventory groups hosts by tag and by group. ProxyJump must already
+// be resolved to ssh -J syntax. tags splits a host's tag string.
+func NewAnsibleInventory(hosts []*domain.Host, tags func(string) []string) *AnsibleInventory {
+	inv := &AnsibleInventory{
+		Groups:   make(map[string][]string),
+		HostVars: make(map[string]map[string]interface{}),
+	}
+
+	for _, host := range hosts {
+		name := strings.Join(strings.Fields(host.Name), "_")
+		inv.hosts = append(inv.hosts, name)
+		inv.HostVars[name] = ansibleHostVars(host)
+
+		groups := make(map[string]bool)
+		if host.Group != "" {
+			groups[AnsibleGroupName(host.Group)] = true
+		}
+		for _, tag := range tags(host.Tags) {
+			groups[AnsibleGroupName(tag)] = true
+		}
+		for group := range groups {
+			inv.Groups[group] = append(inv.Groups[group], name)
+		}
+	}
+
+	return inv
+}
+
+func ansibleHostVars(host *domain.Host) map[string]interface{} {
+	vars := map[string]interface{}{
+		"ansible_host": host.Hostname,
+		"ansible_port": host.Port,
+		"ansible_user": host.Username,
+	}
+	if host.IPAddress != "" {
+		vars["ansible_host"] = host.IPAddress // Works without DNS on the control node
+	}
+	if host.KeyPath != "" {
+		vars["ansible_ssh_private_key_file"] = host.KeyPath
+	}
+	if host.ProxyJump != "" {
+		vars["ansible_ssh_common_args"] = "-o ProxyJump=" + host.ProxyJump
+	}
+	return vars
+}
+
+// ungrouped returns hosts that belong to no group
+func (inv *AnsibleInventory) ungrouped() []string {
+	grouped := make(map[string]bool)
+	for _, members := range inv.Groups {
+		for _, host := range members {
+			grouped[host] = true
+		}
+	}
+	var hosts []string
+	for _, host := range inv.hosts {
+		if !grouped[host] {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+func (inv *AnsibleInventory) groupNames() []string {
+	names := make([]string, 0, len(inv.Groups))
+	for name := range inv.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteINI writes the inventory in INI format with variables inline
+func (inv *AnsibleInventory) WriteINI(w io.Writer) error {
+	// Variables are written once, where the host first appears; Ansible merges them
+	written := make(map[string]bool)
+	writeSection := func(name string, hosts []string) {
+		fmt.Fprintf(w, "[%s]\n", name)
+		for _, host := range hosts {
+			if written[host] {
+				fmt.Fprintln(w, host)
+				continue
+			}
+			written[host] = true
+			fmt.Fprintln(w, host+iniHostVars(inv.HostVars[host]))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "# Generated by sshm")
+	fmt.Fprintln(w)
+	if ungrouped := inv.ungrouped(); len(ungrouped) > 0 {
+		writeSection("ungrouped", ungrouped)
+	}
+	for _, name := range inv.groupNames() {
+		writeSection(name, inv.Groups[name])
+	}
+	return nil
+}
+
+func iniHostVars(vars map[string]interface{}) string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		value := fmt.Sprint(vars[key])
+		if strings.ContainsAny(value, " \t'\"\\#") {
+			value = iniQuote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	return b.String()
+}
+
+// iniQuote double-quotes a value for Ansible, which splits INI lines with
+// POSIX shlex rules: only backslashes and double quotes are escaped inside
+// double quotes, while nothing can be escaped inside single quotes
+func iniQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// WriteYAML writes the inventory in YAML format under the "all" group
+func (inv *AnsibleInventory) WriteYAML(w io.Writer) error {
+	type group struct {
+		Hosts map[string]map[string]interface{} `yaml:"hosts,omitempty"`
+	}
+	type all struct {
+		Hosts    map[string]map[string]interface{} `yaml:"hosts,omitempty"`
+		Children map[string]group                  `yaml:"children,omitempty"`
+	}
+
+	root := all{Hosts: make(map[string]map[string]interface{}), Children: make(map[string]group)}
+	for _, host := range inv.ungrouped() {
+		root.Hosts[host] = inv.HostVars[host]
+	}
+	written := make(map[string]bool)
+	for _, name := range inv.groupNames() {
+		g := group{Hosts: make(map[string]map[string]interface{})}
+		for _, host := range inv.Groups[name] {
+			if written[host] {
+				g.Hosts[host] = nil
+				continue
+			}
+			written[host] = true
+			g.Hosts[host] = inv.HostVars[host]
+		}
+		root.Children[name] = g
+	}
+
+	fmt.Fprintln(w, "# Generated by sshm")
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]all{"all": root}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// WriteList writes the JSON expected from a dynamic inventory script called with --list
+func (inv *AnsibleInventory) WriteList(w io.Writer) error {
+	output := make(map[string]interface{})
+	for name, hosts := range inv.Groups {
+		output[name] = map[string][]string{"hosts": hosts}
+	}
+	if ungrouped := inv.ungrouped(); len(ungrouped) > 0 {
+		output["ungrouped"] = map[string][]string{"hosts": ungrouped}
+	}
+	output["_meta"] = map[string]interface{}{"hostvars": inv.HostVars}
+	return writeJSON(w, output)
+}
+
+// WriteHost writes the JSON expected from a dynamic inventory script called with --host
+func (inv *AnsibleInventory) WriteHost(w io.Writer, name string) error {
+	vars, ok := inv.HostVars[name]
+	if !ok {
+		vars = map[string]interface{}{} // Ansible expects an empty object for unknown hosts
+	}
+	return writeJSON(w, vars)
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,29 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+func TestWriteINIQuotesValues(t *testing.T) {
+	hosts := []*domain.Host{{
+		Name:     "web",
+		Hostname: "web.example.com",
+		Port:     22,
+		Username: `dev"ops`,
+		KeyPath:  `/home/o'neil/.ssh/id web`,
+	}}
+	inv := NewAnsibleInventory(hosts, func(string) []string { return nil })
+
+	var out bytes.Buffer
+	if err := inv.WriteINI(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := `web ansible_host=web.example.com ansible_port=22 ansible_ssh_private_key_file="/home/o'neil/.ssh/id web" ansible_user="dev\"ops"`
+	if !strings.Contains(out.String(), want+"\n") {
+		t.Errorf("inventory =\n%s\nwant line\n%s", out.String(), want)
+	}
+}
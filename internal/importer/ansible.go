@@ -0,0 +1,418 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ansibleParser reads Ansible inventories in INI or YAML format
+type ansibleParser struct{}
+
+func init() {
+	Register(ansibleParser{})
+}
+
+func (ansibleParser) Name() string { return "ansible" }
+
+func (ansibleParser) Description() string { return "Ansible inventory (INI or YAML)" }
+
+func (ansibleParser) Detect(path string, data []byte) bool {
+	if isAnsibleYAML(path, data) {
+		return true
+	}
+	// INI inventories nearly always use ansible_* variables or [group:children]
+	return bytes.Contains(data, []byte("ansible_")) || bytes.Contains(data, []byte(":children]"))
+}
+
+func (ansibleParser) Parse(data []byte) ([]*Entry, error) {
+	inventory := newAnsibleInventory()
+
+	var err error
+	if isAnsibleYAML("", data) {
+		err = inventory.loadYAML(data)
+	} else {
+		err = inventory.loadINI(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return inventory.entries(), nil
+}
+
+func isAnsibleYAML(path string, data []byte) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yml" || ext == ".yaml" {
+		return bytes.Contains(data, []byte("hosts:"))
+	}
+	trimmed := bytes.TrimSpace(data)
+	return bytes.HasPrefix(trimmed, []byte("all:")) || bytes.HasPrefix(trimmed, []byte("---"))
+}
+
+// proxyJumpArg finds the jump host in ansible_ssh_common_args ("-J x" or "-o ProxyJump=x")
+var proxyJumpArg = regexp.MustCompile(`(?:-J\s*|ProxyJump[= ])([^\s'"]+)`)
+
+type ansibleGroup struct {
+	hosts    []string
+	vars     map[string]string
+	children []string
+}
+
+type ansibleInventory struct {
+	groups    map[string]*ansibleGroup
+	hostVars  map[string]map[string]string
+	hostOrder []string
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{
+		groups:   make(map[string]*ansibleGroup),
+		hostVars: make(map[string]map[string]string),
+	}
+}
+
+func (inv *ansibleInventory) group(name string) *ansibleGroup {
+	g, ok := inv.groups[name]
+	if !ok {
+		g = &ansibleGroup{vars: make(map[string]string)}
+		inv.groups[name] = g
+	}
+	return g
+}
+
+func (inv *ansibleInventory) addHost(group, host string, vars map[string]string) {
+	if _, ok := inv.hostVars[host]; !ok {
+		inv.hostVars[host] = make(map[string]string)
+		inv.hostOrder = append(inv.hostOrder, host)
+	}
+	for k, v := range vars {
+		inv.hostVars[host][k] = v
+	}
+	g := inv.group(group)
+	for _, existing := range g.hosts {
+		if existing == host {
+			return
+		}
+	}
+	g.hosts = append(g.hosts, host)
+}
+
+func (inv *ansibleInventory) loadINI(data []byte) error {
+	section := "ungrouped"
+	kind := "hosts" // hosts, vars or children
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.Trim(line, "[]")
+			kind = "hosts"
+			if name, suffix, ok := strings.Cut(section, ":"); ok {
+				section, kind = name, suffix
+			}
+			inv.group(section)
+			continue
+		}
+
+		switch kind {
+		case "vars":
+			if key, value, ok := strings.Cut(line, "="); ok {
+				inv.group(section).vars[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+			}
+		case "children":
+			g := inv.group(section)
+			g.children = append(g.children, strings.Fields(line)[0])
+			inv.group(strings.Fields(line)[0])
+		default:
+			fields := splitINIFields(line)
+			vars := make(map[string]string)
+			for _, field := range fields[1:] {
+				if key, value, ok := strings.Cut(field, "="); ok {
+					vars[key] = unquote(value)
+				}
+			}
+			hosts, err := expandHostPattern(fields[0])
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			for _, host := range hosts {
+				inv.addHost(section, host, vars)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+type ansibleYAMLGroup struct {
+	Hosts    map[string]map[string]interface{} `yaml:"hosts"`
+	Vars     map[string]interface{}            `yaml:"vars"`
+	Children map[string]*ansibleYAMLGroup      `yaml:"children"`
+}
+
+func (inv *ansibleInventory) loadYAML(data []byte) error {
+	var root map[string]*ansibleYAMLGroup
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("invalid YAML inventory: %w", err)
+	}
+
+	var walk func(name string, g *ansibleYAMLGroup) error
+	walk = func(name string, g *ansibleYAMLGroup) error {
+		group := inv.group(name)
+		if g == nil {
+			return nil
+		}
+		for key, value := range g.Vars {
+			group.vars[key] = fmt.Sprint(value)
+		}
+
+		hostNames := make([]string, 0, len(g.Hosts))
+		for host := range g.Hosts {
+			hostNames = append(hostNames, host)
+		}
+		sort.Strings(hostNames)
+		for _, pattern := range hostNames {
+			vars := make(map[string]string)
+			for key, value := range g.Hosts[pattern] {
+				vars[key] = fmt.Sprint(value)
+			}
+			hosts, err := expandHostPattern(pattern)
+			if err != nil {
+				return err
+			}
+			for _, host := range hosts {
+				inv.addHost(name, host, vars)
+			}
+		}
+
+		childNames := make([]string, 0, len(g.Children))
+		for child := range g.Children {
+			childNames = append(childNames, child)
+		}
+		sort.Strings(childNames)
+		for _, child := range childNames {
+			group.children = append(group.children, child)
+			if err := walk(child, g.Children[child]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(root))
+	for name := range root {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := walk(name, root[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entries resolves group membership and variable precedence into hosts
+func (inv *ansibleInventory) entries() []*Entry {
+	parents := make(map[string][]string)
+	for name, g := range inv.groups {
+		for _, child := range g.children {
+			parents[child] = append(parents[child], name)
+		}
+	}
+
+	groupNames := make([]string, 0, len(inv.groups))
+	for name := range inv.groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	var entries []*Entry
+	for _, host := range inv.hostOrder {
+		// Groups that list the host directly
+		var direct []string
+		for _, name := range groupNames {
+			for _, member := range inv.groups[name].hosts {
+				if member == host {
+					direct = append(direct, name)
+				}
+			}
+		}
+
+		// Ancestors come before the groups that inherit from them
+		var ordered []string
+		seen := make(map[string]bool)
+		var visit func(name string)
+		visit = func(name string) {
+			if seen[name] {
+				return
+			}
+			seen[name] = true
+			for _, parent := range parents[name] {
+				visit(parent)
+			}
+			ordered = append(ordered, name)
+		}
+		for _, name := range direct {
+			visit(name)
+		}
+
+		vars := make(map[string]string)
+		for k, v := range inv.group("all").vars {
+			vars[k] = v
+		}
+		for _, name := range ordered {
+			for k, v := range inv.groups[name].vars {
+				vars[k] = v
+			}
+		}
+		for k, v := range inv.hostVars[host] {
+			vars[k] = v
+		}
+
+		entry := &Entry{
+			Name:     host,
+			Hostname: firstNonEmpty(vars["ansible_host"], vars["ansible_ssh_host"], host),
+			Username: firstNonEmpty(vars["ansible_user"], vars["ansible_ssh_user"]),
+			KeyPath:  firstNonEmpty(vars["ansible_ssh_private_key_file"], vars["ansible_private_key_file"]),
+		}
+		if port, err := strconv.Atoi(firstNonEmpty(vars["ansible_port"], vars["ansible_ssh_port"])); err == nil {
+			entry.Port = port
+		}
+		if match := proxyJumpArg.FindStringSubmatch(vars["ansible_ssh_common_args"]); match != nil {
+			entry.ProxyJump = match[1]
+		}
+
+		for _, name := range direct {
+			if name == "all" || name == "ungrouped" {
+				continue
+			}
+			if entry.Group == "" {
+				entry.Group = name
+			}
+		}
+		for _, name := range ordered {
+			if name != "all" && name != "ungrouped" && name != entry.Group {
+				entry.Tags = append(entry.Tags, name)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// expandHostPattern expands Ansible ranges such as web[01:03] and db-[a:c]
+func expandHostPattern(pattern string) ([]string, error) {
+	start := strings.Index(pattern, "[")
+	if start < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.Index(pattern[start:], "]")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated range in %q", pattern)
+	}
+	end += start
+
+	prefix, rangeSpec, suffix := pattern[:start], pattern[start+1:end], pattern[end+1:]
+	first, last, ok := strings.Cut(rangeSpec, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid range in %q", pattern)
+	}
+
+	rest, err := expandHostPattern(suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	if from, err1 := strconv.Atoi(first); err1 == nil {
+		to, err2 := strconv.Atoi(last)
+		if err2 != nil || to < from {
+			return nil, fmt.Errorf("invalid range in %q", pattern)
+		}
+		width := 0
+		if strings.HasPrefix(first, "0") {
+			width = len(first)
+		}
+		for i := from; i <= to; i++ {
+			values = append(values, fmt.Sprintf("%0*d", width, i))
+		}
+	} else if len(first) == 1 && len(last) == 1 && first[0] <= last[0] {
+		for c := first[0]; c <= last[0]; c++ {
+			values = append(values, string(c))
+		}
+	} else {
+		return nil, fmt.Errorf("invalid range in %q", pattern)
+	}
+
+	var hosts []string
+	for _, value := range values {
+		for _, tail := range rest {
+			hosts = append(hosts, prefix+value+tail)
+		}
+	}
+	return hosts, nil
+}
+
+// splitINIFields splits a host line on whitespace, keeping quoted values such
+// as ansible_ssh_common_args='-o ProxyJump=bastion' together
+func splitINIFields(line string) []string {
+	var fields []string
+	var current strings.Builder
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			current.WriteRune(r)
+		case r == ' ' || r == '\t':
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
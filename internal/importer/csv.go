@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// csvParser reads CSV exports such as Termius and MobaXterm host lists.
+// Columns are matched by header name, so column order does not matter.
+type csvParser struct{}
+
+func init() {
+	Register(csvParser{})
+}
+
+// Header aliases, compared after lower-casing and removing spaces, "_" and "-"
+var csvColumns = map[string][]string{
+	"name":     {"label", "name", "sessionname", "session", "alias", "title"},
+	"hostname": {"hostname", "hostname/ip", "host", "address", "ip", "server", "remotehost"},
+	"port":     {"port"},
+	"username": {"username", "user", "login"},
+	"key":      {"sshkey", "key", "privatekey", "identityfile", "keypath"},
+	"group":    {"group", "groups", "folder", "path"},
+	"tags":     {"tags", "tag", "labels"},
+	"protocol": {"protocol", "type", "sessiontype"},
+	"notes":    {"notes", "description", "comment"},
+}
+
+func (csvParser) Name() string { return "csv" }
+
+func (csvParser) Description() string { return "Termius / MobaXterm CSV export" }
+
+func (csvParser) Detect(path string, data []byte) bool {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return true
+	}
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	columns := csvHeaderIndex(strings.Split(string(firstLine), ","))
+	_, hasHost := columns["hostname"]
+	return hasHost && bytes.Contains(firstLine, []byte(","))
+}
+
+func (csvParser) Parse(data []byte) ([]*Entry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := csvHeaderIndex(records[0])
+	if _, ok := columns["hostname"]; !ok {
+		return nil, fmt.Errorf("CSV has no hostname column")
+	}
+
+	get := func(record []string, column string) string {
+		idx, ok := columns[column]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var entries []*Entry
+	for _, record := range records[1:] {
+		protocol := strings.ToLower(get(record, "protocol"))
+		if protocol != "" && protocol != "ssh" {
+			continue
+		}
+
+		username, hostname, port, err := ParseTarget(get(record, "hostname"))
+		if err != nil {
+			continue
+		}
+
+		entry := &Entry{
+			Name:        get(record, "name"),
+			Hostname:    hostname,
+			Port:        port,
+			Username:    username,
+			KeyPath:     get(record, "key"),
+			Description: get(record, "notes"),
+			Group:       get(record, "group"),
+			Tags:        splitList(get(record, "tags")),
+		}
+		if user := get(record, "username"); user != "" {
+			entry.Username = user
+		}
+		if p, err := strconv.Atoi(get(record, "port")); err == nil {
+			entry.Port = p
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func csvHeaderIndex(header []string) map[string]int {
+	normalize := strings.NewReplacer(" ", "", "_", "", "-", "", "\"", "")
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(normalize.Replace(strings.TrimSpace(name)))
+		for column, aliases := range csvColumns {
+			if _, done := index[column]; done {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					index[column] = i
+					break
+				}
+			}
+		}
+	}
+	return index
+}
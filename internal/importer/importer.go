@@ -0,0 +1,236 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Entry is a host read from another tool's export
+type Entry struct {
+	Name        string
+	Hostname    string
+	Port        int
+	Username    string
+	KeyPath     string
+	Description string
+	Group       string
+	ProxyJump   string // ssh -J syntax
+	Tags        []string
+	// Offer only KeyPath to the server, as Vagrant's ssh-config asks
+	IdentitiesOnly bool
+	// Identifies the machine across imports of files describing recreated
+	// machines, e.g. a Terraform resource address; empty if unknown
+	SourceID string
+	// Tells apart machines with the same SourceID in different files, e.g.
+	// the Terraform state lineage. It changes when a lab is set up anew, so
+	// it only decides between several hosts imported with the SourceID.
+	SourceScope string
+}
+
+// Parser reads hosts from one kind of export file
+type Parser interface {
+	// Name is the identifier used with --format and in source tags
+	Name() string
+	// Description is shown in help output
+	Description() string
+	// Detect reports whether the file looks like this parser's format
+	Detect(path string, data []byte) bool
+	// Parse reads all hosts from the file contents
+	Parse(data []byte) ([]*Entry, error)
+}
+
+// PathParser is implemented by parsers that need to know where the file is,
+// not only what it contains. ParseFile uses ParsePath instead of Parse.
+type PathParser interface {
+	ParsePath(path string, data []byte) ([]*Entry, error)
+}
+
+// Refresher is implemented by parsers of files describing machines that are
+// recreated with new addresses, such as Terraform state. Hosts imported from
+// such a file before, recognized by their Entry.SourceID, are updated when it
+// is imported again.
+type Refresher interface {
+	Refreshes() bool
+}
+
+var (
+	parsers  []Parser
+	fallback Parser // Used when no other parser detects the file
+)
+
+// Register adds a parser
+func Register(parser Parser) {
+	parsers = append(parsers, parser)
+}
+
+// RegisterFallback adds a parser that is only tried after all others
+func RegisterFallback(parser Parser) {
+	fallback = parser
+	parsers = append(parsers, parser)
+}
+
+// Parsers returns all registered parsers
+func Parsers() []Parser {
+	return parsers
+}
+
+// Get returns the parser with the given name
+func Get(name string) (Parser, error) {
+	for _, parser := range parsers {
+		if parser.Name() == name {
+			return parser, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown import format %q (available: %s)", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names of all registered parsers, sorted
+func Names() []string {
+	var names []string
+	for _, parser := range parsers {
+		names = append(names, parser.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Refreshes reports whether re-importing the named format updates the hosts
+// imported from it before
+func Refreshes(name string) bool {
+	parser, err := Get(name)
+	if err != nil {
+		return false
+	}
+	refresher, ok := parser.(Refresher)
+	return ok && refresher.Refreshes()
+}
+
+// Detect picks the parser for a file
+func Detect(path string, data []byte) (Parser, error) {
+	for _, parser := range parsers {
+		if parser != fallback && parser.Detect(path, data) {
+			return parser, nil
+		}
+	}
+	if fallback != nil && fallback.Detect(path, data) {
+		return fallback, nil
+	}
+	return nil, fmt.Errorf("cannot detect the format of %s, use --format (available: %s)", path, strings.Join(Names(), ", "))
+}
+
+// ParseFile reads a file with the named parser, or an auto-detected one if format is empty
+func ParseFile(path, format string) ([]*Entry, Parser, error) {
+	data, err := ReadText(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var parser Parser
+	if format != "" {
+		parser, err = Get(format)
+	} else {
+		parser, err = Detect(path, data)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []*Entry
+	if pathParser, ok := parser.(PathParser); ok {
+		entries, err = pathParser.ParsePath(path, data)
+	} else {
+		entries, err = parser.Parse(data)
+	}
+	if err != nil {
+		return nil, parser, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return entries, parser, nil
+}
+
+// ReadText reads a file, converting UTF-16 (as written by regedit) to UTF-8
+func ReadText(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	return decodeText(data), nil
+}
+
+func decodeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], false)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], true)
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:]
+	}
+	return data
+}
+
+func decodeUTF16(data []byte, bigEndian bool) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// ParseTarget splits "user@host:port", "ssh://user@host:port" and
+// "user@[v6addr]:port" into their parts. Missing parts are returned empty/zero.
+func ParseTarget(target string) (username, hostname string, port int, err error) {
+	target = strings.TrimSpace(target)
+	target = strings.TrimPrefix(target, "ssh://")
+	target = strings.TrimSuffix(target, "/")
+
+	if at := strings.LastIndex(target, "@"); at >= 0 {
+		username = target[:at]
+		target = target[at+1:]
+	}
+
+	switch {
+	case strings.HasPrefix(target, "["):
+		host, portStr, splitErr := net.SplitHostPort(target)
+		if splitErr != nil {
+			// Bracketed address without a port
+			hostname = strings.Trim(target, "[]")
+			break
+		}
+		hostname = host
+		if port, err = strconv.Atoi(portStr); err != nil {
+			return "", "", 0, fmt.Errorf("invalid port in %q", target)
+		}
+	case strings.Count(target, ":") == 1:
+		host, portStr, _ := strings.Cut(target, ":")
+		hostname = host
+		if port, err = strconv.Atoi(portStr); err != nil {
+			return "", "", 0, fmt.Errorf("invalid port in %q", target)
+		}
+	default:
+		hostname = target // Plain hostname or unbracketed IPv6 literal
+	}
+
+	if hostname == "" {
+		return "", "", 0, fmt.Errorf("missing hostname in %q", target)
+	}
+	return username, hostname, port, nil
+}
+
+// splitList splits a comma, semicolon or space separated list
+func splitList(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t'
+	})
+	return fields
+}
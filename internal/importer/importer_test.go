@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPuTTY(t *testing.T) {
+	data := []byte(`Windows Registry Editor Version 5.00
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\Default%20Settings]
+"HostName"=""
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\web%20prod]
+"HostName"="deploy@web.example.com"
+"PortNumber"=dword:00000802
+"Protocol"="ssh"
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\serial]
+"HostName"="COM1"
+"Protocol"="serial"
+`)
+	entries := parse(t, "putty.reg", data, "putty")
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Name != "web prod" || e.Hostname != "web.example.com" || e.Username != "deploy" || e.Port != 2050 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	data := []byte("Label,Hostname/IP,Port,Username,Group,Protocol\n" +
+		"db,10.0.0.5,2222,postgres,Databases,SSH\n" +
+		"desk,10.0.0.6,3389,admin,,RDP\n")
+	entries := parse(t, "hosts.csv", data, "csv")
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Name != "db" || e.Hostname != "10.0.0.5" || e.Port != 2222 || e.Username != "postgres" || e.Group != "Databases" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestRemmina(t *testing.T) {
+	data := []byte("[remmina]\nname=bastion\nprotocol=SSH\nserver=bastion.example.com:2200\nusername=ops\ngroup=Infra\n")
+	entries := parse(t, "bastion.remmina", data, "remmina")
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Name != "bastion" || e.Hostname != "bastion.example.com" || e.Port != 2200 || e.Username != "ops" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestAnsibleINI(t *testing.T) {
+	data := []byte(`[web]
+web[01:02].example.com ansible_user=deploy
+
+[db]
+db1 ansible_host=10.0.0.9 ansible_port=2222
+
+[prod:children]
+web
+db
+
+[prod:vars]
+ansible_user=admin
+`)
+	entries := parse(t, "inventory", data, "ansible")
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Name != "web01.example.com" || e.Username != "deploy" || e.Group != "web" || len(e.Tags) != 1 || e.Tags[0] != "prod" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := entries[2]; e.Hostname != "10.0.0.9" || e.Port != 2222 || e.Username != "admin" || e.Group != "db" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestAnsibleYAML(t *testing.T) {
+	data := []byte(`all:
+  vars:
+    ansible_user: root
+  children:
+    cache:
+      hosts:
+        redis1:
+          ansible_host: 10.1.0.1
+`)
+	entries := parse(t, "hosts.yml", data, "ansible")
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if e := entries[0]; e.Name != "redis1" || e.Hostname != "10.1.0.1" || e.Username != "root" || e.Group != "cache" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestPlain(t *testing.T) {
+	data := []byte("# team hosts\nalice@dev.example.com:2022\nssh://ci@[2001:db8::1]:22\nbuild.example.com\n")
+	entries := parse(t, "hosts.txt", data, "plain")
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Hostname != "dev.example.com" || e.Username != "alice" || e.Port != 2022 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := entries[1]; e.Hostname != "2001:db8::1" || e.Username != "ci" || e.Port != 22 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+// parse checks that auto-detection picks the expected parser and returns its entries
+func parse(t *testing.T, path string, data []byte, format string) []*Entry {
+	t.Helper()
+	parser, err := Detect(path, data)
+	if err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+	if parser.Name() != format {
+		t.Fatalf("detected %s, want %s", parser.Name(), format)
+	}
+	entries, err := parser.Parse(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return entries
+}
+
+func TestTerraform(t *testing.T) {
+	data := []byte(`{
+  "version": 4,
+  "terraform_version": "1.9.5",
+  "lineage": "6f1c2d3e",
+  "resources": [
+    {
+      "mode": "managed", "type": "aws_instance", "name": "web",
+      "instances": [
+        {"index_key": 0, "attributes": {"public_ip": "203.0.113.10", "private_ip": "10.0.1.10", "tags": {"Name": "web", "env": "lab"}}},
+        {"index_key": 1, "attributes": {"public_ip": "", "private_ip": "10.0.1.11", "tags": {"Name": "web"}}}
+      ]
+    },
+    {
+      "module": "module.lab", "mode": "managed", "type": "google_compute_instance", "name": "db",
+      "instances": [
+        {"attributes": {"name": "db1", "labels": {"role": "db"}, "network_interface": [{"network_ip": "10.0.2.5", "access_config": []}]}}
+      ]
+    },
+    {"mode": "data", "type": "aws_instance", "name": "other", "instances": [{"attributes": {"public_ip": "198.51.100.1"}}]},
+    {"mode": "managed", "type": "aws_security_group", "name": "ssh", "instances": [{"attributes": {}}]}
+  ]
+}`)
+	entries := parse(t, "terraform.tfstate", data, "terraform")
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(entries), entries)
+	}
+	if e := entries[0]; e.Name != "web" || e.Hostname != "203.0.113.10" || e.Description != "Terraform aws_instance.web[0], private address 10.0.1.10" || len(e.Tags) != 1 || e.Tags[0] != "env=lab" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := entries[1]; e.Name != "web-2" || e.Hostname != "10.0.1.11" || e.SourceID != "aws_instance.web[1]" || e.SourceScope != "6f1c2d3e" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := entries[2]; e.Name != "db1" || e.Hostname != "10.0.2.5" || e.Group != "lab" || len(e.Tags) != 1 || e.Tags[0] != "role=db" ||
+		e.SourceID != "module.lab.google_compute_instance.db" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestVagrant(t *testing.T) {
+	data := []byte(`Host default
+  HostName 127.0.0.1
+  User vagrant
+  Port 2222
+  UserKnownHostsFile /dev/null
+  StrictHostKeyChecking no
+  PasswordAuthentication no
+  IdentityFile "/home/me/labs/k8s/.vagrant/machines/default/virtualbox/private_key"
+  IdentitiesOnly yes
+  LogLevel FATAL
+
+Host web
+  HostName 192.168.121.12
+  User vagrant
+  Port 22
+  UserKnownHostsFile /dev/null
+  IdentityFile /home/me/labs/k8s/.vagrant/machines/web/libvirt/private_key
+`)
+	entries := parse(t, "vagrant-ssh-config", data, "vagrant")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Name != "k8s" || e.Hostname != "127.0.0.1" || e.Port != 2222 || e.Username != "vagrant" || !e.IdentitiesOnly ||
+		e.SourceID != "/home/me/labs/k8s/default" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e.KeyPath != "/home/me/labs/k8s/.vagrant/machines/default/virtualbox/private_key" || len(e.Tags) != 1 || e.Tags[0] != "virtualbox" {
+		t.Errorf("unexpected key and tags %q %v", e.KeyPath, e.Tags)
+	}
+	if e := entries[1]; e.Name != "web" || e.Hostname != "192.168.121.12" || e.Port != 22 || e.Tags[0] != "libvirt" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestVagrantInsecureKey(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "lab")
+	if err := os.MkdirAll(filepath.Join(project, "out"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "Vagrantfile"), []byte("Vagrant.configure(\"2\")\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(project, "out", "ssh-config")
+	data := []byte(`Host web
+  HostName 127.0.0.1
+  User vagrant
+  Port 2200
+  UserKnownHostsFile /dev/null
+  IdentityFile /home/me/.vagrant.d/insecure_private_key
+`)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, parser, err := ParseFile(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parser.Name() != "vagrant" || len(entries) != 1 {
+		t.Fatalf("got %d %s entries, want 1 vagrant entry", len(entries), parser.Name())
+	}
+	if e := entries[0]; e.SourceID != filepath.ToSlash(project)+"/web" || e.Description != "Vagrant machine web in "+filepath.ToSlash(project) {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// plainParser reads one target per line: host, user@host, user@host:port or
+// ssh://user@host:port. An optional second column is used as the host name.
+type plainParser struct{}
+
+func init() {
+	RegisterFallback(plainParser{})
+}
+
+func (plainParser) Name() string { return "plain" }
+
+func (plainParser) Description() string { return "Plain list of user@host:port lines" }
+
+// Detect accepts any file whose lines all parse as targets
+func (plainParser) Detect(path string, data []byte) bool {
+	_, err := plainParser{}.Parse(data)
+	return err == nil
+}
+
+func (plainParser) Parse(data []byte) ([]*Entry, error) {
+	var entries []*Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		username, hostname, port, err := ParseTarget(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		entry := &Entry{Hostname: hostname, Port: port, Username: username}
+		if len(fields) > 1 {
+			entry.Name = fields[1]
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// puttyParser reads PuTTY sessions exported from the Windows registry with
+// regedit /e putty.reg HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions
+type puttyParser struct{}
+
+func init() {
+	Register(puttyParser{})
+}
+
+const puttySessionsKey = `\Software\SimonTatham\PuTTY\Sessions\`
+
+func (puttyParser) Name() string { return "putty" }
+
+func (puttyParser) Description() string { return "PuTTY session registry export (.reg)" }
+
+func (puttyParser) Detect(path string, data []byte) bool {
+	return bytes.Contains(data, []byte(puttySessionsKey))
+}
+
+func (puttyParser) Parse(data []byte) ([]*Entry, error) {
+	var entries []*Entry
+	var current *Entry
+	var protocol string
+
+	flush := func() {
+		if current != nil && current.Hostname != "" && (protocol == "" || protocol == "ssh") {
+			entries = append(entries, current)
+		}
+		current = nil
+		protocol = ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flush()
+			key := strings.Trim(line, "[]")
+			idx := strings.Index(key, puttySessionsKey)
+			if idx < 0 {
+				continue
+			}
+			name := key[idx+len(puttySessionsKey):]
+			if decoded, err := url.PathUnescape(name); err == nil {
+				name = decoded
+			}
+			if name == "" || name == "Default Settings" {
+				continue
+			}
+			current = &Entry{Name: name}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		name, value, ok := parseRegValue(line)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "HostName":
+			// PuTTY accepts user@host in the host name field
+			if user, host, found := strings.Cut(value, "@"); found {
+				current.Username = user
+				current.Hostname = host
+			} else {
+				current.Hostname = value
+			}
+		case "UserName":
+			if value != "" {
+				current.Username = value
+			}
+		case "PortNumber":
+			current.Port = int(parseRegDword(value))
+		case "PublicKeyFile":
+			current.KeyPath = value
+		case "Protocol":
+			protocol = value
+		}
+	}
+	flush()
+
+	return entries, scanner.Err()
+}
+
+// parseRegValue parses "Name"="string" and "Name"=dword:0000001 lines
+func parseRegValue(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, `"`) {
+		return "", "", false
+	}
+	end := strings.Index(line[1:], `"`)
+	if end < 0 {
+		return "", "", false
+	}
+	name := line[1 : end+1]
+	rest := strings.TrimPrefix(line[end+2:], "=")
+
+	if strings.HasPrefix(rest, `"`) && strings.HasSuffix(rest, `"`) && len(rest) >= 2 {
+		value := rest[1 : len(rest)-1]
+		value = strings.ReplaceAll(value, `\\`, `\`)
+		value = strings.ReplaceAll(value, `\"`, `"`)
+		return name, value, true
+	}
+	return name, rest, true
+}
+
+func parseRegDword(value string) int64 {
+	n, err := strconv.ParseInt(strings.TrimPrefix(value, "dword:"), 16, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// remminaParser reads Remmina connection profiles (*.remmina)
+type remminaParser struct{}
+
+func init() {
+	Register(remminaParser{})
+}
+
+func (remminaParser) Name() string { return "remmina" }
+
+func (remminaParser) Description() string { return "Remmina connection profile (.remmina)" }
+
+func (remminaParser) Detect(path string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(path), ".remmina") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("[remmina]"))
+}
+
+func (remminaParser) Parse(data []byte) ([]*Entry, error) {
+	values := make(map[string]string)
+	inSection := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "[remmina]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(values["protocol"], "SSH") || values["server"] == "" {
+		return nil, nil // Only SSH profiles are imported
+	}
+
+	username, hostname, port, err := ParseTarget(values["server"])
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		Name:     values["name"],
+		Hostname: hostname,
+		Port:     port,
+		Username: username,
+		Group:    values["group"],
+	}
+	for _, key := range []string{"ssh_username", "username"} {
+		if values[key] != "" {
+			entry.Username = values[key]
+			break
+		}
+	}
+	for _, key := range []string{"ssh_privatekey", "ssh_tunnel_privatekey"} {
+		if values[key] != "" {
+			entry.KeyPath = values[key]
+			break
+		}
+	}
+
+	return []*Entry{entry}, nil
+}
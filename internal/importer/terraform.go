@@ -0,0 +1,219 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// terraformParser reads compute instances from a local Terraform state file
+type terraformParser struct{}
+
+func init() {
+	Register(terraformParser{})
+}
+
+func (terraformParser) Name() string { return "terraform" }
+
+func (terraformParser) Description() string { return "Terraform state (terraform.tfstate)" }
+
+func (terraformParser) Refreshes() bool { return true }
+
+func (terraformParser) Detect(path string, data []byte) bool {
+	if strings.EqualFold(filepath.Ext(path), ".tfstate") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) && bytes.Contains(data, []byte(`"terraform_version"`))
+}
+
+// terraformState is the part of a version 4 state file sshm reads
+type terraformState struct {
+	Version   int    `json:"version"`
+	Lineage   string `json:"lineage"` // Unique to the state, new after terraform init in an empty directory or workspace
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// terraformResource says where a compute resource type keeps its addresses,
+// name, tags and login user. Attribute paths are dotted; the first element
+// of nested blocks and lists is used.
+type terraformResource struct {
+	public  []string
+	private []string
+	name    []string
+	tags    string
+	user    string
+}
+
+var terraformResources = map[string]terraformResource{
+	"aws_instance":                  {public: []string{"public_ip", "public_dns"}, private: []string{"private_ip"}, name: []string{"tags.Name"}, tags: "tags"},
+	"aws_lightsail_instance":        {public: []string{"public_ip_address"}, private: []string{"private_ip_address"}, name: []string{"name"}, tags: "tags", user: "username"},
+	"google_compute_instance":       {public: []string{"network_interface.access_config.nat_ip"}, private: []string{"network_interface.network_ip"}, name: []string{"name"}, tags: "labels"},
+	"azurerm_linux_virtual_machine": {public: []string{"public_ip_address"}, private: []string{"private_ip_address"}, name: []string{"name"}, tags: "tags", user: "admin_username"},
+	"digitalocean_droplet":          {public: []string{"ipv4_address", "ipv6_address"}, private: []string{"ipv4_address_private"}, name: []string{"name"}, tags: "tags"},
+	"hcloud_server":                 {public: []string{"ipv4_address", "ipv6_address"}, private: []string{"network.ip"}, name: []string{"name"}, tags: "labels"},
+	"linode_instance":               {public: []string{"ip_address"}, private: []string{"private_ip_address"}, name: []string{"label"}, tags: "tags"},
+	"vultr_instance":                {public: []string{"main_ip"}, private: []string{"internal_ip"}, name: []string{"label", "hostname"}, tags: "tags"},
+	"openstack_compute_instance_v2": {public: []string{"access_ip_v4", "access_ip_v6"}, private: []string{"network.fixed_ip_v4"}, name: []string{"name"}, tags: "tags"},
+	"vsphere_virtual_machine":       {private: []string{"default_ip_address"}, name: []string{"name"}},
+	"libvirt_domain":                {private: []string{"network_interface.addresses"}, name: []string{"name"}},
+	"proxmox_vm_qemu":               {private: []string{"default_ipv4_address", "ssh_host"}, name: []string{"name"}, user: "ssh_user"},
+}
+
+// Parse returns one entry per instance of a known compute resource type
+// that has an address. The public address is used when there is one.
+func (terraformParser) Parse(data []byte) ([]*Entry, error) {
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid Terraform state: %w", err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported Terraform state version %d (Terraform 0.12 and later write version 4)", state.Version)
+	}
+
+	var entries []*Entry
+	used := make(map[string]int)
+	for _, resource := range state.Resources {
+		kind, ok := terraformResources[resource.Type]
+		if resource.Mode != "managed" || !ok {
+			continue
+		}
+
+		for _, instance := range resource.Instances {
+			attributes := instance.Attributes
+			public := terraformValue(attributes, kind.public)
+			private := terraformValue(attributes, kind.private)
+			hostname := public
+			if hostname == "" {
+				hostname = private
+			}
+			if hostname == "" {
+				continue // Not running, or no address known to Terraform
+			}
+
+			address := resource.Type + "." + resource.Name
+			name := terraformValue(attributes, kind.name)
+			if name == "" {
+				name = resource.Name
+				if instance.IndexKey != nil {
+					name = fmt.Sprintf("%s-%v", name, instance.IndexKey)
+				}
+			}
+			if instance.IndexKey != nil {
+				address += fmt.Sprintf("[%v]", instance.IndexKey)
+			}
+			// Instances sharing a Name tag, as in count loops, get numbered
+			used[name]++
+			if n := used[name]; n > 1 {
+				name = fmt.Sprintf("%s-%d", name, n)
+			}
+
+			description := "Terraform " + address
+			if public != "" && private != "" {
+				description += ", private address " + private
+			}
+
+			entries = append(entries, &Entry{
+				Name:        name,
+				Hostname:    hostname,
+				Username:    terraformValue(attributes, []string{kind.user}),
+				Description: description,
+				Group:       terraformModule(resource.Module),
+				Tags:        terraformTags(attributes[kind.tags]),
+				SourceID:    terraformSourceID(resource.Module, address),
+				SourceScope: state.Lineage,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// terraformValue returns the first non-empty string among attribute paths
+func terraformValue(attributes map[string]interface{}, paths []string) string {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		var value interface{} = attributes
+		for _, key := range strings.Split(path, ".") {
+			value = firstElement(value)
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = object[key]
+		}
+		if s, ok := firstElement(value).(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// firstElement returns the first element of a list, or value itself
+func firstElement(value interface{}) interface{} {
+	if list, ok := value.([]interface{}); ok {
+		if len(list) == 0 {
+			return nil
+		}
+		return list[0]
+	}
+	return value
+}
+
+// terraformTags turns a tag list, or a tag map as key=value, into tags.
+// The Name tag is left out as it becomes the host name.
+func terraformTags(value interface{}) []string {
+	var tags []string
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				tags = append(tags, s)
+			}
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			if s, ok := item.(string); ok && key != "Name" {
+				tags = append(tags, key+"="+strings.ReplaceAll(s, ",", " ")) // Commas separate tags
+			}
+		}
+		sort.Strings(tags)
+	}
+	return tags
+}
+
+// terraformSourceID identifies an instance by its full resource address,
+// which stays the same when the lab is destroyed and created again
+func terraformSourceID(module, address string) string {
+	if module != "" {
+		return module + "." + address
+	}
+	return address
+}
+
+// terraformModule returns the innermost module name of a resource, e.g.
+// "lab" for module.env.module.lab, or "" for the root module
+func terraformModule(module string) string {
+	if module == "" {
+		return ""
+	}
+	parts := strings.Split(module, ".")
+	name := parts[len(parts)-1]
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// vagrantParser reads the output of `vagrant ssh-config`
+type vagrantParser struct{}
+
+func init() {
+	Register(vagrantParser{})
+}
+
+func (vagrantParser) Name() string { return "vagrant" }
+
+func (vagrantParser) Description() string { return "Output of vagrant ssh-config" }
+
+func (vagrantParser) Refreshes() bool { return true }
+
+// Detect looks for the options Vagrant writes for every machine
+func (vagrantParser) Detect(path string, data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("Host ")) &&
+		bytes.Contains(data, []byte("UserKnownHostsFile /dev/null")) &&
+		bytes.Contains(data, []byte("vagrant"))
+}
+
+func (vagrantParser) Parse(data []byte) ([]*Entry, error) {
+	return parseVagrant(data, "")
+}
+
+// ParsePath takes the project of machines whose key does not tell it, such
+// as those using Vagrant's shared insecure key, from the Vagrantfile the
+// ssh-config output was saved next to
+func (vagrantParser) ParsePath(path string, data []byte) ([]*Entry, error) {
+	return parseVagrant(data, vagrantfileDir(path))
+}
+
+// parseVagrant reads ssh-config output; project is the Vagrantfile directory
+// for machines whose key path does not show it, or ""
+func parseVagrant(data []byte, project string) ([]*Entry, error) {
+	var entries []*Entry
+	var entry *Entry
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key, value := strings.ToLower(fields[0]), strings.Trim(strings.Join(fields[1:], " "), `"`)
+
+		if key == "host" {
+			entry = &Entry{Name: value}
+			entries = append(entries, entry)
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+		switch key {
+		case "hostname":
+			entry.Hostname = value
+		case "user":
+			entry.Username = value
+		case "port":
+			if port, err := strconv.Atoi(value); err == nil {
+				entry.Port = port
+			}
+		case "identityfile":
+			if entry.KeyPath == "" {
+				entry.KeyPath = value
+			}
+		case "identitiesonly":
+			entry.IdentitiesOnly = strings.EqualFold(value, "yes")
+		case "proxyjump":
+			entry.ProxyJump = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		project, provider := vagrantMachine(entry.KeyPath, project)
+		if provider != "" {
+			entry.Tags = append(entry.Tags, provider)
+		}
+		entry.Description = "Vagrant machine " + entry.Name
+		if project != "" {
+			entry.Description += " in " + project
+			entry.SourceID = project + "/" + entry.Name
+		}
+		// Single-machine projects all call their machine "default"
+		if entry.Name == "default" {
+			entry.Name = "vagrant"
+			if project != "" {
+				entry.Name = filepath.Base(project)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// vagrantMachine returns the project directory and provider of a machine
+// from its key path, <project>/.vagrant/machines/<name>/<provider>/private_key.
+// Other keys give the fallback project and no provider.
+func vagrantMachine(keyPath, fallback string) (project, provider string) {
+	keyPath = filepath.ToSlash(keyPath)
+	i := strings.Index(keyPath, "/.vagrant/machines/")
+	if i < 0 {
+		return fallback, ""
+	}
+	project = keyPath[:i]
+	parts := strings.Split(keyPath[i+len("/.vagrant/machines/"):], "/")
+	if len(parts) >= 2 {
+		provider = parts[1]
+	}
+	return project, provider
+}
+
+// vagrantfileDir returns the directory of the Vagrantfile nearest to path,
+// looking upwards as vagrant itself does, or "" if there is none
+func vagrantfileDir(path string) string {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "Vagrantfile")); err == nil && !info.IsDir() {
+			return filepath.ToSlash(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,280 @@
+// Package recording writes and plays back terminal sessions in the
+// asciicast v2 format used by asciinema.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// FormatVersion is the asciicast version written and read
+	FormatVersion = 2
+	// Extension is the file extension of recordings
+	Extension = ".cast"
+)
+
+// Event types
+const (
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r"
+)
+
+// Header is the first line of an asciicast v2 file
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is one timed entry: output, input or a terminal resize ("80x24")
+type Event struct {
+	Time float64 // Seconds since the start of the recording
+	Type string
+	Data string
+}
+
+// MarshalJSON encodes an event as asciicast's [time, type, data] array
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Type, e.Data})
+}
+
+// UnmarshalJSON decodes an asciicast [time, type, data] array
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("event must have 3 fields, got %d", len(fields))
+	}
+	if err := json.Unmarshal(fields[0], &e.Time); err != nil {
+		return fmt.Errorf("invalid event time: %w", err)
+	}
+	if err := json.Unmarshal(fields[1], &e.Type); err != nil {
+		return fmt.Errorf("invalid event type: %w", err)
+	}
+	return json.Unmarshal(fields[2], &e.Data)
+}
+
+// Writer records a session to an asciicast file. It is safe for concurrent use.
+type Writer struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	start   time.Time
+	partial []byte // Incomplete UTF-8 sequence held back from the last output
+	err     error
+}
+
+// Create starts a recording at path. The file is private, as sessions may
+// show secrets.
+func Create(path string, width, height int, title string) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("cannot create recordings directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create recording: %w", err)
+	}
+
+	w := &Writer{file: file, encoder: json.NewEncoder(file), start: time.Now()}
+	header := Header{
+		Version:   FormatVersion,
+		Width:     width,
+		Height:    height,
+		Timestamp: w.start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	}
+	if err := w.encoder.Encode(header); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot write recording: %w", err)
+	}
+	return w, nil
+}
+
+// Output records terminal output. A multi-byte character split across
+// calls is recorded whole with the second part.
+func (w *Writer) Output(data []byte) {
+	w.mu.Lock()
+	data = append(w.partial, data...)
+	cut := len(data) - incompleteSuffix(data)
+	w.partial = append([]byte(nil), data[cut:]...)
+	w.mu.Unlock()
+
+	if cut > 0 {
+		w.write(EventOutput, string(data[:cut]))
+	}
+}
+
+// Resize records a change of the terminal size
+func (w *Writer) Resize(width, height int) {
+	w.write(EventResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+// Write records terminal output, so a Writer can be used with io.MultiWriter
+func (w *Writer) Write(data []byte) (int, error) {
+	w.Output(data)
+	return len(data), nil
+}
+
+// Close finishes the recording and returns the first write error, if any
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	partial := w.partial
+	w.partial = nil
+	w.mu.Unlock()
+	if len(partial) > 0 {
+		w.write(EventOutput, string(partial))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+func (w *Writer) write(eventType, data string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return // A failing recording must not interrupt the session
+	}
+	elapsed := time.Since(w.start).Seconds()
+	w.err = w.encoder.Encode(Event{Time: elapsed, Type: eventType, Data: data})
+}
+
+// incompleteSuffix returns the length of a UTF-8 sequence cut off at the end of data
+func incompleteSuffix(data []byte) int {
+	for n := 1; n <= utf8.UTFMax-1 && n <= len(data); n++ {
+		b := data[len(data)-n]
+		if b < utf8.RuneSelf {
+			return 0 // ASCII: nothing pending
+		}
+		if utf8.RuneStart(b) {
+			if utf8.FullRune(data[len(data)-n:]) {
+				return 0
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// Recording is a parsed asciicast file
+type Recording struct {
+	Header Header
+	Events []Event
+}
+
+// Duration returns the time of the last event
+func (r *Recording) Duration() time.Duration {
+	if len(r.Events) == 0 {
+		return 0
+	}
+	return time.Duration(r.Events[len(r.Events)-1].Time * float64(time.Second))
+}
+
+// Read parses an asciicast v2 stream. A truncated last line, as left by a
+// session that was killed, is ignored.
+func Read(r io.Reader) (*Recording, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("empty recording")
+	}
+	recording := &Recording{}
+	if err := json.Unmarshal(scanner.Bytes(), &recording.Header); err != nil {
+		return nil, fmt.Errorf("invalid asciicast header: %w", err)
+	}
+	if recording.Header.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported asciicast version %d (only version %d is supported)",
+			recording.Header.Version, FormatVersion)
+	}
+
+	var pending error
+	line := 1
+	for scanner.Scan() {
+		line++
+		if pending != nil {
+			return nil, pending // Only the last line may be damaged
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(text), &event); err != nil {
+			pending = fmt.Errorf("line %d: %w", line, err)
+			continue
+		}
+		recording.Events = append(recording.Events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
+
+// ReadFile parses an asciicast file
+func ReadFile(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// PlayOptions controls playback
+type PlayOptions struct {
+	Speed   float64       // 2 plays twice as fast; 0 means 1
+	MaxIdle time.Duration // Pauses are shortened to this; 0 keeps them
+}
+
+// Play writes the recording's output to w with its original timing
+func Play(w io.Writer, recording *Recording, opts PlayOptions) error {
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	previous := 0.0
+	for _, event := range recording.Events {
+		delay := time.Duration((event.Time - previous) * float64(time.Second))
+		previous = event.Time
+		if opts.MaxIdle > 0 && delay > opts.MaxIdle {
+			delay = opts.MaxIdle
+		}
+		if delay > 0 {
+			time.Sleep(time.Duration(float64(delay) / speed))
+		}
+
+		if event.Type != EventOutput {
+			continue
+		}
+		if _, err := io.WriteString(w, event.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package recording
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session"+Extension)
+	w, err := Create(path, 120, 40, "web01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	euro := []byte("€") // 3 bytes, split across two writes
+	w.Output([]byte("hello "))
+	w.Output(euro[:1])
+	w.Output(append(euro[1:], '\n'))
+	w.Resize(100, 30)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recording, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recording.Header.Width != 120 || recording.Header.Height != 40 || recording.Header.Title != "web01" {
+		t.Errorf("unexpected header %+v", recording.Header)
+	}
+
+	var output strings.Builder
+	for _, event := range recording.Events {
+		if event.Type == EventOutput {
+			output.WriteString(event.Data)
+		}
+	}
+	if output.String() != "hello €\n" {
+		t.Errorf("output = %q", output.String())
+	}
+	last := recording.Events[len(recording.Events)-1]
+	if last.Type != EventResize || last.Data != "100x30" {
+		t.Errorf("last event = %+v, want resize 100x30", last)
+	}
+}
+
+func TestReadIgnoresTruncatedLastLine(t *testing.T) {
+	input := `{"version": 2, "width": 80, "height": 24}
+[0.5, "o", "one"]
+[1.0, "o", "tw`
+	recording, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Events) != 1 {
+		t.Fatalf("got %d events, want 1", len(recording.Events))
+	}
+
+	damaged := `{"version": 2, "width": 80, "height": 24}
+[0.5, "o"
+[1.0, "o", "two"]`
+	if _, err := Read(strings.NewReader(damaged)); err == nil {
+		t.Error("expected an error for a damaged line before the end")
+	}
+
+	if _, err := Read(strings.NewReader(`{"version": 1}`)); err == nil {
+		t.Error("expected an error for asciicast v1")
+	}
+}
+
+func TestPlay(t *testing.T) {
+	recording := &Recording{Events: []Event{
+		{Time: 0.01, Type: EventOutput, Data: "a"},
+		{Time: 0.02, Type: EventResize, Data: "80x24"},
+		{Time: 60, Type: EventOutput, Data: "b"},
+	}}
+
+	var out bytes.Buffer
+	if err := Play(&out, recording, PlayOptions{Speed: 10, MaxIdle: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "ab" {
+		t.Errorf("played %q, want %q", out.String(), "ab")
+	}
+}
@@ -3,17 +3,61 @@ package repo
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/levanduy/ssh_management/internal/backup"
 	"github.com/levanduy/ssh_management/internal/domain"
 	_ "modernc.org/sqlite"
 )
 
 type SQLiteRepo struct {
-	db *sql.DB
+	db     *sql.DB
+	dbPath string
+}
+
+// Columns selected for every host query, in scanHost order. Personal
+// overlays take precedence over the stored username and key.
+const hostColumns = `h.id, h.name, h.hostname, h.ip_address, h.port,
+		   COALESCE(NULLIF(o.username, ''), h.username), COALESCE(NULLIF(o.key_path, ''), h.key_path),
+		   h.description, h.tags, h.group_name, h.proxy_jump, h.origin, h.connector,
+		   h.forward_agent, h.identities_only, h.record, h.address_family, h.source_id, h.source_scope, COALESCE(o.favorite, 0),
+		   h.last_used, h.use_count, h.created_at, h.updated_at`
+
+// Columns selected by DNS cache queries, in scanDNSRecord order
+const dnsColumns = `hostname, addresses, error, resolved_at, expires_at`
+
+// Columns selected by history queries, in scanHistory order
+const historyColumns = `id, host_id, host_name, connected_at, recording_path`
+
+// Tables for host queries; the hosts table is aliased h
+const hostTables = `hosts h LEFT JOIN overlays o ON o.host_name = h.name`
+
+// Columns written by Create, Update and Import, in hostValues order
+var hostWriteColumns = []string{
+	"name", "hostname", "ip_address", "port", "username", "key_path", "description", "tags",
+	"group_name", "proxy_jump", "origin", "connector", "forward_agent", "identities_only", "record",
+	"address_family", "source_id", "source_scope",
+}
+
+// Migrations are applied in order; the schema version is stored in PRAGMA user_version
+var migrations = []func(*sql.Tx) error{
+	migrateCreateHosts,
+	migrateCreateHistory,
+	migrateAddGroup,
+	migrateAddProxyJump,
+	migrateAddShared,
+	migrateAddConnector,
+	migrateAddAgentOptions,
+	migrateAddRecording,
+	migrateAddIgnoreRules,
+	migrateAddAddressFamily,
+	migrateAddDNSCache,
+	migrateAddSourceID,
+	migrateAddSourceScope,
 }
 
 func NewSQLiteRepo(dbPath string) (*SQLiteRepo, error) {
@@ -28,15 +72,101 @@ func NewSQLiteRepo(dbPath string) (*SQLiteRepo, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	repo := &SQLiteRepo{db: db}
-	if err := repo.createTables(); err != nil {
-		return nil, fmt.Errorf("failed to create tables: %w", err)
+	repo := &SQLiteRepo{db: db, dbPath: dbPath}
+	if err := repo.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
 	return repo, nil
 }
 
-func (r *SQLiteRepo) createTables() error {
+// CountHosts returns the number of hosts in a database file without
+// migrating it, for listing databases other than the one in use
+func CountHosts(dbPath string) (int, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM hosts").Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// migrate brings the schema up to date, copying the database file to the
+// backup directory first if an existing database is about to change
+func (r *SQLiteRepo) migrate() error {
+	var version int
+	if err := r.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version >= len(migrations) {
+		return nil
+	}
+
+	var existing int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'hosts'").Scan(&existing); err != nil {
+		return err
+	}
+	if existing > 0 {
+		if err := r.backupDatabaseFile(fmt.Sprintf("pre-migration-v%d", len(migrations))); err != nil {
+			return fmt.Errorf("failed to back up database before migration: %w", err)
+		}
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := r.db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := migrations[i](tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backupDatabaseFile copies the database file into the rotating backup directory
+func (r *SQLiteRepo) backupDatabaseFile(reason string) error {
+	src, err := os.Open(r.dbPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dir := backup.GetBackupDir(filepath.Dir(r.dbPath))
+	if err := ensureDir(dir); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(backup.AutoFileName(reason), ".json") + ".db"
+	dst, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+
+	return backup.Rotate(dir, backup.AutoPrefix, backup.DefaultKeep)
+}
+
+func migrateCreateHosts(tx *sql.Tx) error {
 	// Create the main table
 	query := `
 	CREATE TABLE IF NOT EXISTS hosts (
@@ -55,34 +185,164 @@ func (r *SQLiteRepo) createTables() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 	`
-	_, err := r.db.Exec(query)
+	_, err := tx.Exec(query)
 	if err != nil {
 		return err
 	}
 
-	// Add ip_address column if it doesn't exist (migration)
-	migrationQuery := `
-	ALTER TABLE hosts ADD COLUMN ip_address TEXT DEFAULT '';
-	`
-	// This will fail if column already exists, which is fine
-	r.db.Exec(migrationQuery)
+	// Databases created before ip_address existed still lack the column
+	if !columnExists(tx, "hosts", "ip_address") {
+		if _, err := tx.Exec(`ALTER TABLE hosts ADD COLUMN ip_address TEXT DEFAULT ''`); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+func migrateCreateHistory(tx *sql.Tx) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host_id INTEGER NOT NULL,
+		host_name TEXT NOT NULL,
+		connected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_history_host_id ON history(host_id);
+	`
+	_, err := tx.Exec(query)
+	return err
+}
+
+func migrateAddGroup(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE hosts ADD COLUMN group_name TEXT DEFAULT ''`)
+	return err
+}
+
+func migrateAddProxyJump(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE hosts ADD COLUMN proxy_jump TEXT DEFAULT ''`)
+	return err
+}
+
+func migrateAddShared(tx *sql.Tx) error {
+	query := `
+	ALTER TABLE hosts ADD COLUMN origin TEXT DEFAULT '';
+	CREATE TABLE IF NOT EXISTS overlays (
+		host_name TEXT PRIMARY KEY,
+		username TEXT DEFAULT '',
+		key_path TEXT DEFAULT '',
+		favorite INTEGER DEFAULT 0
+	);
+	`
+	_, err := tx.Exec(query)
+	return err
+}
+
+func migrateAddConnector(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE hosts ADD COLUMN connector TEXT DEFAULT ''`)
+	return err
+}
+
+func migrateAddAgentOptions(tx *sql.Tx) error {
+	query := `
+	ALTER TABLE hosts ADD COLUMN forward_agent INTEGER DEFAULT 0;
+	ALTER TABLE hosts ADD COLUMN identities_only INTEGER DEFAULT 0;
+	`
+	_, err := tx.Exec(query)
+	return err
+}
+
+func migrateAddRecording(tx *sql.Tx) error {
+	query := `
+	ALTER TABLE hosts ADD COLUMN record INTEGER DEFAULT 0;
+	ALTER TABLE history ADD COLUMN recording_path TEXT DEFAULT '';
+	`
+	_, err := tx.Exec(query)
+	return err
+}
+
+func migrateAddIgnoreRules(tx *sql.Tx) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS ignore_rules (
+		pattern TEXT PRIMARY KEY,
+		note TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err := tx.Exec(query)
+	return err
+}
+
+func migrateAddAddressFamily(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE hosts ADD COLUMN address_family TEXT DEFAULT ''`)
+	return err
+}
+
+func migrateAddDNSCache(tx *sql.Tx) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS dns_cache (
+		hostname TEXT PRIMARY KEY,
+		addresses TEXT DEFAULT '',
+		error TEXT DEFAULT '',
+		resolved_at DATETIME,
+		expires_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS address_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		hostname TEXT NOT NULL,
+		addresses TEXT DEFAULT '',
+		previous TEXT DEFAULT '',
+		changed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_address_history_hostname ON address_history(hostname);
+	`
+	_, err := tx.Exec(query)
+	return err
+}
+
+func migrateAddSourceID(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE hosts ADD COLUMN source_id TEXT DEFAULT ''`)
+	return err
+}
+
+func migrateAddSourceScope(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE hosts ADD COLUMN source_scope TEXT DEFAULT ''`)
+	return err
+}
+
+// columnExists reports whether table has the named column
+func columnExists(tx *sql.Tx, table, column string) bool {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid          int
+			name, ctype  string
+			notNull, pk  int
+			defaultValue sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &defaultValue, &pk); err == nil && name == column {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *SQLiteRepo) Create(host *domain.Host) error {
 	now := time.Now()
 	host.CreatedAt = now
 	host.UpdatedAt = now
 
-	query := `
-	INSERT INTO hosts (name, hostname, ip_address, port, username, key_path, description, tags, created_at, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	result, err := r.db.Exec(query,
-		host.Name, host.Hostname, host.IPAddress, host.Port, host.Username,
-		host.KeyPath, host.Description, host.Tags,
-		host.CreatedAt, host.UpdatedAt)
+	columns := append(hostWriteColumns, "created_at", "updated_at")
+	query := fmt.Sprintf(`INSERT INTO hosts (%s) VALUES (%s)`,
+		strings.Join(columns, ", "), placeholders(len(columns)))
+	result, err := r.db.Exec(query, append(hostValues(host), host.CreatedAt, host.UpdatedAt)...)
 
 	if err != nil {
 		return fmt.Errorf("failed to create host: %w", err)
@@ -98,46 +358,19 @@ func (r *SQLiteRepo) Create(host *domain.Host) error {
 }
 
 func (r *SQLiteRepo) GetAll() ([]*domain.Host, error) {
-	query := `
-	SELECT id, name, hostname, ip_address, port, username, key_path, description, tags,
-		   last_used, use_count, created_at, updated_at
-	FROM hosts ORDER BY id ASC
-	`
+	query := `SELECT ` + hostColumns + ` FROM ` + hostTables + ` ORDER BY h.id ASC`
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query hosts: %w", err)
 	}
 	defer rows.Close()
 
-	var hosts []*domain.Host
-	for rows.Next() {
-		host := &domain.Host{}
-		err := rows.Scan(
-			&host.ID, &host.Name, &host.Hostname, &host.IPAddress, &host.Port,
-			&host.Username, &host.KeyPath, &host.Description, &host.Tags,
-			&host.LastUsed, &host.UseCount, &host.CreatedAt, &host.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan host: %w", err)
-		}
-		hosts = append(hosts, host)
-	}
-
-	return hosts, nil
+	return scanHosts(rows)
 }
 
 func (r *SQLiteRepo) GetByID(id int) (*domain.Host, error) {
-	query := `
-	SELECT id, name, hostname, ip_address, port, username, key_path, description, tags,
-		   last_used, use_count, created_at, updated_at
-	FROM hosts WHERE id = ?
-	`
-	host := &domain.Host{}
-	err := r.db.QueryRow(query, id).Scan(
-		&host.ID, &host.Name, &host.Hostname, &host.IPAddress, &host.Port,
-		&host.Username, &host.KeyPath, &host.Description, &host.Tags,
-		&host.LastUsed, &host.UseCount, &host.CreatedAt, &host.UpdatedAt,
-	)
+	query := `SELECT ` + hostColumns + ` FROM ` + hostTables + ` WHERE h.id = ?`
+	host, err := scanHost(r.db.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("host with id %d not found", id)
@@ -150,17 +383,8 @@ func (r *SQLiteRepo) GetByID(id int) (*domain.Host, error) {
 }
 
 func (r *SQLiteRepo) GetByName(name string) (*domain.Host, error) {
-	query := `
-	SELECT id, name, hostname, ip_address, port, username, key_path, description, tags,
-		   last_used, use_count, created_at, updated_at
-	FROM hosts WHERE name = ?
-	`
-	host := &domain.Host{}
-	err := r.db.QueryRow(query, name).Scan(
-		&host.ID, &host.Name, &host.Hostname, &host.IPAddress, &host.Port,
-		&host.Username, &host.KeyPath, &host.Description, &host.Tags,
-		&host.LastUsed, &host.UseCount, &host.CreatedAt, &host.UpdatedAt,
-	)
+	query := `SELECT ` + hostColumns + ` FROM ` + hostTables + ` WHERE h.name = ?`
+	host, err := scanHost(r.db.QueryRow(query, name))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("host with name '%s' not found", name)
@@ -175,16 +399,12 @@ func (r *SQLiteRepo) GetByName(name string) (*domain.Host, error) {
 func (r *SQLiteRepo) Update(host *domain.Host) error {
 	host.UpdatedAt = time.Now()
 
-	query := `
-	UPDATE hosts SET 
-		name = ?, hostname = ?, ip_address = ?, port = ?, username = ?, 
-		key_path = ?, description = ?, tags = ?, updated_at = ?
-	WHERE id = ?
-	`
-	_, err := r.db.Exec(query,
-		host.Name, host.Hostname, host.IPAddress, host.Port, host.Username,
-		host.KeyPath, host.Description, host.Tags, host.UpdatedAt,
-		host.ID)
+	var assignments []string
+	for _, column := range append(hostWriteColumns, "updated_at") {
+		assignments = append(assignments, column+" = ?")
+	}
+	query := fmt.Sprintf(`UPDATE hosts SET %s WHERE id = ?`, strings.Join(assignments, ", "))
+	_, err := r.db.Exec(query, append(hostValues(host), host.UpdatedAt, host.ID)...)
 
 	if err != nil {
 		return fmt.Errorf("failed to update host: %w", err)
@@ -194,8 +414,40 @@ func (r *SQLiteRepo) Update(host *domain.Host) error {
 }
 
 func (r *SQLiteRepo) Delete(id int) error {
-	query := `DELETE FROM hosts WHERE id = ?`
-	result, err := r.db.Exec(query, id)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteHost(tx, id); err != nil {
+		return err
+	}
+
+	// Reorder IDs after deletion to maintain sequential order
+	if err := reorderIDs(tx); err != nil {
+		return fmt.Errorf("failed to reorder IDs after deletion: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// deleteHost removes a host with its overlay and history. Recorded sessions
+// stay in the history so sshm replay can still reach their files. They are
+// detached from the host, whose ID goes to the next host when the IDs are
+// reordered, and keep only its name.
+func deleteHost(tx *sql.Tx, id int) error {
+	if _, err := tx.Exec(`DELETE FROM overlays WHERE host_name = (SELECT name FROM hosts WHERE id = ?)`, id); err != nil {
+		return fmt.Errorf("failed to delete host overlay: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM history WHERE host_id = ? AND recording_path = ''`, id); err != nil {
+		return fmt.Errorf("failed to delete host history: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE history SET host_id = 0 WHERE host_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to detach host recordings: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM hosts WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete host: %w", err)
 	}
@@ -208,42 +460,44 @@ func (r *SQLiteRepo) Delete(id int) error {
 	if affected == 0 {
 		return fmt.Errorf("host with id %d not found", id)
 	}
-
-	// Reorder IDs after deletion to maintain sequential order
-	err = r.reorderIDs()
-	if err != nil {
-		return fmt.Errorf("failed to reorder IDs after deletion: %w", err)
-	}
-
 	return nil
 }
 
 // reorderIDs reassigns IDs to maintain sequential order starting from 1
-func (r *SQLiteRepo) reorderIDs() error {
-	// Get all hosts ordered by current ID
-	hosts, err := r.GetAll()
+func reorderIDs(tx *sql.Tx) error {
+	// Get all host IDs in their current order
+	rows, err := tx.Query("SELECT id FROM hosts ORDER BY id ASC")
 	if err != nil {
 		return err
 	}
-
-	// Begin transaction
-	tx, err := r.db.Begin()
-	if err != nil {
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
 		return err
 	}
-	defer tx.Rollback()
 
 	// Temporarily disable foreign key constraints if any
-	_, err = tx.Exec("PRAGMA foreign_keys = OFF")
-	if err != nil {
+	if _, err := tx.Exec("PRAGMA foreign_keys = OFF"); err != nil {
 		return err
 	}
 
 	// Update IDs to sequential order
-	for i, host := range hosts {
+	for i, id := range ids {
 		newID := i + 1
-		if host.ID != newID {
-			_, err = tx.Exec("UPDATE hosts SET id = ? WHERE id = ?", newID, host.ID)
+		if id != newID {
+			_, err = tx.Exec("UPDATE hosts SET id = ? WHERE id = ?", newID, id)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec("UPDATE history SET host_id = ? WHERE host_id = ?", newID, id)
 			if err != nil {
 				return err
 			}
@@ -251,51 +505,32 @@ func (r *SQLiteRepo) reorderIDs() error {
 	}
 
 	// Re-enable foreign key constraints
-	_, err = tx.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
+	if _, err := tx.Exec("PRAGMA foreign_keys = ON"); err != nil {
 		return err
 	}
 
-	// Reset the auto-increment counter
-	_, err = tx.Exec("UPDATE SQLITE_SEQUENCE SET seq = ? WHERE name = 'hosts'", len(hosts))
-	if err != nil {
-		// This is not critical if the table doesn't use AUTOINCREMENT
-		// Just log and continue
-	}
+	// Reset the auto-increment counter. This is not critical if the table
+	// doesn't use AUTOINCREMENT.
+	_, _ = tx.Exec("UPDATE SQLITE_SEQUENCE SET seq = ? WHERE name = 'hosts'", len(ids))
 
-	return tx.Commit()
+	return nil
 }
 
 func (r *SQLiteRepo) Search(query string) ([]*domain.Host, error) {
 	searchQuery := `
-	SELECT id, name, hostname, ip_address, port, username, key_path, description, tags,
-		   last_used, use_count, created_at, updated_at
-	FROM hosts 
-	WHERE name LIKE ? OR hostname LIKE ? OR ip_address LIKE ? OR description LIKE ? OR tags LIKE ?
-	ORDER BY id ASC
+	SELECT ` + hostColumns + `
+	FROM ` + hostTables + `
+	WHERE h.name LIKE ? OR h.hostname LIKE ? OR h.ip_address LIKE ? OR h.description LIKE ? OR h.tags LIKE ? OR h.group_name LIKE ?
+	ORDER BY h.id ASC
 	`
 	pattern := "%" + strings.ToLower(query) + "%"
-	rows, err := r.db.Query(searchQuery, pattern, pattern, pattern, pattern, pattern)
+	rows, err := r.db.Query(searchQuery, pattern, pattern, pattern, pattern, pattern, pattern)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search hosts: %w", err)
 	}
 	defer rows.Close()
 
-	var hosts []*domain.Host
-	for rows.Next() {
-		host := &domain.Host{}
-		err := rows.Scan(
-			&host.ID, &host.Name, &host.Hostname, &host.IPAddress, &host.Port,
-			&host.Username, &host.KeyPath, &host.Description, &host.Tags,
-			&host.LastUsed, &host.UseCount, &host.CreatedAt, &host.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan host: %w", err)
-		}
-		hosts = append(hosts, host)
-	}
-
-	return hosts, nil
+	return scanHosts(rows)
 }
 
 func (r *SQLiteRepo) IncrementUseCount(id int) error {
@@ -313,11 +548,438 @@ func (r *SQLiteRepo) IncrementUseCount(id int) error {
 	return nil
 }
 
+// Merge stores keep, including its usage statistics, and removes the other
+// hosts after moving their history to it, all in one transaction
+func (r *SQLiteRepo) Merge(keep *domain.Host, removeIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	keep.UpdatedAt = time.Now()
+	columns := append(hostWriteColumns, "last_used", "use_count", "created_at", "updated_at")
+	var assignments []string
+	for _, column := range columns {
+		assignments = append(assignments, column+" = ?")
+	}
+	query := fmt.Sprintf(`UPDATE hosts SET %s WHERE id = ?`, strings.Join(assignments, ", "))
+	values := append(hostValues(keep), keep.LastUsed, keep.UseCount, keep.CreatedAt, keep.UpdatedAt, keep.ID)
+	if _, err := tx.Exec(query, values...); err != nil {
+		return fmt.Errorf("failed to update merged host: %w", err)
+	}
+
+	for _, id := range removeIDs {
+		if _, err := tx.Exec(`UPDATE history SET host_id = ? WHERE host_id = ?`, keep.ID, id); err != nil {
+			return fmt.Errorf("failed to move history: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM overlays WHERE host_name = (SELECT name FROM hosts WHERE id = ?)`, id); err != nil {
+			return fmt.Errorf("failed to delete host overlay: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM hosts WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete merged host: %w", err)
+		}
+	}
+
+	// Keep IDs sequential, as after any deletion
+	if err := reorderIDs(tx); err != nil {
+		return fmt.Errorf("failed to reorder IDs after merge: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Restore applies a backup in one transaction, so a failure leaves the
+// database as it was. History entries of hosts that are not in the database
+// are dropped, except recorded sessions, which are kept detached.
+func (r *SQLiteRepo) Restore(restore *domain.Restore) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, name := range restore.Remove {
+		var id int
+		if err := tx.QueryRow(`SELECT id FROM hosts WHERE name = ?`, name).Scan(&id); err != nil {
+			return fmt.Errorf("failed to find host %s: %w", name, err)
+		}
+		if err := deleteHost(tx, id); err != nil {
+			return err
+		}
+	}
+	if err := reorderIDs(tx); err != nil {
+		return fmt.Errorf("failed to reorder IDs after restore: %w", err)
+	}
+
+	now := time.Now()
+	var assignments []string
+	for _, column := range append(hostWriteColumns, "last_used", "use_count", "updated_at") {
+		assignments = append(assignments, column+" = ?")
+	}
+	update := fmt.Sprintf(`UPDATE hosts SET %s WHERE name = ?`, strings.Join(assignments, ", "))
+	for _, host := range restore.Update {
+		values := append(hostValues(host), host.LastUsed, host.UseCount, now, host.Name)
+		if _, err := tx.Exec(update, values...); err != nil {
+			return fmt.Errorf("failed to restore host %s: %w", host.Name, err)
+		}
+	}
+
+	columns := append(hostWriteColumns, "last_used", "use_count", "created_at", "updated_at")
+	insert := fmt.Sprintf(`INSERT INTO hosts (%s) VALUES (%s)`, strings.Join(columns, ", "), placeholders(len(columns)))
+	for _, host := range restore.Add {
+		values := append(hostValues(host), host.LastUsed, host.UseCount, host.CreatedAt, host.UpdatedAt)
+		if _, err := tx.Exec(insert, values...); err != nil {
+			return fmt.Errorf("failed to restore host %s: %w", host.Name, err)
+		}
+	}
+
+	if restore.ReplaceHistory {
+		if _, err := tx.Exec(`DELETE FROM history`); err != nil {
+			return fmt.Errorf("failed to delete history: %w", err)
+		}
+	}
+	for _, entry := range restore.History {
+		_, err := tx.Exec(`
+		INSERT INTO history (host_id, host_name, connected_at, recording_path)
+		SELECT COALESCE((SELECT id FROM hosts WHERE name = ?), 0), ?, ?, ?
+		WHERE EXISTS (SELECT 1 FROM hosts WHERE name = ?) OR ? != ''
+		`, entry.HostName, entry.HostName, entry.ConnectedAt, entry.RecordingPath, entry.HostName, entry.RecordingPath)
+		if err != nil {
+			return fmt.Errorf("failed to restore history: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteRepo) AddHistory(entry *domain.HistoryEntry) error {
+	if entry.ConnectedAt.IsZero() {
+		entry.ConnectedAt = time.Now()
+	}
+
+	result, err := r.db.Exec(`INSERT INTO history (host_id, host_name, connected_at, recording_path) VALUES (?, ?, ?, ?)`,
+		entry.HostID, entry.HostName, entry.ConnectedAt, entry.RecordingPath)
+	if err != nil {
+		return fmt.Errorf("failed to add history: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	entry.ID = int(id)
+	return nil
+}
+
+// hostHistory matches a host's history entries, including the recordings
+// left detached by a deleted host of the same name
+const hostHistory = `host_id = ? OR (host_id = 0 AND host_name = (SELECT name FROM hosts WHERE id = ?))`
+
+// GetHistory returns the newest history entries first. hostID 0 means all hosts,
+// limit 0 means no limit.
+func (r *SQLiteRepo) GetHistory(hostID int, limit int) ([]*domain.HistoryEntry, error) {
+	query := `SELECT ` + historyColumns + ` FROM history`
+	var args []interface{}
+	if hostID != 0 {
+		query += ` WHERE ` + hostHistory
+		args = append(args, hostID, hostID)
+	}
+	query += ` ORDER BY connected_at DESC, id DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.HistoryEntry
+	for rows.Next() {
+		entry, err := scanHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (r *SQLiteRepo) GetHistoryEntry(id int) (*domain.HistoryEntry, error) {
+	query := `SELECT ` + historyColumns + ` FROM history WHERE id = ?`
+	entry, err := scanHistory(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("history entry %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history entry: %w", err)
+	}
+	return entry, nil
+}
+
+// GetShared returns shared hosts as stored, without personal overlays
+func (r *SQLiteRepo) GetShared() ([]*domain.Host, error) {
+	// Joining on a false condition keeps hostColumns usable while applying no overlay
+	query := `SELECT ` + hostColumns + ` FROM hosts h LEFT JOIN overlays o ON 0 WHERE h.origin = ? ORDER BY h.id ASC`
+	rows, err := r.db.Query(query, domain.OriginShared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shared hosts: %w", err)
+	}
+	defer rows.Close()
+
+	return scanHosts(rows)
+}
+
+// GetOverlay returns the personal overlay for a host name, or an empty one
+func (r *SQLiteRepo) GetOverlay(hostName string) (*domain.Overlay, error) {
+	overlay := &domain.Overlay{HostName: hostName}
+	err := r.db.QueryRow(`SELECT username, key_path, favorite FROM overlays WHERE host_name = ?`, hostName).
+		Scan(&overlay.Username, &overlay.KeyPath, &overlay.Favorite)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get overlay: %w", err)
+	}
+	return overlay, nil
+}
+
+// SetOverlay stores a personal overlay, removing it when it no longer changes anything
+func (r *SQLiteRepo) SetOverlay(overlay *domain.Overlay) error {
+	if overlay.IsEmpty() {
+		return r.DeleteOverlay(overlay.HostName)
+	}
+	_, err := r.db.Exec(`
+	INSERT INTO overlays (host_name, username, key_path, favorite) VALUES (?, ?, ?, ?)
+	ON CONFLICT(host_name) DO UPDATE SET username = excluded.username, key_path = excluded.key_path, favorite = excluded.favorite
+	`, overlay.HostName, overlay.Username, overlay.KeyPath, overlay.Favorite)
+	if err != nil {
+		return fmt.Errorf("failed to save overlay: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepo) DeleteOverlay(hostName string) error {
+	if _, err := r.db.Exec(`DELETE FROM overlays WHERE host_name = ?`, hostName); err != nil {
+		return fmt.Errorf("failed to delete overlay: %w", err)
+	}
+	return nil
+}
+
+// GetIgnoreRules returns the discovery ignore list, oldest first
+func (r *SQLiteRepo) GetIgnoreRules() ([]*domain.IgnoreRule, error) {
+	rows, err := r.db.Query(`SELECT pattern, note, created_at FROM ignore_rules ORDER BY created_at ASC, pattern ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ignore rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*domain.IgnoreRule
+	for rows.Next() {
+		rule := &domain.IgnoreRule{}
+		if err := rows.Scan(&rule.Pattern, &rule.Note, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ignore rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// AddIgnoreRule stores a rule, replacing the note of an existing one
+func (r *SQLiteRepo) AddIgnoreRule(rule *domain.IgnoreRule) error {
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now()
+	}
+	_, err := r.db.Exec(`
+	INSERT INTO ignore_rules (pattern, note, created_at) VALUES (?, ?, ?)
+	ON CONFLICT(pattern) DO UPDATE SET note = excluded.note
+	`, rule.Pattern, rule.Note, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save ignore rule: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepo) DeleteIgnoreRule(pattern string) error {
+	result, err := r.db.Exec(`DELETE FROM ignore_rules WHERE pattern = ?`, pattern)
+	if err != nil {
+		return fmt.Errorf("failed to delete ignore rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%q is not on the ignore list", pattern)
+	}
+	return nil
+}
+
+// GetDNSRecord returns the cached lookup of a hostname, or nil if it was
+// never looked up
+func (r *SQLiteRepo) GetDNSRecord(hostname string) (*domain.DNSRecord, error) {
+	record, err := scanDNSRecord(r.db.QueryRow(`SELECT `+dnsColumns+` FROM dns_cache WHERE hostname = ?`, strings.ToLower(hostname)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DNS record: %w", err)
+	}
+	return record, nil
+}
+
+// GetDNSRecords returns every cached lookup
+func (r *SQLiteRepo) GetDNSRecords() ([]*domain.DNSRecord, error) {
+	rows, err := r.db.Query(`SELECT ` + dnsColumns + ` FROM dns_cache ORDER BY hostname ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query DNS cache: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*domain.DNSRecord
+	for rows.Next() {
+		record, err := scanDNSRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan DNS record: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// SaveDNSRecord stores a lookup, adding an address history entry when the
+// hostname's addresses are not the ones stored before
+func (r *SQLiteRepo) SaveDNSRecord(record *domain.DNSRecord) error {
+	hostname := strings.ToLower(record.Hostname)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	previous, err := scanDNSRecord(tx.QueryRow(`SELECT `+dnsColumns+` FROM dns_cache WHERE hostname = ?`, hostname))
+	if err == sql.ErrNoRows {
+		previous = &domain.DNSRecord{}
+	} else if err != nil {
+		return fmt.Errorf("failed to get DNS record: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	INSERT INTO dns_cache (hostname, addresses, error, resolved_at, expires_at) VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(hostname) DO UPDATE SET addresses = excluded.addresses, error = excluded.error,
+		resolved_at = excluded.resolved_at, expires_at = excluded.expires_at
+	`, hostname, strings.Join(record.Addresses, ","), record.Error, record.ResolvedAt, record.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save DNS record: %w", err)
+	}
+
+	if len(record.Addresses) > 0 && !previous.SameAddresses(record.Addresses) {
+		_, err = tx.Exec(`INSERT INTO address_history (hostname, addresses, previous, changed_at) VALUES (?, ?, ?, ?)`,
+			hostname, strings.Join(record.Addresses, ","), strings.Join(previous.Addresses, ","), record.ResolvedAt)
+		if err != nil {
+			return fmt.Errorf("failed to add address history: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAddressHistory returns the address changes of a hostname, newest first
+func (r *SQLiteRepo) GetAddressHistory(hostname string, limit int) ([]*domain.AddressChange, error) {
+	rows, err := r.db.Query(`
+	SELECT id, hostname, addresses, previous, changed_at FROM address_history
+	WHERE hostname = ? ORDER BY changed_at DESC, id DESC LIMIT ?
+	`, strings.ToLower(hostname), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query address history: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*domain.AddressChange
+	for rows.Next() {
+		change := &domain.AddressChange{}
+		var addresses, previous string
+		if err := rows.Scan(&change.ID, &change.Hostname, &addresses, &previous, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan address change: %w", err)
+		}
+		change.Addresses = splitAddresses(addresses)
+		change.Previous = splitAddresses(previous)
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
 func (r *SQLiteRepo) Close() error {
 	return r.db.Close()
 }
 
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanHost reads one row selected with hostColumns
+func scanHost(row rowScanner) (*domain.Host, error) {
+	host := &domain.Host{}
+	err := row.Scan(
+		&host.ID, &host.Name, &host.Hostname, &host.IPAddress, &host.Port,
+		&host.Username, &host.KeyPath, &host.Description, &host.Tags,
+		&host.Group, &host.ProxyJump, &host.Origin, &host.Connector,
+		&host.ForwardAgent, &host.IdentitiesOnly, &host.Record, &host.AddressFamily, &host.SourceID, &host.SourceScope, &host.Favorite,
+		&host.LastUsed, &host.UseCount, &host.CreatedAt, &host.UpdatedAt,
+	)
+	return host, err
+}
+
+// scanHistory reads one row selected with historyColumns
+func scanHistory(row rowScanner) (*domain.HistoryEntry, error) {
+	entry := &domain.HistoryEntry{}
+	err := row.Scan(&entry.ID, &entry.HostID, &entry.HostName, &entry.ConnectedAt, &entry.RecordingPath)
+	return entry, err
+}
+
+// scanDNSRecord reads one row selected with dnsColumns
+func scanDNSRecord(row rowScanner) (*domain.DNSRecord, error) {
+	record := &domain.DNSRecord{}
+	var addresses string
+	if err := row.Scan(&record.Hostname, &addresses, &record.Error, &record.ResolvedAt, &record.ExpiresAt); err != nil {
+		return nil, err
+	}
+	record.Addresses = splitAddresses(addresses)
+	return record, nil
+}
+
+// splitAddresses parses a comma separated address list
+func splitAddresses(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// hostValues returns the values for hostWriteColumns
+func hostValues(host *domain.Host) []interface{} {
+	return []interface{}{
+		host.Name, host.Hostname, host.IPAddress, host.Port, host.Username,
+		host.KeyPath, host.Description, host.Tags,
+		host.Group, host.ProxyJump, host.Origin, host.Connector,
+		host.ForwardAgent, host.IdentitiesOnly, host.Record, host.AddressFamily, host.SourceID, host.SourceScope,
+	}
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func scanHosts(rows *sql.Rows) ([]*domain.Host, error) {
+	var hosts []*domain.Host
+	for rows.Next() {
+		host, err := scanHost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan host: %w", err)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
 // Helper function to ensure directory exists
 func ensureDir(dirPath string) error {
-	return os.MkdirAll(dirPath, 0755)
+	return os.MkdirAll(dirPath, 0700)
 }
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+func newTestRepo(t *testing.T) *SQLiteRepo {
+	t.Helper()
+	r, err := NewSQLiteRepo(filepath.Join(t.TempDir(), "hosts.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestDeleteDetachesRecordings(t *testing.T) {
+	r := newTestRepo(t)
+	web := &domain.Host{Name: "web", Hostname: "web.example.com", Port: 22}
+	db := &domain.Host{Name: "db", Hostname: "db.example.com", Port: 22}
+	for _, host := range []*domain.Host{web, db} {
+		if err := r.Create(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorded := &domain.HistoryEntry{HostID: web.ID, HostName: "web", RecordingPath: "/tmp/web.cast"}
+	for _, entry := range []*domain.HistoryEntry{
+		recorded,
+		{HostID: web.ID, HostName: "web"},
+		{HostID: db.ID, HostName: "db"},
+	} {
+		if err := r.AddHistory(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Delete(web.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// db is renumbered to the deleted host's ID and keeps only its own session
+	renumbered, err := r.GetByName("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renumbered.ID != web.ID {
+		t.Fatalf("db ID = %d, want it renumbered to %d", renumbered.ID, web.ID)
+	}
+	history, err := r.GetHistory(renumbered.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].HostName != "db" {
+		t.Errorf("db history = %+v, want only its own session", history)
+	}
+
+	// The recording is still listed, detached from any host
+	entry, err := r.GetHistoryEntry(recorded.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.HostID != 0 || entry.HostName != "web" {
+		t.Errorf("recording = %+v, want host ID 0 and name web", entry)
+	}
+	all, err := r.GetHistory(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("got %d history entries, want the recording and db's session", len(all))
+	}
+
+	// A new host with the deleted one's name finds its recordings again
+	again := &domain.Host{Name: "web", Hostname: "web.example.com", Port: 22}
+	if err := r.Create(again); err != nil {
+		t.Fatal(err)
+	}
+	history, err = r.GetHistory(again.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].ID != recorded.ID {
+		t.Errorf("web history = %+v, want the detached recording", history)
+	}
+}
+
+func TestRestoreIsAllOrNothing(t *testing.T) {
+	r := newTestRepo(t)
+	for _, name := range []string{"web", "db"} {
+		if err := r.Create(&domain.Host{Name: name, Hostname: name + ".example.com", Port: 22}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Adding db again violates its unique name after web was removed
+	err := r.Restore(&domain.Restore{
+		Remove:         []string{"web"},
+		Add:            []*domain.Host{{Name: "db", Hostname: "db2.example.com", Port: 22}},
+		ReplaceHistory: true,
+	})
+	if err == nil {
+		t.Fatal("restore with a duplicate name succeeded")
+	}
+	hosts, err := r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 || hosts[0].Name != "web" {
+		t.Fatalf("hosts after failed restore = %+v, want web and db untouched", hosts)
+	}
+
+	err = r.Restore(&domain.Restore{
+		Remove: []string{"web"},
+		Update: []*domain.Host{{Name: "db", Hostname: "db2.example.com", Port: 2222, UseCount: 4}},
+		History: []*domain.HistoryEntry{
+			{HostName: "db"},
+			{HostName: "gone"},
+			{HostName: "gone", RecordingPath: "/tmp/gone.cast"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := r.GetByName("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.ID != 1 || db.Hostname != "db2.example.com" || db.Port != 2222 || db.UseCount != 4 {
+		t.Errorf("db = %+v, want it renumbered and restored", db)
+	}
+	history, err := r.GetHistory(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Errorf("got %d history entries, want db's session and the detached recording", len(history))
+	}
+}
@@ -0,0 +1,109 @@
+// Package resolver looks up the addresses of many hostnames at once, each
+// with a timeout, so that one unreachable DNS server cannot stall sshm.
+package resolver
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds a single lookup
+	DefaultTimeout = 2 * time.Second
+	// DefaultWorkers is how many hostnames are looked up at once
+	DefaultWorkers = 8
+)
+
+// LookupFunc returns every address of a hostname, as net.Resolver.LookupNetIP does
+type LookupFunc func(ctx context.Context, hostname string) ([]netip.Addr, error)
+
+// Resolver looks up hostnames concurrently
+type Resolver struct {
+	Timeout time.Duration
+	Workers int
+	Lookup  LookupFunc
+}
+
+// Result is the outcome of looking up one hostname
+type Result struct {
+	Hostname  string
+	Addresses []string // Every A and AAAA record, in the order the resolver prefers them
+	Err       error
+}
+
+// New returns a resolver using the system resolver with the given timeout
+func New(timeout time.Duration) *Resolver {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Resolver{
+		Timeout: timeout,
+		Workers: DefaultWorkers,
+		Lookup: func(ctx context.Context, hostname string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", hostname)
+		},
+	}
+}
+
+// Resolve looks up one hostname
+func (r *Resolver) Resolve(ctx context.Context, hostname string) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result := Result{Hostname: hostname}
+	addrs, err := r.Lookup(ctx, hostname)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err() // A lookup that ignored the context still timed out
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		address := addr.Unmap().String()
+		if !seen[address] {
+			seen[address] = true
+			result.Addresses = append(result.Addresses, address)
+		}
+	}
+	return result
+}
+
+// ResolveAll looks up hostnames concurrently and returns one result per
+// distinct hostname, compared case-insensitively, in the order given
+func (r *Resolver) ResolveAll(ctx context.Context, hostnames []string) []Result {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, hostname := range hostnames {
+		if key := strings.ToLower(hostname); hostname != "" && !seen[key] {
+			seen[key] = true
+			unique = append(unique, hostname)
+		}
+	}
+
+	workers := r.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	limit := make(chan struct{}, workers)
+	results := make([]Result, len(unique))
+
+	var wg sync.WaitGroup
+	for i, hostname := range unique {
+		wg.Add(1)
+		go func(i int, hostname string) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			results[i] = r.Resolve(ctx, hostname)
+		}(i, hostname)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,55 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResolveAll(t *testing.T) {
+	r := &Resolver{
+		Timeout: 50 * time.Millisecond,
+		Workers: 2,
+		Lookup: func(ctx context.Context, hostname string) ([]netip.Addr, error) {
+			switch hostname {
+			case "web.example.com":
+				return []netip.Addr{
+					netip.MustParseAddr("::ffff:10.0.0.5"),
+					netip.MustParseAddr("2001:db8::5"),
+					netip.MustParseAddr("10.0.0.5"),
+				}, nil
+			case "slow.example.com":
+				<-ctx.Done()
+				return nil, ctx.Err()
+			case "stuck.example.com":
+				time.Sleep(100 * time.Millisecond) // Ignores the context
+				return []netip.Addr{netip.MustParseAddr("10.0.0.9")}, nil
+			}
+			return nil, errors.New("no such host")
+		},
+	}
+
+	start := time.Now()
+	results := r.ResolveAll(context.Background(), []string{"web.example.com", "slow.example.com", "WEB.example.com", "stuck.example.com", "gone.example.com", ""})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ResolveAll took %v", elapsed)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4: %+v", len(results), results)
+	}
+	if want := []string{"10.0.0.5", "2001:db8::5"}; results[0].Hostname != "web.example.com" || !reflect.DeepEqual(results[0].Addresses, want) || results[0].Err != nil {
+		t.Errorf("web = %+v, want addresses %v", results[0], want)
+	}
+	for _, i := range []int{1, 2} {
+		if !errors.Is(results[i].Err, context.DeadlineExceeded) || results[i].Addresses != nil {
+			t.Errorf("%s = %+v, want a timeout", results[i].Hostname, results[i])
+		}
+	}
+	if results[3].Hostname != "gone.example.com" || results[3].Err == nil {
+		t.Errorf("gone = %+v, want an error", results[3])
+	}
+}
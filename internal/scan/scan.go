@@ -0,0 +1,328 @@
+// Package scan probes networks for SSH servers. Every SSH server sends an
+// identification line such as "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13" as
+// soon as a client connects (RFC 4253 section 4.2), so reading it is enough
+// to tell an SSH server and its software apart without logging in.
+package scan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds connecting to a port and reading its banner
+	DefaultTimeout = time.Second
+	// DefaultWorkers is how many probes run at once
+	DefaultWorkers = 64
+	// DefaultRate is how many probes are started per second
+	DefaultRate = 200
+	// MaxTargets is the largest number of addresses one scan may cover
+	MaxTargets = 65536
+)
+
+// Servers may send other lines before the identification line; at most
+// this many are read, each at most 255 bytes as the RFC allows
+const maxBannerLines = 10
+
+// Result is an SSH server that answered a probe
+type Result struct {
+	Address  string
+	Port     int
+	Banner   string // Identification line, e.g. SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13
+	Software string // Software version from the banner, e.g. OpenSSH_9.6p1
+	Name     string // Reverse DNS name, when looked up and found
+}
+
+// Scanner probes addresses for SSH servers
+type Scanner struct {
+	Timeout time.Duration
+	Workers int
+	Rate    int  // Probes started per second; 0 for no limit
+	Resolve bool // Look up reverse DNS names of the servers found
+
+	Dial       func(ctx context.Context, network, address string) (net.Conn, error)
+	LookupAddr func(ctx context.Context, addr string) ([]string, error)
+}
+
+// New returns a scanner with the default limits using the system network
+// and resolver
+func New() *Scanner {
+	dialer := &net.Dialer{}
+	return &Scanner{
+		Timeout:    DefaultTimeout,
+		Workers:    DefaultWorkers,
+		Rate:       DefaultRate,
+		Dial:       dialer.DialContext,
+		LookupAddr: net.DefaultResolver.LookupAddr,
+	}
+}
+
+// Scan probes every port of every address and returns the SSH servers
+// found, ordered by address and port. It stops early when ctx is done.
+func (s *Scanner) Scan(ctx context.Context, addrs []netip.Addr, ports []int) []Result {
+	type probe struct {
+		addr netip.Addr
+		port int
+	}
+	probes := make(chan probe)
+	go func() {
+		defer close(probes)
+		var tick <-chan time.Time
+		if s.Rate > 0 {
+			ticker := time.NewTicker(time.Second / time.Duration(s.Rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for _, addr := range addrs {
+			for _, port := range ports {
+				if tick != nil {
+					select {
+					case <-tick:
+					case <-ctx.Done():
+						return
+					}
+				}
+				select {
+				case probes <- probe{addr, port}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var results []Result
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range probes {
+				result, ok := s.probe(ctx, p.addr, p.port)
+				if !ok {
+					continue
+				}
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := netip.MustParseAddr(results[i].Address), netip.MustParseAddr(results[j].Address)
+		if a != b {
+			return a.Less(b)
+		}
+		return results[i].Port < results[j].Port
+	})
+	return results
+}
+
+// probe connects to one port and reads its identification line
+func (s *Scanner) probe(ctx context.Context, addr netip.Addr, port int) (Result, bool) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	address := netip.AddrPortFrom(addr, uint16(port)).String()
+	conn, err := s.Dial(ctx, "tcp", address)
+	if err != nil {
+		return Result{}, false
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	banner, ok := readBanner(bufio.NewReaderSize(conn, 256))
+	if !ok {
+		return Result{}, false
+	}
+	software, _, _ := ParseBanner(banner)
+	result := Result{Address: addr.String(), Port: port, Banner: banner, Software: software}
+
+	if s.Resolve && s.LookupAddr != nil {
+		if names, err := s.LookupAddr(ctx, addr.String()); err == nil && len(names) > 0 {
+			result.Name = strings.TrimSuffix(names[0], ".")
+		}
+	}
+	return result, true
+}
+
+// readBanner returns the first line starting with SSH-
+func readBanner(r *bufio.Reader) (string, bool) {
+	for i := 0; i < maxBannerLines; i++ {
+		line, err := r.ReadSlice('\n')
+		if err != nil && (err != bufio.ErrBufferFull || len(line) == 0) {
+			return "", false
+		}
+		text := strings.TrimRight(string(line), "\r\n")
+		if strings.HasPrefix(text, "SSH-") {
+			return text, true
+		}
+		if err == bufio.ErrBufferFull {
+			return "", false // Longer than any line an SSH server may send
+		}
+	}
+	return "", false
+}
+
+// ParseBanner splits an identification line, SSH-protoversion-softwareversion
+// followed by optional comments, into the software version and comments
+func ParseBanner(banner string) (software, comments string, ok bool) {
+	rest, ok := strings.CutPrefix(banner, "SSH-")
+	if !ok {
+		return "", "", false
+	}
+	_, rest, ok = strings.Cut(rest, "-")
+	if !ok || rest == "" {
+		return "", "", false
+	}
+	software, comments, _ = strings.Cut(rest, " ")
+	return software, comments, true
+}
+
+// ParseTargets expands addresses, CIDRs such as 10.20.0.0/24 and ranges
+// such as 10.20.0.10-10.20.0.50 or 10.20.0.10-50 into the addresses to
+// probe. The network and broadcast addresses of IPv4 networks are left out.
+func ParseTargets(specs []string) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	seen := make(map[netip.Addr]bool)
+	add := func(addr netip.Addr) error {
+		if seen[addr] {
+			return nil
+		}
+		if len(addrs) >= MaxTargets {
+			return fmt.Errorf("too many addresses to scan (at most %d)", MaxTargets)
+		}
+		seen[addr] = true
+		addrs = append(addrs, addr)
+		return nil
+	}
+
+	for _, spec := range specs {
+		first, last, err := parseTarget(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+		if size := rangeSize(first, last); size.Cmp(big.NewInt(MaxTargets)) > 0 {
+			return nil, fmt.Errorf("%s has %s addresses; scan at most %d at once", spec, size, MaxTargets)
+		}
+		for addr := first; ; addr = addr.Next() {
+			if err := add(addr); err != nil {
+				return nil, err
+			}
+			if addr == last {
+				break
+			}
+		}
+	}
+	return addrs, nil
+}
+
+// parseTarget returns the first and last address of a target
+func parseTarget(spec string) (netip.Addr, netip.Addr, error) {
+	if strings.Contains(spec, "/") {
+		prefix, err := netip.ParsePrefix(spec)
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid CIDR %q", spec)
+		}
+		prefix = prefix.Masked()
+		first, last := prefix.Addr(), lastAddr(prefix)
+		if first.Is4() && prefix.Bits() <= 30 {
+			first, last = first.Next(), last.Prev()
+		}
+		return first, last, nil
+	}
+
+	if from, to, ok := strings.Cut(spec, "-"); ok {
+		first, err := netip.ParseAddr(from)
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid range %q", spec)
+		}
+		last, err := netip.ParseAddr(to)
+		if err != nil && first.Is4() {
+			// 10.0.0.10-50: the end replaces the last octet
+			octet, convErr := strconv.Atoi(to)
+			if convErr != nil || octet < 0 || octet > 255 {
+				return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid range %q", spec)
+			}
+			b := first.As4()
+			b[3] = byte(octet)
+			last, err = netip.AddrFrom4(b), nil
+		}
+		if err != nil || first.BitLen() != last.BitLen() || last.Less(first) {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid range %q", spec)
+		}
+		return first, last, nil
+	}
+
+	addr, err := netip.ParseAddr(spec)
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("%q is not an IP address, CIDR or range", spec)
+	}
+	return addr, addr, nil
+}
+
+// lastAddr returns the highest address of a masked prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// rangeSize returns how many addresses lie from first to last
+func rangeSize(first, last netip.Addr) *big.Int {
+	a := new(big.Int).SetBytes(first.AsSlice())
+	b := new(big.Int).SetBytes(last.AsSlice())
+	return b.Sub(b, a).Add(b, big.NewInt(1))
+}
+
+// ParsePorts parses a port list such as 22,2222 or 2200-2210
+func ParsePorts(spec string) ([]int, error) {
+	var ports []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			to = from
+		}
+		first, err1 := strconv.Atoi(from)
+		last, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || first < 1 || last > 65535 || last < first {
+			return nil, fmt.Errorf("invalid port %q", part)
+		}
+		for port := first; port <= last; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports to scan")
+	}
+	return ports, nil
+}
@@ -0,0 +1,137 @@
+package scan
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// listen serves every connection on 127.0.0.1 with handle and returns the port
+func listen(t *testing.T, handle func(net.Conn)) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestScan(t *testing.T) {
+	sshPort := listen(t, func(conn net.Conn) {
+		conn.Write([]byte("Welcome to the lab\r\nSSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n"))
+		time.Sleep(time.Second)
+	})
+	httpPort := listen(t, func(conn net.Conn) {
+		conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+	})
+	silentPort := listen(t, func(conn net.Conn) {
+		time.Sleep(time.Second)
+	})
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	scanner := New()
+	scanner.Timeout = 200 * time.Millisecond
+	scanner.Rate = 1000
+	scanner.Resolve = true
+	scanner.LookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		return []string{"lab1.example.com."}, nil
+	}
+
+	addrs, err := ParseTargets([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	results := scanner.Scan(context.Background(), addrs, []int{sshPort, httpPort, silentPort, closedPort})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Scan took %v", elapsed)
+	}
+
+	want := []Result{{
+		Address:  "127.0.0.1",
+		Port:     sshPort,
+		Banner:   "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13",
+		Software: "OpenSSH_9.6p1",
+		Name:     "lab1.example.com",
+	}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Scan = %+v, want %+v", results, want)
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	tests := []struct {
+		specs []string
+		want  []string
+	}{
+		{[]string{"10.0.0.0/30"}, []string{"10.0.0.1", "10.0.0.2"}},
+		{[]string{"10.0.0.7/32", "10.0.0.7"}, []string{"10.0.0.7"}},
+		{[]string{"10.0.0.250-10.0.0.252"}, []string{"10.0.0.250", "10.0.0.251", "10.0.0.252"}},
+		{[]string{"127.0.0.1-2"}, []string{"127.0.0.1", "127.0.0.2"}},
+		{[]string{"2001:db8::/127"}, []string{"2001:db8::", "2001:db8::1"}},
+	}
+	for _, tt := range tests {
+		addrs, err := ParseTargets(tt.specs)
+		if err != nil {
+			t.Errorf("ParseTargets(%v): %v", tt.specs, err)
+			continue
+		}
+		var got []string
+		for _, addr := range addrs {
+			got = append(got, addr.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTargets(%v) = %v, want %v", tt.specs, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.0/8", "2001:db8::/64", "10.0.0.5-1", "10.0.0.1-300", "web01", "10.0.0.0/33"} {
+		if _, err := ParseTargets([]string{bad}); err == nil {
+			t.Errorf("ParseTargets(%q) accepted", bad)
+		}
+	}
+}
+
+func TestParsePortsAndBanner(t *testing.T) {
+	ports, err := ParsePorts("22, 2200-2202,22")
+	if err != nil || !reflect.DeepEqual(ports, []int{22, 2200, 2201, 2202}) {
+		t.Errorf("ParsePorts = %v, %v", ports, err)
+	}
+	for _, bad := range []string{"0", "22-10", "ssh", "70000", ""} {
+		if _, err := ParsePorts(bad); err == nil {
+			t.Errorf("ParsePorts(%q) accepted", bad)
+		}
+	}
+
+	if software, comments, ok := ParseBanner("SSH-2.0-dropbear_2022.83"); !ok || software != "dropbear_2022.83" || comments != "" {
+		t.Errorf("ParseBanner = %q, %q, %v", software, comments, ok)
+	}
+	if _, _, ok := ParseBanner("HTTP/1.1 200 OK"); ok {
+		t.Error("ParseBanner accepted an HTTP status line")
+	}
+
+	if addr := lastAddr(netip.MustParsePrefix("10.1.0.0/16")); addr != netip.MustParseAddr("10.1.255.255") {
+		t.Errorf("lastAddr = %v", addr)
+	}
+}
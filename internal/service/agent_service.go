@@ -0,0 +1,77 @@
+package service
+
+import (
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// AgentEntry is an ssh-agent identity with the local key and hosts it belongs to
+type AgentEntry struct {
+	Key   *ssh.AgentKey
+	Local *KeyEntry // nil if the key is not in ~/.ssh
+}
+
+// ListAgentKeys returns the keys loaded in ssh-agent, matched to keys in ~/.ssh
+func (s *HostService) ListAgentKeys() ([]*AgentEntry, error) {
+	agentKeys, err := ssh.AgentKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := s.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+	byFingerprint := make(map[string]*KeyEntry)
+	for _, entry := range local {
+		if entry.Key.Fingerprint != "" {
+			byFingerprint[entry.Key.Fingerprint] = entry
+		}
+	}
+
+	var entries []*AgentEntry
+	for _, key := range agentKeys {
+		entries = append(entries, &AgentEntry{Key: key, Local: byFingerprint[key.Fingerprint]})
+	}
+	return entries, nil
+}
+
+// AgentKeyStatus reports, for each key used by the given hosts, whether it is
+// loaded in ssh-agent. Keys whose fingerprint cannot be read are left out.
+// Returns ssh.ErrNoAgent when no agent is running.
+func (s *HostService) AgentKeyStatus(hosts []*domain.Host) (map[string]bool, error) {
+	loaded, err := ssh.AgentFingerprints()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]bool)
+	for _, host := range hosts {
+		if host.KeyPath == "" {
+			continue
+		}
+		if _, done := status[host.KeyPath]; done {
+			continue
+		}
+		key, err := ssh.InspectKey(host.KeyPath)
+		if err != nil || key.Fingerprint == "" {
+			continue
+		}
+		status[host.KeyPath] = loaded[key.Fingerprint]
+	}
+	return status, nil
+}
+
+// SetAgentOptions changes whether a host uses agent forwarding and offers only
+// its own key. Nil leaves an option unchanged. Like the connector these are
+// local choices, so shared hosts can change them too.
+func (s *HostService) SetAgentOptions(host *domain.Host, forwardAgent, identitiesOnly *bool) error {
+	return s.updateLocalSettings(host, func(h *domain.Host) {
+		if forwardAgent != nil {
+			h.ForwardAgent = *forwardAgent
+		}
+		if identitiesOnly != nil {
+			h.IdentitiesOnly = *identitiesOnly
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/levanduy/ssh_management/internal/backup"
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// RestoreMode selects how a backup is applied to the current database
+type RestoreMode string
+
+const (
+	// RestoreMerge adds missing hosts and updates changed ones, keeping local-only hosts
+	RestoreMerge RestoreMode = "merge"
+	// RestoreReplace makes the database match the backup exactly
+	RestoreReplace RestoreMode = "replace"
+)
+
+// FieldChange is a single changed host field
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// HostChange lists the changed fields of one host
+type HostChange struct {
+	Name    string
+	Changes []FieldChange
+}
+
+// RestoreReport describes what a restore did, or would do in dry-run mode
+type RestoreReport struct {
+	Added          []string
+	Updated        []HostChange
+	Removed        []string
+	Unchanged      int
+	HistoryAdded   int
+	HistoryRemoved int
+	Settings       []string
+	SafetyBackup   string // Automatic backup taken before applying changes
+}
+
+// GetBackupDir returns the directory holding manual and automatic backups
+func (s *HostService) GetBackupDir() string {
+	return backup.GetBackupDir(s.dataDir)
+}
+
+// CreateBackup snapshots hosts, tags, history and settings
+func (s *HostService) CreateBackup() (*backup.Archive, error) {
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.GetHistory(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := backup.NewArchive()
+	archive.Hosts = hosts
+	archive.History = history
+
+	tagSet := make(map[string]bool)
+	for _, host := range hosts {
+		for _, tag := range ParseTags(host.Tags) {
+			tagSet[tag] = true
+		}
+	}
+	for tag := range tagSet {
+		archive.Tags = append(archive.Tags, tag)
+	}
+	sort.Strings(archive.Tags)
+
+	for _, name := range backup.SettingsFiles {
+		data, err := os.ReadFile(filepath.Join(s.dataDir, name))
+		if err == nil {
+			archive.Settings[name] = string(data)
+		}
+	}
+
+	return archive, nil
+}
+
+// SaveBackup writes a backup to path, or to a timestamped file in the backup directory if path is empty
+func (s *HostService) SaveBackup(path string) (string, error) {
+	archive, err := s.CreateBackup()
+	if err != nil {
+		return "", err
+	}
+
+	if path == "" {
+		path = filepath.Join(s.GetBackupDir(), backup.FileName("sshm-backup-"))
+	}
+
+	if err := backup.WriteFile(path, archive); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// AutoBackup writes a rotating backup before a destructive operation
+func (s *HostService) AutoBackup(reason string) (string, error) {
+	archive, err := s.CreateBackup()
+	if err != nil {
+		return "", err
+	}
+	archive.Reason = reason
+
+	dir := s.GetBackupDir()
+	path := filepath.Join(dir, backup.AutoFileName(reason))
+	if err := backup.WriteFile(path, archive); err != nil {
+		return "", err
+	}
+
+	if err := backup.Rotate(dir, backup.AutoPrefix, backup.DefaultKeep); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// RestoreBackup applies an archive to the database. With dryRun set nothing
+// is changed and the report describes what would happen.
+func (s *HostService) RestoreBackup(archive *backup.Archive, mode RestoreMode, dryRun bool) (*RestoreReport, error) {
+	if mode != RestoreMerge && mode != RestoreReplace {
+		return nil, fmt.Errorf("unknown restore mode %q (use merge or replace)", mode)
+	}
+
+	localHosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	localByName := make(map[string]*domain.Host)
+	for _, host := range localHosts {
+		localByName[host.Name] = host
+	}
+
+	archivedNames := make(map[string]bool)
+	report := &RestoreReport{}
+	var toAdd, toUpdate []*domain.Host
+
+	for _, archived := range archive.Hosts {
+		archivedNames[archived.Name] = true
+		local, exists := localByName[archived.Name]
+		if !exists {
+			report.Added = append(report.Added, archived.Name)
+			toAdd = append(toAdd, archived)
+			continue
+		}
+
+		changes := diffHosts(local, archived)
+		if len(changes) == 0 {
+			report.Unchanged++
+			continue
+		}
+		report.Updated = append(report.Updated, HostChange{Name: archived.Name, Changes: changes})
+		toUpdate = append(toUpdate, archived)
+	}
+
+	var toRemove []*domain.Host
+	if mode == RestoreReplace {
+		for _, local := range localHosts {
+			if !archivedNames[local.Name] {
+				report.Removed = append(report.Removed, local.Name)
+				toRemove = append(toRemove, local)
+			}
+		}
+	}
+
+	historyToAdd, historyRemoved, err := s.planHistoryRestore(archive, mode)
+	if err != nil {
+		return nil, err
+	}
+	report.HistoryAdded = len(historyToAdd)
+	report.HistoryRemoved = historyRemoved
+
+	for _, name := range backup.SettingsFiles {
+		content, ok := archive.Settings[name]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(s.dataDir, name)
+		current, err := os.ReadFile(path)
+		if err == nil && (mode == RestoreMerge || string(current) == content) {
+			continue // Merge never overwrites existing settings
+		}
+		report.Settings = append(report.Settings, name)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if report.SafetyBackup, err = s.AutoBackup("pre-restore"); err != nil {
+		return nil, fmt.Errorf("failed to create safety backup: %w", err)
+	}
+
+	restore := &domain.Restore{
+		Update:         toUpdate,
+		Add:            toAdd,
+		ReplaceHistory: mode == RestoreReplace,
+		History:        historyToAdd,
+	}
+	for _, host := range toRemove {
+		restore.Remove = append(restore.Remove, host.Name)
+	}
+	if err := s.repo.Restore(restore); err != nil {
+		return nil, err
+	}
+
+	for _, name := range report.Settings {
+		if err := os.WriteFile(filepath.Join(s.dataDir, name), []byte(archive.Settings[name]), 0600); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+
+	return report, nil
+}
+
+// planHistoryRestore returns the archived entries to insert and, for replace
+// mode, how many local entries will be dropped
+func (s *HostService) planHistoryRestore(archive *backup.Archive, mode RestoreMode) ([]*domain.HistoryEntry, int, error) {
+	local, err := s.repo.GetHistory(0, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if mode == RestoreReplace {
+		return archive.History, len(local), nil
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range local {
+		seen[historyKey(entry)] = true
+	}
+
+	var toAdd []*domain.HistoryEntry
+	for _, entry := range archive.History {
+		if !seen[historyKey(entry)] {
+			toAdd = append(toAdd, entry)
+		}
+	}
+	return toAdd, 0, nil
+}
+
+func historyKey(entry *domain.HistoryEntry) string {
+	return entry.HostName + "@" + strconv.FormatInt(entry.ConnectedAt.Unix(), 10)
+}
+
+// diffHosts lists the user-visible fields that differ between two hosts
+func diffHosts(old, new *domain.Host) []FieldChange {
+	var changes []FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("hostname", old.Hostname, new.Hostname)
+	add("ip_address", old.IPAddress, new.IPAddress)
+	add("port", strconv.Itoa(old.Port), strconv.Itoa(new.Port))
+	add("username", old.Username, new.Username)
+	add("key_path", old.KeyPath, new.KeyPath)
+	add("description", old.Description, new.Description)
+	add("tags", old.Tags, new.Tags)
+	add("group", old.Group, new.Group)
+	add("proxy_jump", old.ProxyJump, new.ProxyJump)
+	add("connector", old.Connector, new.Connector)
+	add("forward_agent", strconv.FormatBool(old.ForwardAgent), strconv.FormatBool(new.ForwardAgent))
+	add("identities_only", strconv.FormatBool(old.IdentitiesOnly), strconv.FormatBool(new.IdentitiesOnly))
+	add("record", strconv.FormatBool(old.Record), strconv.FormatBool(new.Record))
+	add("address_family", old.AddressFamily, new.AddressFamily)
+	add("source_id", old.SourceID, new.SourceID)
+	add("source_scope", old.SourceScope, new.SourceScope)
+	add("use_count", strconv.Itoa(old.UseCount), strconv.Itoa(new.UseCount))
+
+	return changes
+}
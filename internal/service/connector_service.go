@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/recording"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// Connector returns the SSH client backend for a host: its own choice, or
+// the configured default
+func (s *HostService) Connector(host *domain.Host) (ssh.Connector, error) {
+	name := host.Connector
+	if name == "" {
+		name = s.config.Connector
+	}
+	return ssh.NewConnector(name)
+}
+
+// SetConnector sets (or clears, with an empty name) the SSH client backend of
+// a host. It is a local choice, so shared hosts can change it too.
+func (s *HostService) SetConnector(host *domain.Host, name string) error {
+	if name != "" {
+		if _, err := ssh.NewConnector(name); err != nil {
+			return err
+		}
+	}
+
+	return s.updateLocalSettings(host, func(h *domain.Host) {
+		h.Connector = name
+	})
+}
+
+// SetAddressFamily limits a host to IPv4 (inet) or IPv6 (inet6), or lets it
+// use either with an empty family. The cached IP address is looked up again
+// in the new family. Like the connector it is a local choice.
+func (s *HostService) SetAddressFamily(host *domain.Host, family string) error {
+	if err := ssh.CheckAddressFamily(family); err != nil {
+		return err
+	}
+
+	if ssh.IsIPLiteral(host.Hostname) {
+		if _, err := ssh.ResolveAddress(host.Hostname, family); err != nil {
+			return err
+		}
+	}
+
+	address := s.resolveIPAddress(host.Hostname, family)
+	return s.updateLocalSettings(host, func(h *domain.Host) {
+		h.AddressFamily = family
+		h.IPAddress = address
+	})
+}
+
+// updateLocalSettings applies a change to connection settings that are kept
+// per machine and survive shared inventory syncs
+func (s *HostService) updateLocalSettings(host *domain.Host, apply func(*domain.Host)) error {
+	stored := host
+	if host.IsShared() {
+		// Write the shared host's own row, not its overlay values
+		base, err := s.sharedBase(host.Name)
+		if err != nil {
+			return err
+		}
+		stored = base
+	}
+	apply(stored)
+	if err := s.repo.Update(stored); err != nil {
+		return err
+	}
+	if stored != host {
+		apply(host)
+	}
+	return nil
+}
+
+// ConnectionTarget returns a copy of the host ready to hand to a connector,
+// with jump hosts that name other sshm hosts resolved
+func (s *HostService) ConnectionTarget(host *domain.Host) (*domain.Host, error) {
+	target := *host
+	jump, err := s.ResolveProxyJump(host)
+	if err != nil {
+		return nil, err
+	}
+	target.ProxyJump = jump
+	return &target, nil
+}
+
+// Connect opens an interactive session to the host through its connector.
+// Matching hooks run around it, and the session is logged in the history and
+// recorded when enabled. A failing pre-connect hook aborts with a *HookError.
+func (s *HostService) Connect(host *domain.Host) error {
+	// Jump hosts may refer to other sshm hosts by name
+	target, err := s.ConnectionTarget(host)
+	if err != nil {
+		return err
+	}
+	conn, err := s.Connector(host)
+	if err != nil {
+		return err
+	}
+
+	started, err := runPreHooks(s.HooksFor(host), target)
+	if err != nil {
+		if postErr := runPostHooks(started, target, err); postErr != nil {
+			return fmt.Errorf("%w\n%v", err, postErr)
+		}
+		return err
+	}
+
+	sessionErr := s.openSession(host, target, conn)
+	if err := runPostHooks(started, target, sessionErr); err != nil && sessionErr == nil {
+		return err
+	}
+	return sessionErr
+}
+
+// openSession logs and, when enabled, records a session while it runs
+func (s *HostService) openSession(host, target *domain.Host, conn ssh.Connector) error {
+	var writer *recording.Writer
+	var recordingPath string
+	if s.ShouldRecord(host) {
+		recordingPath = s.recordingPath(host, time.Now())
+		width, height := ssh.TerminalSize()
+		var err error
+		writer, err = recording.Create(recordingPath, width, height, host.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.ConnectToHost(host.ID, recordingPath); err != nil {
+		if writer != nil {
+			writer.Close()
+			os.Remove(recordingPath)
+		}
+		return fmt.Errorf("failed to update stats: %w", err)
+	}
+
+	if writer == nil {
+		return conn.Connect(target, nil)
+	}
+
+	connectErr := conn.Connect(target, writer)
+	if err := writer.Close(); err != nil && connectErr == nil {
+		return fmt.Errorf("recording %s is incomplete: %w", recordingPath, err)
+	}
+	return connectErr
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/levanduy/ssh_management/internal/dedupe"
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// FindDuplicates groups local hosts that are likely the same machine. Shared
+// hosts are left out, as they can only be changed through the shared file.
+func (s *HostService) FindDuplicates() ([]*dedupe.Cluster, error) {
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var local []*domain.Host
+	for _, host := range hosts {
+		if !host.IsShared() {
+			local = append(local, host)
+		}
+	}
+
+	addresses := s.resolveAll(local)
+	return dedupe.Find(local, func(host *domain.Host) []string {
+		return addresses[host.ID]
+	}), nil
+}
+
+// resolveAll returns every known address of each host: its stored IP
+// address, its known_hosts address and all cached DNS records. Hostnames
+// never looked up are looked up concurrently first.
+func (s *HostService) resolveAll(hosts []*domain.Host) map[int][]string {
+	var hostnames []string
+	for _, host := range hosts {
+		hostnames = append(hostnames, host.Hostname)
+	}
+	s.prefetchAddresses(hostnames)
+
+	addresses := make(map[int][]string)
+	for _, host := range hosts {
+		candidates := []string{host.IPAddress, s.cachedIPAddress(host.Hostname, host.AddressFamily)}
+		if record, err := s.repo.GetDNSRecord(host.Hostname); err == nil && record != nil {
+			candidates = append(candidates, record.Addresses...)
+		}
+
+		var found []string
+		for _, addr := range candidates {
+			if ip, err := netip.ParseAddr(addr); err == nil && !containsAddress(found, ip.Unmap().String()) {
+				found = append(found, ip.Unmap().String())
+			}
+		}
+		addresses[host.ID] = found
+	}
+	return addresses
+}
+
+// MergeHosts applies a merge planned by dedupe: keep is stored with its
+// chosen values and combined usage, and the other hosts are removed after
+// their history is moved to it. A safety backup is taken first and its path
+// returned.
+func (s *HostService) MergeHosts(keep *domain.Host, remove []*domain.Host) (string, error) {
+	if len(remove) == 0 {
+		return "", nil
+	}
+
+	// IDs are renumbered after every deletion, so look them up again by name
+	ids := make([]int, 0, len(remove))
+	for _, host := range append([]*domain.Host{keep}, remove...) {
+		current, err := s.repo.GetByName(host.Name)
+		if err != nil {
+			return "", err
+		}
+		if current.IsShared() {
+			return "", fmt.Errorf("%s is managed by the shared inventory and cannot be merged", host.Name)
+		}
+		if host == keep {
+			keep.ID = current.ID
+		} else {
+			ids = append(ids, current.ID)
+		}
+	}
+	if keep.Port <= 0 || keep.Port > 65535 {
+		return "", fmt.Errorf("port must be between 1 and 65535")
+	}
+
+	safetyBackup, err := s.AutoBackup("merge-" + keep.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create safety backup: %w", err)
+	}
+	if err := s.repo.Merge(keep, ids); err != nil {
+		return safetyBackup, err
+	}
+
+	// The favorite flag lives in the overlay, which follows the kept name
+	overlay, err := s.repo.GetOverlay(keep.Name)
+	if err != nil {
+		return safetyBackup, err
+	}
+	if keep.Favorite && !overlay.Favorite {
+		overlay.Favorite = true
+		return safetyBackup, s.repo.SetOverlay(overlay)
+	}
+	return safetyBackup, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// HostDetail is everything known about a host, as shown in the TUI detail pane
+type HostDetail struct {
+	Host      *domain.Host
+	Connector string       // Backend used to connect, the host's own or the default
+	Key       *ssh.KeyInfo // nil without a key or when it cannot be read
+	KeyError  string
+	Jumps     []string // Resolved jump hops in the order they are connected through
+	JumpError string
+	Command   string // ssh command line for the host, with jump hosts resolved
+	Sessions  []*domain.HistoryEntry
+}
+
+// HostDetail gathers a host's key, jump chain, ssh command and its most
+// recent sessions. Problems with the key or jump hosts are reported in the
+// detail rather than failing it.
+func (s *HostService) HostDetail(host *domain.Host, sessions int) (*HostDetail, error) {
+	detail := &HostDetail{Host: host, Connector: host.Connector}
+	if detail.Connector == "" {
+		detail.Connector = s.config.Connector
+	}
+
+	if host.KeyPath != "" {
+		key, err := ssh.InspectKey(host.KeyPath)
+		if err != nil {
+			detail.KeyError = err.Error()
+		} else {
+			detail.Key = key
+		}
+	}
+
+	target, err := s.ConnectionTarget(host)
+	if err != nil {
+		detail.JumpError = err.Error()
+		target = host
+	}
+	if target.ProxyJump != "" {
+		detail.Jumps = strings.Split(target.ProxyJump, ",")
+	}
+	detail.Command = ssh.BuildSSHCommand(target)
+
+	detail.Sessions, err = s.repo.GetHistory(host.ID, sessions)
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
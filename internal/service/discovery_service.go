@@ -0,0 +1,287 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/discovery"
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// DiscoveredHost is a change proposed by discovery: a host to add, or an
+// existing host with the values discovery found for it
+type DiscoveredHost struct {
+	Host    *domain.Host
+	Sources []string      // Sources that reported the host, best first
+	Changes []FieldChange // For updates, what would change
+	Wanted  string        // For additions, the preferred name when it clashed with another host
+	Skip    bool          // Rejected by the user; left out when applying
+}
+
+// DiscoveryPlan is what discovery would change, computed without writing
+type DiscoveryPlan struct {
+	Sources []string // Sources that were run
+	Add     []*DiscoveredHost
+	Update  []*DiscoveredHost
+	Errors  []error // Sources that failed; the others still ran
+}
+
+// IsEmpty reports whether discovery found nothing to change
+func (p *DiscoveryPlan) IsEmpty() bool {
+	return len(p.Add) == 0 && len(p.Update) == 0
+}
+
+// DiscoveryReport describes what applying a discovery plan did
+type DiscoveryReport struct {
+	Added    []string
+	Updated  []string
+	Renamed  []string       // Added hosts whose preferred name clashed, as "wanted → name"
+	BySource map[string]int // Hosts added, by the source that found them first
+	Failed   []error        // Hosts that could not be stored
+}
+
+// HasChanges reports whether any host was added or updated
+func (r *DiscoveryReport) HasChanges() bool {
+	return len(r.Added) > 0 || len(r.Updated) > 0
+}
+
+// Summary describes the report in one line, e.g.
+// "2 new host(s) (ssh_config 1, known_hosts 1), 1 updated"
+func (r *DiscoveryReport) Summary() string {
+	var sources []string
+	for _, source := range append(discovery.Names(), ScanSource) {
+		if n := r.BySource[source]; n > 0 {
+			sources = append(sources, fmt.Sprintf("%s %d", source, n))
+		}
+	}
+
+	summary := fmt.Sprintf("%d new host(s)", len(r.Added))
+	if len(sources) > 0 {
+		summary += " (" + strings.Join(sources, ", ") + ")"
+	}
+	summary += fmt.Sprintf(", %d updated", len(r.Updated))
+	if len(r.Renamed) > 0 {
+		summary += fmt.Sprintf(", %d renamed (%s)", len(r.Renamed), strings.Join(r.Renamed, ", "))
+	}
+	if len(r.Failed) > 0 {
+		summary += fmt.Sprintf(", %d failed", len(r.Failed))
+	}
+	return summary
+}
+
+// DiscoverCandidates runs discovery sources and merges their results. Nil
+// sources means the ones enabled in the config. Hosts on the ignore list are
+// left out. Sources that fail are reported without stopping the others.
+func (s *HostService) DiscoverCandidates(sources []string) ([]discovery.Candidate, []error) {
+	if sources == nil {
+		sources = s.config.Discovery.Sources
+	}
+	ignore, err := s.ignoreList()
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to load ignore list: %w", err)}
+	}
+	return discovery.Run(discovery.DefaultEnv(), sources, ignore)
+}
+
+// PlanDiscovery works out which hosts discovery would add and which existing
+// hosts it would update, without changing anything. Nil sources means the
+// ones enabled in the config.
+func (s *HostService) PlanDiscovery(sources []string) (*DiscoveryPlan, error) {
+	for _, name := range sources {
+		if _, ok := discovery.Lookup(name); !ok {
+			return nil, fmt.Errorf("unknown discovery source %q (available: %s)", name, strings.Join(discovery.Names(), ", "))
+		}
+	}
+	if sources == nil {
+		sources = s.config.Discovery.Sources
+	}
+
+	candidates, errs := s.DiscoverCandidates(sources)
+	plan := &DiscoveryPlan{Sources: sources, Errors: errs}
+	if err := s.planCandidates(plan, candidates); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planCandidates adds to plan the candidates that are new hosts, with unique
+// names, and the updates of existing hosts that candidates match
+func (s *HostService) planCandidates(plan *DiscoveryPlan, candidates []discovery.Candidate) error {
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	// Existing hosts are matched by machine, never by name: two hosts may
+	// share a first label without being the same machine
+	byMachine := make(map[string]*domain.Host)
+	taken := make(map[string]string)
+	for _, host := range hosts {
+		taken[strings.ToLower(host.Name)] = host.Hostname
+		for _, address := range []string{host.Hostname, host.IPAddress} {
+			key := machineKey(address, host.Port)
+			if _, ok := byMachine[key]; address != "" && !ok {
+				byMachine[key] = host
+			}
+		}
+	}
+
+	var added []discovery.Candidate
+	for _, candidate := range candidates {
+		existing := findMachine(byMachine, candidate)
+		if existing == nil {
+			added = append(added, candidate)
+			continue
+		}
+		if existing.IsShared() {
+			continue // Discovery never changes shared hosts
+		}
+		if update := s.discoveredUpdate(existing, s.hostFromCandidate(candidate, existing.Name), candidate); update != nil {
+			plan.Update = append(plan.Update, update)
+		}
+	}
+
+	naming := discovery.Naming{Strategy: s.config.Discovery.Naming, Template: s.config.Discovery.NameTemplate}
+	names, collisions := naming.AssignNames(added, taken)
+	wanted := make(map[string]string)
+	for _, collision := range collisions {
+		wanted[collision.Name] = collision.Wanted
+	}
+	for i, candidate := range added {
+		plan.Add = append(plan.Add, &DiscoveredHost{
+			Host:    s.hostFromCandidate(candidate, names[i]),
+			Sources: candidate.Sources,
+			Wanted:  wanted[names[i]],
+		})
+	}
+	return nil
+}
+
+// machineKey identifies a host by address and port
+func machineKey(address string, port int) string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(address), port)
+}
+
+// findMachine returns the existing host a candidate refers to, by its
+// hostname, its address or its ssh_config alias
+func findMachine(byMachine map[string]*domain.Host, candidate discovery.Candidate) *domain.Host {
+	for _, address := range []string{candidate.Hostname, candidate.Address, candidate.Name} {
+		if address == "" {
+			continue
+		}
+		if host, ok := byMachine[machineKey(address, candidate.Port)]; ok {
+			return host
+		}
+	}
+	return nil
+}
+
+// discoveredUpdate returns what discovery would fill in on an existing host:
+// a user found by discovery in place of the default one, and a missing
+// IP address
+func (s *HostService) discoveredUpdate(existing, found *domain.Host, candidate discovery.Candidate) *DiscoveredHost {
+	updated := *existing
+	if existing.Username == s.getCurrentUsername() && found.Username != s.getCurrentUsername() {
+		updated.Username = found.Username
+	}
+	if existing.IPAddress == "" {
+		updated.IPAddress = candidate.Address
+		if updated.IPAddress == "" {
+			updated.IPAddress = s.cachedIPAddress(existing.Hostname, existing.AddressFamily)
+		}
+	}
+
+	changes := diffHosts(existing, &updated)
+	if len(changes) == 0 {
+		return nil
+	}
+	return &DiscoveredHost{Host: &updated, Sources: candidate.Sources, Changes: changes}
+}
+
+// ApplyDiscovery stores the plan's hosts that were not skipped
+func (s *HostService) ApplyDiscovery(plan *DiscoveryPlan) *DiscoveryReport {
+	report := &DiscoveryReport{BySource: make(map[string]int)}
+
+	// Look up new hostnames together instead of one host at a time
+	var hostnames []string
+	for _, add := range plan.Add {
+		if !add.Skip && add.Host.IPAddress == "" {
+			hostnames = append(hostnames, add.Host.Hostname)
+		}
+	}
+	s.prefetchAddresses(hostnames)
+
+	for _, add := range plan.Add {
+		if add.Skip {
+			continue
+		}
+		if err := s.AddHost(add.Host); err != nil {
+			report.Failed = append(report.Failed, fmt.Errorf("%s: %w", add.Host.Name, err))
+			continue
+		}
+		report.Added = append(report.Added, add.Host.Name)
+		if add.Wanted != "" {
+			report.Renamed = append(report.Renamed, add.Wanted+" → "+add.Host.Name)
+		}
+		if len(add.Sources) > 0 {
+			report.BySource[add.Sources[0]]++
+		}
+	}
+	for _, update := range plan.Update {
+		if update.Skip {
+			continue
+		}
+		if err := s.UpdateHost(update.Host); err != nil {
+			report.Failed = append(report.Failed, fmt.Errorf("%s: %w", update.Host.Name, err))
+			continue
+		}
+		report.Updated = append(report.Updated, update.Host.Name)
+	}
+	return report
+}
+
+// AutoDiscover applies everything the enabled discovery sources find. It is
+// shared by the CLI and the TUI refresh; use PlanDiscovery to review first.
+func (s *HostService) AutoDiscover() (*DiscoveryReport, error) {
+	if !s.config.Discovery.Enabled {
+		return &DiscoveryReport{}, nil
+	}
+	plan, err := s.PlanDiscovery(nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.ApplyDiscovery(plan), nil
+}
+
+// hostFromCandidate builds the host a discovery candidate would be stored as
+func (s *HostService) hostFromCandidate(candidate discovery.Candidate, name string) *domain.Host {
+	host := &domain.Host{
+		Name:          name,
+		Hostname:      candidate.Hostname,
+		IPAddress:     candidate.Address,
+		Port:          candidate.Port,
+		Username:      candidate.Username,
+		KeyPath:       candidate.KeyPath,
+		ProxyJump:     candidate.ProxyJump,
+		AddressFamily: candidate.Family,
+		Tags:          "ssh-detected",
+	}
+	if len(candidate.Tags) > 0 {
+		host.Tags = JoinTags(candidate.Tags)
+	}
+	if host.Username == "" {
+		host.Username = s.getCurrentUsername()
+	}
+	if host.KeyPath != "" {
+		if _, err := os.Stat(host.KeyPath); err != nil {
+			host.KeyPath = "" // Keys named in ssh_config may not exist here
+		}
+	}
+
+	host.Description = "Auto-detected from " + strings.Join(candidate.Sources, ", ")
+	if candidate.Detail != "" {
+		host.Description += fmt.Sprintf(" (%s)", candidate.Detail)
+	}
+	return host
+}
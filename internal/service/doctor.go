@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// Severity ranks doctor findings
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Finding is a single problem reported by the doctor
+type Finding struct {
+	Severity   Severity
+	Check      string // keys, permissions, duplicates, dns, known_hosts
+	Subject    string // File, host or key the finding is about
+	Message    string
+	Suggestion string
+	fix        func() error // Set only for fixes that are safe to apply automatically
+}
+
+// Fixable reports whether the finding can be fixed with --fix
+func (f *Finding) Fixable() bool {
+	return f.fix != nil
+}
+
+// Fix applies the automatic fix for the finding
+func (f *Finding) Fix() error {
+	if f.fix == nil {
+		return fmt.Errorf("no automatic fix available")
+	}
+	return f.fix()
+}
+
+// DoctorOptions controls which checks the doctor runs
+type DoctorOptions struct {
+	SkipDNS    bool
+	DNSTimeout time.Duration
+}
+
+// RunDoctor audits keys, directories, hosts and known_hosts and returns
+// findings sorted by severity (most severe first)
+func (s *HostService) RunDoctor(opts DoctorOptions) ([]*Finding, error) {
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []*Finding
+	findings = append(findings, checkDirectoryPermissions()...)
+	findings = append(findings, checkHostKeys(hosts)...)
+	findings = append(findings, checkDuplicateHosts(hosts)...)
+	findings = append(findings, checkKnownHosts(hosts)...)
+	if !opts.SkipDNS {
+		timeout := opts.DNSTimeout
+		if timeout <= 0 {
+			timeout = 3 * time.Second
+		}
+		findings = append(findings, checkHostResolution(hosts, timeout)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+	return findings, nil
+}
+
+func checkDirectoryPermissions() []*Finding {
+	var findings []*Finding
+	dirs := []string{ssh.GetDefaultKeyDir(), GetDefaultConfigPath()}
+
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		mode := info.Mode().Perm()
+		if mode&0077 == 0 {
+			continue
+		}
+
+		path := dir
+		findings = append(findings, &Finding{
+			Severity:   SeverityWarning,
+			Check:      "permissions",
+			Subject:    path,
+			Message:    fmt.Sprintf("directory is accessible by other users (%o)", mode),
+			Suggestion: fmt.Sprintf("chmod 700 %s", path),
+			fix:        func() error { return os.Chmod(path, 0700) },
+		})
+	}
+
+	return findings
+}
+
+func checkHostKeys(hosts []*domain.Host) []*Finding {
+	var findings []*Finding
+
+	// Check each referenced key once, listing every host that uses it
+	keyHosts := make(map[string][]string)
+	var keyPaths []string
+	for _, host := range hosts {
+		if host.KeyPath == "" {
+			continue
+		}
+		path := ssh.ExpandPath(host.KeyPath)
+		if _, seen := keyHosts[path]; !seen {
+			keyPaths = append(keyPaths, path)
+		}
+		keyHosts[path] = append(keyHosts[path], host.Name)
+	}
+
+	for _, path := range keyPaths {
+		usedBy := "used by " + strings.Join(keyHosts[path], ", ")
+
+		if _, err := os.Stat(path); err != nil {
+			findings = append(findings, &Finding{
+				Severity:   SeverityError,
+				Check:      "keys",
+				Subject:    path,
+				Message:    "key file does not exist (" + usedBy + ")",
+				Suggestion: "pick another key with 'K' in the TUI or generate one with 'sshm keys generate'",
+			})
+			continue
+		}
+
+		keyPath := path
+		var permErr *ssh.KeyPermissionError
+		if err := ssh.CheckKeyPermissions(path); errors.As(err, &permErr) {
+			findings = append(findings, &Finding{
+				Severity:   SeverityError,
+				Check:      "keys",
+				Subject:    path,
+				Message:    fmt.Sprintf("private key is readable by other users (%o); ssh will refuse it", permErr.Mode),
+				Suggestion: fmt.Sprintf("chmod 600 %s", path),
+				fix:        func() error { return os.Chmod(keyPath, 0600) },
+			})
+		}
+
+		key, err := ssh.InspectKey(path)
+		if err != nil {
+			findings = append(findings, &Finding{
+				Severity:   SeverityWarning,
+				Check:      "keys",
+				Subject:    path,
+				Message:    fmt.Sprintf("cannot parse key: %v", err),
+				Suggestion: "check that the file is an SSH private key",
+			})
+			continue
+		}
+
+		switch {
+		case key.Type == "dsa":
+			findings = append(findings, &Finding{
+				Severity:   SeverityError,
+				Check:      "keys",
+				Subject:    path,
+				Message:    "DSA keys are insecure and disabled by modern OpenSSH (" + usedBy + ")",
+				Suggestion: "generate an ed25519 key with 'sshm keys generate' and deploy it with 'sshm keys deploy'",
+			})
+		case key.Type == "rsa" && key.Bits < 2048:
+			findings = append(findings, &Finding{
+				Severity:   SeverityError,
+				Check:      "keys",
+				Subject:    path,
+				Message:    fmt.Sprintf("RSA key is only %d bits (%s)", key.Bits, usedBy),
+				Suggestion: "generate an ed25519 key with 'sshm keys generate' and deploy it with 'sshm keys deploy'",
+			})
+		}
+
+		if key.HasPrivate() && !key.Encrypted {
+			findings = append(findings, &Finding{
+				Severity:   SeverityWarning,
+				Check:      "keys",
+				Subject:    path,
+				Message:    "private key is not protected by a passphrase",
+				Suggestion: fmt.Sprintf("ssh-keygen -p -f %s", path),
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkDuplicateHosts(hosts []*domain.Host) []*Finding {
+	var findings []*Finding
+
+	byTarget := make(map[string][]string)
+	var targets []string
+	for _, host := range hosts {
+		target := fmt.Sprintf("%s@%s:%d", host.Username, strings.ToLower(host.Hostname), host.Port)
+		if _, seen := byTarget[target]; !seen {
+			targets = append(targets, target)
+		}
+		byTarget[target] = append(byTarget[target], host.Name)
+	}
+
+	for _, target := range targets {
+		names := byTarget[target]
+		if len(names) < 2 {
+			continue
+		}
+		findings = append(findings, &Finding{
+			Severity:   SeverityWarning,
+			Check:      "duplicates",
+			Subject:    target,
+			Message:    fmt.Sprintf("%d hosts point to the same target: %s", len(names), strings.Join(names, ", ")),
+			Suggestion: "delete the extra entries in the TUI",
+		})
+	}
+
+	return findings
+}
+
+func checkKnownHosts(hosts []*domain.Host) []*Finding {
+	path, err := ssh.GetKnownHostsPath()
+	if err != nil {
+		return nil
+	}
+
+	entries, malformed, err := ssh.ReadKnownHosts(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []*Finding{{
+			Severity: SeverityWarning,
+			Check:    "known_hosts",
+			Subject:  path,
+			Message:  fmt.Sprintf("cannot read known_hosts: %v", err),
+		}}
+	}
+
+	var findings []*Finding
+	for _, line := range malformed {
+		findings = append(findings, &Finding{
+			Severity:   SeverityWarning,
+			Check:      "known_hosts",
+			Subject:    fmt.Sprintf("%s:%d", path, line),
+			Message:    "malformed line",
+			Suggestion: fmt.Sprintf("remove or fix line %d of %s", line, path),
+		})
+	}
+
+	// Conflicting keys: the same host pattern with different keys of one type
+	type patternKey struct{ pattern, keyType string }
+	keys := make(map[patternKey]map[string]bool)
+	var order []patternKey
+	hasHashed := false
+	present := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Hashed {
+			hasHashed = true
+			continue
+		}
+		if entry.Marker != "" {
+			continue
+		}
+		for _, pattern := range entry.Patterns {
+			pattern = strings.ToLower(pattern)
+			present[pattern] = true
+			pk := patternKey{pattern, entry.KeyType}
+			if keys[pk] == nil {
+				keys[pk] = make(map[string]bool)
+				order = append(order, pk)
+			}
+			keys[pk][entry.Key] = true
+		}
+	}
+
+	for _, pk := range order {
+		if len(keys[pk]) < 2 {
+			continue
+		}
+		findings = append(findings, &Finding{
+			Severity:   SeverityError,
+			Check:      "known_hosts",
+			Subject:    pk.pattern,
+			Message:    fmt.Sprintf("%d different %s keys recorded; ssh will report a host key mismatch", len(keys[pk]), pk.keyType),
+			Suggestion: fmt.Sprintf("verify the server key, then run: ssh-keygen -R '%s'", pk.pattern),
+		})
+	}
+
+	// Hosts that ssh has never verified. Hashed entries cannot be matched, so skip this check.
+	if !hasHashed {
+		for _, host := range hosts {
+			pattern := strings.ToLower(ssh.KnownHostsPattern(host.Hostname, host.Port))
+			if present[pattern] {
+				continue
+			}
+			if host.IPAddress != "" && present[strings.ToLower(ssh.KnownHostsPattern(host.IPAddress, host.Port))] {
+				continue
+			}
+			findings = append(findings, &Finding{
+				Severity:   SeverityInfo,
+				Check:      "known_hosts",
+				Subject:    host.Name,
+				Message:    fmt.Sprintf("no known_hosts entry for %s", pattern),
+				Suggestion: "connect once and verify the host key fingerprint",
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkHostResolution(hosts []*domain.Host, timeout time.Duration) []*Finding {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		findings []*Finding
+	)
+	sem := make(chan struct{}, 8)
+
+	for _, host := range hosts {
+		if ssh.IsIPLiteral(host.Hostname) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(host *domain.Host) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			// A host limited to one family needs an address in it
+			network, family := "ip", ""
+			switch host.AddressFamily {
+			case domain.FamilyInet:
+				network, family = "ip4", " to an IPv4 address"
+			case domain.FamilyInet6:
+				network, family = "ip6", " to an IPv6 address"
+			}
+			if _, err := net.DefaultResolver.LookupNetIP(ctx, network, host.Hostname); err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			findings = append(findings, &Finding{
+				Severity:   SeverityWarning,
+				Check:      "dns",
+				Subject:    host.Name,
+				Message:    fmt.Sprintf("hostname %s does not resolve%s", host.Hostname, family),
+				Suggestion: "check the hostname or your VPN/DNS settings",
+			})
+		}(host)
+	}
+
+	wg.Wait()
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Subject < findings[j].Subject })
+	return findings
+}
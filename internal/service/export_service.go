@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/exporter"
+)
+
+// ExportHosts returns copies of all hosts (or those with tag) with jump hosts
+// resolved, ready to be written for another tool
+func (s *HostService) ExportHosts(tag string) ([]*domain.Host, error) {
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*domain.Host)
+	for _, host := range hosts {
+		byName[host.Name] = host
+	}
+
+	var exported []*domain.Host
+	for _, host := range hosts {
+		if tag != "" && !hasTag(host, tag) {
+			continue
+		}
+		copied := *host
+		if host.ProxyJump != "" {
+			hops, err := resolveJumpHops(host.ProxyJump, byName, 0)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", host.Name, err)
+			}
+			copied.ProxyJump = strings.Join(hops, ",")
+		}
+		exported = append(exported, &copied)
+	}
+	return exported, nil
+}
+
+// AnsibleInventory builds an Ansible inventory grouped by tag and group
+func (s *HostService) AnsibleInventory(tag string) (*exporter.AnsibleInventory, error) {
+	hosts, err := s.ExportHosts(tag)
+	if err != nil {
+		return nil, err
+	}
+	return exporter.NewAnsibleInventory(hosts, ParseTags), nil
+}
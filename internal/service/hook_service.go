@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// Hook stages
+const (
+	HookPre  = "pre"
+	HookPost = "post"
+)
+
+// Lines of hook output kept in a HookError
+const hookOutputLines = 10
+
+// HookError is returned when a pre- or post-connect hook fails
+type HookError struct {
+	Stage  string // HookPre or HookPost
+	Hook   string // The hook's name, or its command if unnamed
+	Output string // Combined stdout and stderr
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	msg := fmt.Sprintf("%s-connect hook %q failed: %v", e.Stage, e.Hook, e.Err)
+	if e.Output != "" {
+		msg += "\n" + e.Output
+	}
+	return msg
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// HooksFor returns the configured hooks that apply to a host, in config order
+func (s *HostService) HooksFor(host *domain.Host) []domain.HookConfig {
+	tags := ParseTags(host.Tags)
+
+	var hooks []domain.HookConfig
+	for _, hook := range s.config.Hooks {
+		if hookMatches(hook, host.Name, tags) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+func hookMatches(hook domain.HookConfig, name string, tags []string) bool {
+	if len(hook.Hosts) == 0 && len(hook.Tags) == 0 {
+		return true
+	}
+	for _, hostName := range hook.Hosts {
+		if strings.EqualFold(hostName, name) {
+			return true
+		}
+	}
+	for _, want := range hook.Tags {
+		for _, tag := range tags {
+			if strings.EqualFold(want, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runPreHooks runs the pre-connect hooks of a host and stops at the first
+// failure. It returns the hooks that were started, whose post commands must run.
+func runPreHooks(hooks []domain.HookConfig, host *domain.Host) ([]domain.HookConfig, error) {
+	env := hookEnv(host, HookPre, nil)
+	for i, hook := range hooks {
+		if hook.Pre == "" {
+			continue
+		}
+		if err := runHook(hook, HookPre, hook.Pre, env); err != nil {
+			return hooks[:i], err
+		}
+	}
+	return hooks, nil
+}
+
+// runPostHooks runs post-connect hooks in reverse order, so that they undo
+// what pre-hooks set up, and returns the first failure
+func runPostHooks(hooks []domain.HookConfig, host *domain.Host, sessionErr error) error {
+	env := hookEnv(host, HookPost, sessionErr)
+
+	var first error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if hooks[i].Post == "" {
+			continue
+		}
+		if err := runHook(hooks[i], HookPost, hooks[i].Post, env); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// runHook runs one hook command through the shell
+func runHook(hook domain.HookConfig, stage, command string, env []string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		name := hook.Name
+		if name == "" {
+			name = command
+		}
+		return &HookError{Stage: stage, Hook: name, Output: lastLines(output.String(), hookOutputLines), Err: err}
+	}
+	return nil
+}
+
+// hookEnv describes the host to hooks. Post hooks also learn how the session ended.
+func hookEnv(host *domain.Host, stage string, sessionErr error) []string {
+	env := []string{
+		"SSHM_HOST_NAME=" + host.Name,
+		"SSHM_HOSTNAME=" + host.Hostname,
+		"SSHM_PORT=" + strconv.Itoa(host.Port),
+		"SSHM_USER=" + host.Username,
+		"SSHM_KEY_PATH=" + host.KeyPath,
+		"SSHM_GROUP=" + host.Group,
+		"SSHM_TAGS=" + JoinTags(ParseTags(host.Tags)),
+		"SSHM_PROXY_JUMP=" + host.ProxyJump,
+	}
+	if stage != HookPost {
+		return env
+	}
+	if sessionErr != nil {
+		return append(env, "SSHM_SESSION_STATUS=failed", "SSHM_SESSION_ERROR="+sessionErr.Error())
+	}
+	return append(env, "SSHM_SESSION_STATUS=ok")
+}
+
+// lastLines trims text to its last n lines
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/levanduy/ssh_management/internal/discovery"
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// IgnoreRules returns the patterns discovery skips
+func (s *HostService) IgnoreRules() ([]*domain.IgnoreRule, error) {
+	return s.repo.GetIgnoreRules()
+}
+
+// AddIgnoreRule keeps hosts matching pattern out of discovery
+func (s *HostService) AddIgnoreRule(pattern, note string) error {
+	if err := discovery.CheckIgnorePattern(pattern); err != nil {
+		return err
+	}
+	return s.repo.AddIgnoreRule(&domain.IgnoreRule{Pattern: pattern, Note: note})
+}
+
+// RemoveIgnoreRule lets discovery find hosts matching pattern again
+func (s *HostService) RemoveIgnoreRule(pattern string) error {
+	return s.repo.DeleteIgnoreRule(pattern)
+}
+
+// DeleteAndIgnore deletes a host and adds its hostname to the ignore list,
+// so discovery does not add it back from a leftover known_hosts line. Its
+// cached IP address is ignored too, for sources that only know the address.
+func (s *HostService) DeleteAndIgnore(id int) error {
+	host, err := s.repo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get host: %w", err)
+	}
+	if host.IsShared() {
+		return fmt.Errorf("%s is managed by the shared inventory; remove it there and run sshm sync", host.Name)
+	}
+
+	// Ignore first: if removing the known_hosts line fails, the host must
+	// still stay deleted
+	if err := s.AddIgnoreRule(host.Hostname, "deleted "+host.Name); err != nil {
+		return err
+	}
+	if host.IPAddress != "" && host.IPAddress != host.Hostname {
+		if err := s.AddIgnoreRule(host.IPAddress, "deleted "+host.Name); err != nil {
+			return err
+		}
+	}
+	return s.DeleteHostFromBoth(id)
+}
+
+// ignoreList loads the ignore rules for discovery
+func (s *HostService) ignoreList() (*discovery.IgnoreList, error) {
+	rules, err := s.repo.GetIgnoreRules()
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([]string, len(rules))
+	for i, rule := range rules {
+		patterns[i] = rule.Pattern
+	}
+	return discovery.NewIgnoreList(patterns)
+}
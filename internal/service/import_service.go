@@ -0,0 +1,312 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/importer"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// ImportConflict decides what happens when an imported name already exists
+type ImportConflict string
+
+const (
+	ImportSkip   ImportConflict = "skip"   // Keep the existing host
+	ImportRename ImportConflict = "rename" // Import under a new, unique name
+	ImportUpdate ImportConflict = "update" // Overwrite the existing host's connection details
+)
+
+// ImportAction is what an import will do with one entry
+type ImportAction string
+
+const (
+	ImportActionAdd    ImportAction = "add"
+	ImportActionRename ImportAction = "rename"
+	ImportActionUpdate ImportAction = "update"
+	ImportActionSkip   ImportAction = "skip"
+)
+
+// ImportItem is one planned import
+type ImportItem struct {
+	Host     *domain.Host // Host as it will be stored
+	Existing *domain.Host // Host with the same name, if any
+	Action   ImportAction
+	Note     string
+}
+
+// ImportPlan is a previewable set of imports from one source
+type ImportPlan struct {
+	Source string
+	Items  []*ImportItem
+}
+
+// Count returns how many items have the given action
+func (p *ImportPlan) Count(action ImportAction) int {
+	n := 0
+	for _, item := range p.Items {
+		if item.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// SourceTag returns the tag applied to hosts imported from source
+func SourceTag(source string) string {
+	return source + "-imported"
+}
+
+// PlanImport turns parsed entries into a plan without changing the database.
+// For formats describing recreated machines, such as Terraform state, a host
+// imported from the same machine before is updated whatever the conflict
+// mode, even if it was renamed since. When several hosts were imported with
+// the same SourceID, the one with the entry's SourceScope is updated.
+func (s *HostService) PlanImport(entries []*importer.Entry, source string, conflict ImportConflict) (*ImportPlan, error) {
+	switch conflict {
+	case ImportSkip, ImportRename, ImportUpdate:
+	default:
+		return nil, fmt.Errorf("unknown conflict mode %q (use skip, rename or update)", conflict)
+	}
+
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	existingByName := make(map[string]*domain.Host)
+	existingBySource := make(map[string][]*domain.Host)
+	for _, host := range hosts {
+		existingByName[host.Name] = host
+		if host.SourceID != "" && !host.IsShared() {
+			existingBySource[host.SourceID] = append(existingBySource[host.SourceID], host)
+		}
+	}
+
+	plan := &ImportPlan{Source: source}
+	refresh := importer.Refreshes(source)
+	taken := make(map[string]bool) // Names claimed by earlier items in this plan
+	previousImport := previousImports(entries, source, existingBySource)
+
+	for _, entry := range entries {
+		host, note := s.hostFromEntry(entry, source)
+		item := &ImportItem{Host: host, Action: ImportActionAdd, Note: note}
+
+		existing, exists := existingByName[host.Name]
+		previous := previousImport[entry]
+		switch {
+		case refresh && previous != nil:
+			item.Existing = previous
+			host.Name = previous.Name
+			// Keep what the user set where the file has nothing to say
+			if entry.Username == "" {
+				host.Username = previous.Username
+			}
+			if entry.Port == 0 {
+				host.Port = previous.Port
+			}
+			if sameTarget(previous, host) && (host.KeyPath == "" || host.KeyPath == previous.KeyPath) {
+				item.Action = ImportActionSkip
+				item.Note = "already imported"
+			} else {
+				item.Action = ImportActionUpdate
+				item.Note = "recreated, was " + previous.Endpoint()
+			}
+
+		case taken[host.Name]:
+			if conflict == ImportRename {
+				host.Name = uniqueName(host.Name, existingByName, taken)
+				item.Action = ImportActionRename
+			} else {
+				item.Action = ImportActionSkip
+				item.Note = "duplicate name in the imported files"
+			}
+
+		case exists && existing.IsShared() && conflict != ImportRename:
+			item.Existing = existing
+			item.Action = ImportActionSkip
+			item.Note = "managed by the shared inventory"
+
+		case exists && sameTarget(existing, host) && conflict != ImportUpdate:
+			item.Existing = existing
+			item.Action = ImportActionSkip
+			item.Note = "already imported"
+
+		case exists:
+			item.Existing = existing
+			switch conflict {
+			case ImportRename:
+				host.Name = uniqueName(host.Name, existingByName, taken)
+				item.Action = ImportActionRename
+			case ImportUpdate:
+				item.Action = ImportActionUpdate
+			default:
+				item.Action = ImportActionSkip
+				item.Note = fmt.Sprintf("name already used by %s@%s", existing.Username, existing.Hostname)
+			}
+		}
+
+		taken[host.Name] = true
+		plan.Items = append(plan.Items, item)
+	}
+
+	return plan, nil
+}
+
+// ApplyImport stores a plan. Individual failures are collected and do not stop the import.
+func (s *HostService) ApplyImport(plan *ImportPlan) (added, updated int, errs []error) {
+	for _, item := range plan.Items {
+		switch item.Action {
+		case ImportActionAdd, ImportActionRename:
+			if err := s.AddHost(item.Host); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", item.Host.Name, err))
+				continue
+			}
+			added++
+
+		case ImportActionUpdate:
+			host := item.Existing
+			if !strings.EqualFold(host.Hostname, item.Host.Hostname) {
+				host.IPAddress = s.resolveIPAddress(item.Host.Hostname, host.AddressFamily)
+			}
+			host.Hostname = item.Host.Hostname
+			host.Port = item.Host.Port
+			host.Username = item.Host.Username
+			if item.Host.KeyPath != "" {
+				host.KeyPath = item.Host.KeyPath
+			}
+			if item.Host.Group != "" {
+				host.Group = item.Host.Group
+			}
+			if item.Host.ProxyJump != "" {
+				host.ProxyJump = item.Host.ProxyJump
+			}
+			if item.Host.IdentitiesOnly {
+				host.IdentitiesOnly = true
+			}
+			if item.Host.SourceID != "" {
+				host.SourceID, host.SourceScope = item.Host.SourceID, item.Host.SourceScope
+			}
+			if host.Description == "" {
+				host.Description = item.Host.Description
+			}
+			host.Tags = JoinTags(uniqueStrings(append(ParseTags(host.Tags), ParseTags(item.Host.Tags)...)))
+			if err := s.repo.Update(host); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", host.Name, err))
+				continue
+			}
+			updated++
+		}
+	}
+	return added, updated, errs
+}
+
+func (s *HostService) hostFromEntry(entry *importer.Entry, source string) (*domain.Host, string) {
+	var note string
+
+	host := &domain.Host{
+		Name:        entry.Name,
+		Hostname:    entry.Hostname,
+		Port:        entry.Port,
+		Username:    entry.Username,
+		Description: entry.Description,
+		Group:       entry.Group,
+		ProxyJump:   entry.ProxyJump,
+		SourceID:    sourceID(source, entry.SourceID),
+		SourceScope: entry.SourceScope,
+		Tags:        JoinTags(uniqueStrings(append(append([]string{}, entry.Tags...), SourceTag(source)))),
+	}
+	if host.Name == "" {
+		host.Name = entry.Hostname
+	}
+	if host.Port <= 0 || host.Port > 65535 {
+		host.Port = s.config.DefaultPort
+	}
+	if host.Username == "" {
+		host.Username = s.getCurrentUsername()
+	}
+	if host.Description == "" {
+		host.Description = fmt.Sprintf("Imported from %s", source)
+	}
+
+	if entry.KeyPath != "" {
+		keyPath := ssh.ExpandPath(entry.KeyPath)
+		if _, err := os.Stat(keyPath); err == nil {
+			host.KeyPath = keyPath
+		} else {
+			note = fmt.Sprintf("key %s not found, skipped", entry.KeyPath)
+		}
+	}
+	host.IdentitiesOnly = entry.IdentitiesOnly && host.KeyPath != ""
+
+	return host, note
+}
+
+// sameTarget reports whether two hosts connect to the same place
+func sameTarget(a, b *domain.Host) bool {
+	return strings.EqualFold(a.Hostname, b.Hostname) && a.Port == b.Port && a.Username == b.Username
+}
+
+// sourceID namespaces an entry's machine identity by its import format
+func sourceID(source, id string) string {
+	if id == "" {
+		return ""
+	}
+	return source + ":" + id
+}
+
+// previousImports pairs entries with the hosts they were imported as before,
+// among the hosts with their SourceID. A host in the entry's scope is taken
+// first; otherwise the only host left with the SourceID is.
+func previousImports(entries []*importer.Entry, source string, bySource map[string][]*domain.Host) map[*importer.Entry]*domain.Host {
+	previous := make(map[*importer.Entry]*domain.Host)
+	matched := make(map[*domain.Host]bool)
+	for _, entry := range entries {
+		for _, host := range bySource[sourceID(source, entry.SourceID)] {
+			if !matched[host] && host.SourceScope == entry.SourceScope {
+				previous[entry] = host
+				matched[host] = true
+				break
+			}
+		}
+	}
+	for _, entry := range entries {
+		if previous[entry] != nil {
+			continue
+		}
+		var candidates []*domain.Host
+		for _, host := range bySource[sourceID(source, entry.SourceID)] {
+			if !matched[host] {
+				candidates = append(candidates, host)
+			}
+		}
+		if len(candidates) == 1 {
+			previous[entry] = candidates[0]
+			matched[candidates[0]] = true
+		}
+	}
+	return previous
+}
+
+// uniqueName appends -2, -3, ... until the name is unused
+func uniqueName(name string, existing map[string]*domain.Host, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if _, exists := existing[candidate]; !exists && !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// maxJumpDepth stops runaway resolution of jump hosts that refer to each other
+const maxJumpDepth = 8
+
+// SetProxyJump sets (or clears, with an empty spec) the jump host chain of a host.
+// The spec uses ssh -J syntax; each hop may also be the name of another sshm host.
+func (s *HostService) SetProxyJump(host *domain.Host, spec string) error {
+	if host.IsShared() {
+		return fmt.Errorf("%s is managed by the shared inventory; change its jump host there", host.Name)
+	}
+
+	spec = strings.Join(strings.Fields(strings.ReplaceAll(spec, ",", " ")), ",")
+	for _, hop := range strings.Split(spec, ",") {
+		if hop == host.Name {
+			return fmt.Errorf("%s cannot be its own jump host", host.Name)
+		}
+	}
+
+	host.ProxyJump = spec
+	if _, err := s.ResolveProxyJump(host); err != nil {
+		return err
+	}
+	return s.repo.Update(host)
+}
+
+// ResolveProxyJump returns the host's jump chain in ssh -J syntax, replacing
+// sshm host names with user@hostname:port and expanding their own jump hosts
+func (s *HostService) ResolveProxyJump(host *domain.Host) (string, error) {
+	if host.ProxyJump == "" {
+		return "", nil
+	}
+
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return "", err
+	}
+	byName := make(map[string]*domain.Host)
+	for _, h := range hosts {
+		byName[h.Name] = h
+	}
+	byName[host.Name] = host // May have unsaved changes
+
+	hops, err := resolveJumpHops(host.ProxyJump, byName, 0)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", host.Name, err)
+	}
+	return strings.Join(hops, ","), nil
+}
+
+func resolveJumpHops(spec string, byName map[string]*domain.Host, depth int) ([]string, error) {
+	if depth >= maxJumpDepth {
+		return nil, fmt.Errorf("jump host chain is too deep or loops")
+	}
+
+	var hops []string
+	for _, hop := range strings.Split(spec, ",") {
+		hop = strings.TrimSpace(hop)
+		if hop == "" {
+			continue
+		}
+		jump, ok := byName[hop]
+		if !ok {
+			hops = append(hops, hop) // Raw ssh destination or ~/.ssh/config alias
+			continue
+		}
+		if jump.ProxyJump != "" {
+			inner, err := resolveJumpHops(jump.ProxyJump, byName, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			hops = append(hops, inner...)
+		}
+		hops = append(hops, jumpTarget(jump))
+	}
+	return hops, nil
+}
+
+// jumpTarget formats a host as a ssh -J hop
+func jumpTarget(host *domain.Host) string {
+	hostname := host.Hostname
+	if strings.Contains(hostname, ":") {
+		hostname = "[" + hostname + "]"
+	}
+	target := host.Username + "@" + hostname
+	if host.Port != 0 && host.Port != 22 {
+		target += ":" + strconv.Itoa(host.Port)
+	}
+	return target
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// KeyEntry pairs a key from the inventory with the hosts that reference it
+type KeyEntry struct {
+	Key   *ssh.KeyInfo
+	Hosts []*domain.Host
+}
+
+// ListKeys scans ~/.ssh and returns every key with the hosts using it
+func (s *HostService) ListKeys() ([]*KeyEntry, error) {
+	keys, err := ssh.ScanKeys(ssh.GetDefaultKeyDir())
+	if err != nil {
+		return nil, err
+	}
+
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*KeyEntry
+	for _, key := range keys {
+		entries = append(entries, &KeyEntry{Key: key, Hosts: hostsUsingKey(hosts, key)})
+	}
+
+	return entries, nil
+}
+
+// FindKey resolves a key by file name (e.g. id_ed25519) or path
+func (s *HostService) FindKey(nameOrPath string) (*KeyEntry, error) {
+	path := nameOrPath
+	if !filepath.IsAbs(path) && filepath.Base(path) == path {
+		candidate := filepath.Join(ssh.GetDefaultKeyDir(), path)
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+		}
+	}
+
+	key, err := ssh.InspectKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("key %s not found: %w", nameOrPath, err)
+	}
+
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyEntry{Key: key, Hosts: hostsUsingKey(hosts, key)}, nil
+}
+
+// GenerateKey creates a new ed25519 key in ~/.ssh
+func (s *HostService) GenerateKey(name, comment, passphrase string) (*ssh.KeyInfo, error) {
+	if name == "" {
+		return nil, fmt.Errorf("key name is required")
+	}
+
+	path := name
+	if filepath.Base(name) == name {
+		path = filepath.Join(ssh.GetDefaultKeyDir(), name)
+	}
+
+	return ssh.GenerateEd25519Key(path, comment, passphrase)
+}
+
+// SetHostKey assigns a key to a host, clearing it when key is nil
+func (s *HostService) SetHostKey(host *domain.Host, key *ssh.KeyInfo) error {
+	if key == nil {
+		host.KeyPath = ""
+	} else {
+		host.KeyPath = key.IdentityPath()
+	}
+	return s.UpdateHost(host)
+}
+
+func hostsUsingKey(hosts []*domain.Host, key *ssh.KeyInfo) []*domain.Host {
+	var result []*domain.Host
+	for _, host := range hosts {
+		if host.KeyPath == "" {
+			continue
+		}
+		hostKey := ssh.ExpandPath(host.KeyPath)
+		if hostKey == key.Path || hostKey == key.PublicKeyPath {
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+// DeployKey authorizes a key on the host, verifies key-based login and then
+// makes it the host's key. Returns true if the key was newly added.
+func (s *HostService) DeployKey(host *domain.Host, key *ssh.KeyInfo) (bool, error) {
+	if !key.HasPrivate() {
+		return false, fmt.Errorf("private key for %s not found, cannot verify login", key.Name)
+	}
+
+	publicKey, err := key.ReadPublicKey()
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := s.Connector(host)
+	if err != nil {
+		return false, err
+	}
+	target, err := s.ConnectionTarget(host)
+	if err != nil {
+		return false, err
+	}
+
+	added, err := ssh.DeployPublicKey(conn, target, publicKey)
+	if err != nil {
+		return false, err
+	}
+
+	if err := conn.TestKey(target, key.Path); err != nil {
+		return added, err
+	}
+
+	if err := s.SetHostKey(host, key); err != nil {
+		return added, fmt.Errorf("key deployed but failed to update host: %w", err)
+	}
+
+	return added, nil
+}
+
+// GetHostsByTag returns hosts carrying the given tag (case-insensitive)
+func (s *HostService) GetHostsByTag(tag string) ([]*domain.Host, error) {
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*domain.Host
+	for _, host := range hosts {
+		if hasTag(host, tag) {
+			result = append(result, host)
+		}
+	}
+	return result, nil
+}
+
+// hasTag reports whether a host has the tag, ignoring case
+func hasTag(host *domain.Host, tag string) bool {
+	for _, hostTag := range ParseTags(host.Tags) {
+		if strings.EqualFold(hostTag, tag) {
+			return true
+		}
+	}
+	return false
+}
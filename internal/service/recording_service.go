@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/recording"
+)
+
+// Characters not allowed in recording file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// RecordingsDir returns the directory session recordings are written to
+func (s *HostService) RecordingsDir() string {
+	return filepath.Join(s.dataDir, "recordings")
+}
+
+// ShouldRecord reports whether sessions to the host are recorded, either
+// because the host asks for it or because recording is on globally
+func (s *HostService) ShouldRecord(host *domain.Host) bool {
+	return host.Record || s.config.Recording.Enabled
+}
+
+// SetRecord turns session recording on or off for a host. It is a local
+// choice, so shared hosts can change it too.
+func (s *HostService) SetRecord(host *domain.Host, record bool) error {
+	return s.updateLocalSettings(host, func(h *domain.Host) {
+		h.Record = record
+	})
+}
+
+// recordingPath returns a new file name for a session started at the given time
+func (s *HostService) recordingPath(host *domain.Host, started time.Time) string {
+	name := fmt.Sprintf("%s-%s%s", started.Format("20060102-150405"),
+		unsafeFileChars.ReplaceAllString(host.Name, "_"), recording.Extension)
+	return filepath.Join(s.RecordingsDir(), name)
+}
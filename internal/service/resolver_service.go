@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/resolver"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// retryFailedAfter is how soon a hostname whose lookup failed is looked up
+// again, if that is sooner than the cache TTL
+const retryFailedAfter = 5 * time.Minute
+
+// AddressReport summarises a refresh of the address cache
+type AddressReport struct {
+	Resolved int      // Hostnames looked up successfully
+	Failed   []string // Hostnames that could not be looked up
+	Filled   []string // Hosts that had no IP address before
+	Moved    []string // Hosts whose IP address changed, as "name: old → new"
+}
+
+// HasChanges reports whether any host's IP address was set or changed
+func (r *AddressReport) HasChanges() bool {
+	return len(r.Filled) > 0 || len(r.Moved) > 0
+}
+
+// Summary describes the refresh in one line
+func (r *AddressReport) Summary() string {
+	summary := fmt.Sprintf("%d hostname(s) looked up", r.Resolved)
+	if len(r.Failed) > 0 {
+		summary += fmt.Sprintf(", %d failed", len(r.Failed))
+	}
+	if len(r.Filled) > 0 {
+		summary += fmt.Sprintf(", %d address(es) found", len(r.Filled))
+	}
+	if len(r.Moved) > 0 {
+		summary += fmt.Sprintf(", %d moved (%s)", len(r.Moved), strings.Join(r.Moved, ", "))
+	}
+	return summary
+}
+
+// RefreshAddresses looks up the hostnames whose cached addresses expired,
+// or every hostname with force, and updates the IP address of hosts that
+// moved. Lookups run concurrently, each bounded by resolver.timeout.
+func (s *HostService) RefreshAddresses(force bool) (*AddressReport, error) {
+	hosts, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	stale := make(map[string]bool)
+	var hostnames []string
+	for _, host := range hosts {
+		key := strings.ToLower(host.Hostname)
+		if ssh.IsIPLiteral(host.Hostname) || stale[key] {
+			continue
+		}
+		if !force {
+			record, err := s.repo.GetDNSRecord(host.Hostname)
+			if err != nil {
+				return nil, err
+			}
+			if record != nil && !record.Expired(time.Now()) {
+				continue
+			}
+		}
+		stale[key] = true
+		hostnames = append(hostnames, host.Hostname)
+	}
+
+	report := &AddressReport{}
+	records := s.lookupAddresses(hostnames)
+	for _, hostname := range hostnames {
+		if record := records[strings.ToLower(hostname)]; record.Error != "" {
+			report.Failed = append(report.Failed, hostname)
+		} else {
+			report.Resolved++
+		}
+	}
+
+	for _, host := range hosts {
+		record, ok := records[strings.ToLower(host.Hostname)]
+		if !ok || containsAddress(record.Addresses, host.IPAddress) {
+			continue
+		}
+		address := ssh.PickAddress(record.Addresses, host.AddressFamily)
+		if address == "" {
+			continue
+		}
+
+		previous := host.IPAddress
+		if err := s.updateLocalSettings(host, func(h *domain.Host) { h.IPAddress = address }); err != nil {
+			return report, fmt.Errorf("%s: %w", host.Name, err)
+		}
+		if previous == "" {
+			report.Filled = append(report.Filled, host.Name)
+		} else {
+			report.Moved = append(report.Moved, fmt.Sprintf("%s: %s → %s", host.Name, previous, address))
+		}
+	}
+	return report, nil
+}
+
+// DNSRecord returns the cached lookup of a host's hostname, or nil if it
+// was never looked up
+func (s *HostService) DNSRecord(host *domain.Host) (*domain.DNSRecord, error) {
+	return s.repo.GetDNSRecord(host.Hostname)
+}
+
+// AddressHistory returns the recorded address changes of a host's
+// hostname, newest first
+func (s *HostService) AddressHistory(host *domain.Host, limit int) ([]*domain.AddressChange, error) {
+	return s.repo.GetAddressHistory(host.Hostname, limit)
+}
+
+// lookupAddresses looks up hostnames concurrently and caches the results,
+// keyed by lowercase hostname. A failed lookup keeps the addresses found
+// before it.
+func (s *HostService) lookupAddresses(hostnames []string) map[string]*domain.DNSRecord {
+	ttl := s.config.Resolver.TTLDuration()
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	retry := ttl
+	if retry > retryFailedAfter {
+		retry = retryFailedAfter
+	}
+
+	records := make(map[string]*domain.DNSRecord)
+	r := resolver.New(s.config.Resolver.TimeoutDuration())
+	for _, result := range r.ResolveAll(context.Background(), hostnames) {
+		now := time.Now()
+		record := &domain.DNSRecord{
+			Hostname:   strings.ToLower(result.Hostname),
+			Addresses:  result.Addresses,
+			ResolvedAt: now,
+			ExpiresAt:  now.Add(ttl),
+		}
+		if result.Err != nil {
+			record.Error = lookupError(result.Err)
+			record.ExpiresAt = now.Add(retry)
+			if previous, err := s.repo.GetDNSRecord(result.Hostname); err == nil && previous != nil {
+				record.Addresses = previous.Addresses
+			}
+		}
+		// A cache that cannot be written only means looking up again next time
+		_ = s.repo.SaveDNSRecord(record)
+		records[record.Hostname] = record
+	}
+	return records
+}
+
+// cachedIPAddress returns an address of hostname in the given family from
+// known_hosts or the address cache, without looking it up
+func (s *HostService) cachedIPAddress(hostname, family string) string {
+	if ssh.IsIPLiteral(hostname) {
+		return hostname
+	}
+	if ip := s.getIPFromKnownHosts(hostname, family); ip != "" {
+		return ip
+	}
+	if record, err := s.repo.GetDNSRecord(hostname); err == nil && record != nil {
+		return ssh.PickAddress(record.Addresses, family)
+	}
+	return ""
+}
+
+// prefetchAddresses looks up, all at once, the hostnames that were never
+// looked up, so later resolveIPAddress calls are answered from the cache
+func (s *HostService) prefetchAddresses(hostnames []string) {
+	var missing []string
+	for _, hostname := range hostnames {
+		if ssh.IsIPLiteral(hostname) {
+			continue
+		}
+		if record, err := s.repo.GetDNSRecord(hostname); err == nil && record == nil {
+			missing = append(missing, hostname)
+		}
+	}
+	s.lookupAddresses(missing)
+}
+
+// lookupError describes why a lookup failed
+func lookupError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "lookup timed out"
+	}
+	return err.Error()
+}
+
+// containsAddress reports whether address is one of addresses
+func containsAddress(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
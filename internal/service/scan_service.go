@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/levanduy/ssh_management/internal/discovery"
+	"github.com/levanduy/ssh_management/internal/scan"
+)
+
+// ScanSource names network scans in discovery plans and host descriptions
+const ScanSource = "scan"
+
+// PlanScan works out which SSH servers found by a network scan would be
+// added as hosts, and which existing hosts they would update, like
+// PlanDiscovery. New hosts are tagged ssh-scanned and with the server's
+// software version. Hosts on the ignore list are left out.
+func (s *HostService) PlanScan(results []scan.Result) (*DiscoveryPlan, error) {
+	ignore, err := s.ignoreList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore list: %w", err)
+	}
+
+	var candidates []discovery.Candidate
+	for _, result := range results {
+		candidate := discovery.Candidate{
+			Hostname: result.Address,
+			Address:  result.Address,
+			Port:     result.Port,
+			Detail:   result.Banner,
+			Sources:  []string{ScanSource},
+			Tags:     []string{"ssh-scanned"},
+		}
+		if result.Name != "" {
+			candidate.Hostname = result.Name
+		}
+		if result.Software != "" {
+			candidate.Tags = append(candidate.Tags, result.Software)
+		}
+		if ignore.Match(candidate) != "" {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	plan := &DiscoveryPlan{Sources: []string{ScanSource}}
+	if err := s.planCandidates(plan, candidates); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
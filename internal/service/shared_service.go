@@ -0,0 +1,254 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/shared"
+	"github.com/levanduy/ssh_management/pkg/ssh"
+)
+
+// SyncReport describes what a shared inventory sync did, or would do in dry-run mode
+type SyncReport struct {
+	Source       string
+	Pulled       string // Output of git pull, if the file is in a git checkout
+	Added        []string
+	Updated      []HostChange
+	Removed      []string
+	Adopted      []string // Local hosts taken over by a shared entry with the same name
+	Unchanged    int
+	SafetyBackup string
+}
+
+// HasChanges reports whether the sync changes the database
+func (r *SyncReport) HasChanges() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed)+len(r.Adopted) > 0
+}
+
+// SyncShared makes the shared hosts in the database match the shared
+// inventory file. Personal overlays are kept; local hosts whose name appears
+// in the file are adopted, keeping a differing user or key as an overlay.
+// A dry run neither pulls nor changes anything.
+func (s *HostService) SyncShared(dryRun bool) (*SyncReport, error) {
+	path := s.config.Shared.Path
+	if path == "" {
+		return nil, fmt.Errorf("no shared inventory configured")
+	}
+	report := &SyncReport{Source: path}
+
+	if s.config.Shared.Pull && !dryRun {
+		output, err := shared.Pull(path)
+		if err != nil {
+			return nil, err
+		}
+		report.Pulled = output
+	}
+
+	entries, err := shared.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetShared()
+	if err != nil {
+		return nil, err
+	}
+	currentByName := make(map[string]*domain.Host)
+	for _, host := range current {
+		currentByName[host.Name] = host
+	}
+
+	all, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	localByName := make(map[string]*domain.Host)
+	for _, host := range all {
+		if !host.IsShared() {
+			localByName[host.Name] = host
+		}
+	}
+
+	var toAdd, toUpdate, toAdopt []*domain.Host
+	inFile := make(map[string]bool)
+	for _, entry := range entries {
+		host := s.hostFromShared(entry)
+		inFile[host.Name] = true
+
+		if existing, ok := currentByName[host.Name]; ok {
+			// Cached address and usage are local and not part of the file
+			host.ID = existing.ID
+			host.IPAddress = existing.IPAddress
+			host.UseCount = existing.UseCount
+			host.Connector = existing.Connector
+			host.ForwardAgent = existing.ForwardAgent
+			host.IdentitiesOnly = existing.IdentitiesOnly
+			host.Record = existing.Record
+			host.AddressFamily = existing.AddressFamily
+			if changes := diffHosts(existing, host); len(changes) > 0 {
+				report.Updated = append(report.Updated, HostChange{Name: host.Name, Changes: changes})
+				toUpdate = append(toUpdate, host)
+			} else {
+				report.Unchanged++
+			}
+			continue
+		}
+
+		if local, ok := localByName[host.Name]; ok {
+			host.ID = local.ID
+			host.IPAddress = local.IPAddress
+			host.Connector = local.Connector
+			host.ForwardAgent = local.ForwardAgent
+			host.IdentitiesOnly = local.IdentitiesOnly
+			host.Record = local.Record
+			host.AddressFamily = local.AddressFamily
+			report.Adopted = append(report.Adopted, host.Name)
+			toAdopt = append(toAdopt, host)
+			continue
+		}
+
+		report.Added = append(report.Added, host.Name)
+		toAdd = append(toAdd, host)
+	}
+
+	var toRemove []*domain.Host
+	for _, host := range current {
+		if !inFile[host.Name] {
+			report.Removed = append(report.Removed, host.Name)
+			toRemove = append(toRemove, host)
+		}
+	}
+
+	if dryRun || !report.HasChanges() {
+		return report, nil
+	}
+
+	if report.SafetyBackup, err = s.AutoBackup("pre-sync"); err != nil {
+		return nil, fmt.Errorf("failed to create safety backup: %w", err)
+	}
+
+	for _, host := range toAdopt {
+		local := localByName[host.Name]
+		overlay, err := s.repo.GetOverlay(host.Name)
+		if err != nil {
+			return nil, err
+		}
+		if local.Username != host.Username {
+			overlay.Username = local.Username
+		}
+		if local.KeyPath != "" && local.KeyPath != host.KeyPath {
+			overlay.KeyPath = local.KeyPath
+		}
+		if err := s.repo.SetOverlay(overlay); err != nil {
+			return nil, err
+		}
+		if err := s.repo.Update(host); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, host := range toUpdate {
+		if err := s.repo.Update(host); err != nil {
+			return nil, err
+		}
+	}
+
+	// Removals last: deleting renumbers host IDs
+	for _, host := range toRemove {
+		existing, err := s.repo.GetByName(host.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.Delete(existing.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, host := range toAdd {
+		if err := s.repo.Create(host); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// ToggleFavorite flips the favorite flag kept in the host's personal overlay
+func (s *HostService) ToggleFavorite(host *domain.Host) error {
+	overlay, err := s.repo.GetOverlay(host.Name)
+	if err != nil {
+		return err
+	}
+	overlay.Favorite = !overlay.Favorite
+	if err := s.repo.SetOverlay(overlay); err != nil {
+		return err
+	}
+	host.Favorite = overlay.Favorite
+	return nil
+}
+
+// updateSharedHost stores the locally changeable fields of a shared host
+// (user and key) as its personal overlay
+func (s *HostService) updateSharedHost(host *domain.Host) error {
+	base, err := s.sharedBase(host.Name)
+	if err != nil {
+		return err
+	}
+
+	if host.Hostname != base.Hostname || host.Port != base.Port || host.Description != base.Description ||
+		host.Tags != base.Tags || host.Group != base.Group || host.ProxyJump != base.ProxyJump {
+		return fmt.Errorf("%s is managed by the shared inventory; only its user and key can be changed locally", host.Name)
+	}
+
+	overlay, err := s.repo.GetOverlay(host.Name)
+	if err != nil {
+		return err
+	}
+	overlay.Username = ""
+	if host.Username != base.Username {
+		overlay.Username = host.Username
+	}
+	overlay.KeyPath = ""
+	if host.KeyPath != base.KeyPath {
+		overlay.KeyPath = host.KeyPath
+	}
+	return s.repo.SetOverlay(overlay)
+}
+
+// sharedBase returns a shared host as defined in the inventory, without overlays
+func (s *HostService) sharedBase(name string) (*domain.Host, error) {
+	hosts, err := s.repo.GetShared()
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range hosts {
+		if host.Name == name {
+			return host, nil
+		}
+	}
+	return nil, fmt.Errorf("shared host %s not found", name)
+}
+
+func (s *HostService) hostFromShared(entry shared.Entry) *domain.Host {
+	host := &domain.Host{
+		Name:        entry.Name,
+		Hostname:    entry.Hostname,
+		Port:        entry.Port,
+		Username:    entry.User,
+		Description: entry.Description,
+		Group:       entry.Group,
+		Tags:        JoinTags(entry.Tags),
+		ProxyJump:   entry.ProxyJump,
+		Origin:      domain.OriginShared,
+	}
+	if host.Port == 0 {
+		host.Port = s.config.DefaultPort
+	}
+	if host.Username == "" {
+		host.Username = s.getCurrentUsername()
+	}
+	if entry.Key != "" {
+		host.KeyPath = ssh.ExpandPath(entry.Key)
+	}
+	return host
+}
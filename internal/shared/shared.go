@@ -0,0 +1,92 @@
+// Package shared reads the team-shared host inventory, a YAML or JSON file
+// usually kept in a git checkout:
+//
+//	hosts:
+//	  - name: web1
+//	    hostname: web1.example.com
+//	    port: 22
+//	    user: deploy
+//	    key: ~/.ssh/team_ed25519
+//	    group: prod
+//	    tags: [web, eu]
+//	    proxy_jump: bastion
+//	    description: Frontend
+package shared
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is one host in the shared inventory
+type Entry struct {
+	Name        string   `yaml:"name" json:"name"`
+	Hostname    string   `yaml:"hostname" json:"hostname"`
+	Port        int      `yaml:"port,omitempty" json:"port,omitempty"`
+	User        string   `yaml:"user,omitempty" json:"user,omitempty"`
+	Key         string   `yaml:"key,omitempty" json:"key,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Group       string   `yaml:"group,omitempty" json:"group,omitempty"`
+	Tags        []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	ProxyJump   string   `yaml:"proxy_jump,omitempty" json:"proxy_jump,omitempty"`
+}
+
+// Inventory is the top level of the shared file
+type Inventory struct {
+	Hosts []Entry `yaml:"hosts" json:"hosts"`
+}
+
+// Load reads and validates the shared inventory. JSON is accepted because it is valid YAML.
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read shared inventory: %w", err)
+	}
+
+	var inventory Inventory
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&inventory); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, entry := range inventory.Hosts {
+		switch {
+		case entry.Name == "" || entry.Hostname == "":
+			return nil, fmt.Errorf("%s: host %d needs a name and hostname", path, i+1)
+		case seen[entry.Name]:
+			return nil, fmt.Errorf("%s: host %q is listed twice", path, entry.Name)
+		case entry.Port < 0 || entry.Port > 65535:
+			return nil, fmt.Errorf("%s: host %q has invalid port %d", path, entry.Name, entry.Port)
+		}
+		seen[entry.Name] = true
+	}
+
+	return inventory.Hosts, nil
+}
+
+// Pull updates the git checkout containing path with a fast-forward pull.
+// It returns git's output; files outside a git checkout are left alone.
+func Pull(path string) (string, error) {
+	dir := filepath.Dir(path)
+	if err := exec.Command("git", "-C", dir, "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return "", nil // Not a git checkout
+	}
+	if err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "@{upstream}").Run(); err != nil {
+		return "", nil // Local repository without a remote branch to pull from
+	}
+
+	output, err := exec.Command("git", "-C", dir, "pull", "--ff-only").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git pull failed: %s", strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/levanduy/ssh_management/internal/service"
+)
+
+const (
+	detailMinWidth = 100 // Narrower terminals show the list alone
+	detailSessions = 5   // Recent sessions listed in the pane
+)
+
+type detailLoadedMsg struct {
+	detail *service.HostDetail
+	err    error
+}
+
+// detailVisible reports whether the list shares the screen with the detail pane
+func (m Model) detailVisible() bool {
+	return m.showDetail && m.width >= detailMinWidth
+}
+
+// listWidth is the width left to the host list; the detail pane takes the
+// right 45% when it is shown
+func (m Model) listWidth() int {
+	if m.detailVisible() {
+		return m.width * 55 / 100
+	}
+	return m.width
+}
+
+// layout sizes the lists for the current terminal
+func (m *Model) layout() {
+	m.list.SetSize(m.listWidth(), m.height-4)
+	m.keyPicker.SetSize(m.width, m.height-4)
+}
+
+// selectedHost returns the host under the cursor, or nil
+func (m Model) selectedHost() *domain.Host {
+	if selected := m.list.SelectedItem(); selected != nil {
+		return selected.(hostItem).host
+	}
+	return nil
+}
+
+// followSelection loads the detail of the selected host when it changed,
+// including when the hosts were reloaded
+func (m Model) followSelection() (Model, tea.Cmd) {
+	host := m.selectedHost()
+	if !m.detailVisible() || host == m.detailFor {
+		return m, nil
+	}
+	m.detailFor = host
+	if host == nil {
+		m.detail = nil
+		return m, nil
+	}
+	return m, m.loadDetail(host)
+}
+
+func (m Model) loadDetail(host *domain.Host) tea.Cmd {
+	return func() tea.Msg {
+		detail, err := m.hostService.HostDetail(host, detailSessions)
+		return detailLoadedMsg{detail: detail, err: err}
+	}
+}
+
+// detailPane renders the selected host's detail in a bordered column
+func (m Model) detailPane(width, height int) string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(mutedColor).
+		PaddingLeft(1).
+		Width(width - 1).
+		MaxHeight(height)
+
+	detail := m.detail
+	if detail == nil || detail.Host != m.detailFor {
+		return style.Render(helpStyle.Render("No host selected"))
+	}
+	host := detail.Host
+	inner := width - 2
+
+	heading := lipgloss.NewStyle().Foreground(accentColor).Bold(true)
+	label := lipgloss.NewStyle().Foreground(mutedColor).Width(12)
+	var b strings.Builder
+	field := func(name, value string) {
+		if value == "" {
+			return
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, label.Render(name),
+			lipgloss.NewStyle().Width(inner-12).Render(value)) + "\n")
+	}
+	section := func(name string) {
+		b.WriteString("\n" + heading.Render(name) + "\n")
+	}
+
+	name := host.Name
+	if host.Favorite {
+		name = "★ " + name
+	}
+	b.WriteString(heading.Render(name) + "\n")
+	if host.Description != "" {
+		b.WriteString(lipgloss.NewStyle().Width(inner).Render(host.Description) + "\n")
+	}
+	b.WriteString("\n")
+
+	field("Hostname", host.Hostname)
+	if host.IPAddress != host.Hostname {
+		field("IP", host.IPAddress)
+	}
+	field("Port", fmt.Sprintf("%d", host.Port))
+	field("User", host.Username)
+	field("Family", familyLabel(host.AddressFamily))
+	field("Group", host.Group)
+	field("Connector", detail.Connector)
+	if host.ForwardAgent {
+		field("Forwarding", "ssh-agent")
+	}
+	if host.Record {
+		field("Recording", "always")
+	}
+	if host.IsShared() {
+		field("Inventory", "shared, read-only")
+	}
+
+	// The command comes before the longer sections, which are cut off first
+	// on short terminals
+	section("Command")
+	b.WriteString(lipgloss.NewStyle().Foreground(textColor).Width(inner).Render(detail.Command) + "\n")
+
+	section("Key")
+	switch {
+	case host.KeyPath == "":
+		b.WriteString(helpStyle.UnsetMarginTop().Render("ssh defaults") + "\n")
+	case detail.Key == nil:
+		field("Path", host.KeyPath)
+		b.WriteString(warningStyle.UnsetMarginTop().Width(inner).Render(detail.KeyError) + "\n")
+	default:
+		key := detail.Key
+		field("Path", host.KeyPath)
+		keyType := key.Type
+		if key.Bits > 0 {
+			keyType += fmt.Sprintf("-%d", key.Bits)
+		}
+		if key.Encrypted {
+			keyType += ", passphrase"
+		}
+		field("Type", keyType)
+		field("SHA256", strings.TrimPrefix(key.Fingerprint, "SHA256:"))
+		field("Comment", key.Comment)
+		if loaded, known := m.agentKeys[host.KeyPath]; known && loaded {
+			field("Agent", "loaded")
+		} else if known {
+			field("Agent", "not loaded")
+		}
+		if host.IdentitiesOnly {
+			field("Offers", "only this key")
+		}
+	}
+
+	section("Jump chain")
+	switch {
+	case detail.JumpError != "":
+		b.WriteString(errorStyle.UnsetMarginTop().Width(inner).Render(detail.JumpError) + "\n")
+	case len(detail.Jumps) == 0:
+		b.WriteString(helpStyle.UnsetMarginTop().Render("direct") + "\n")
+	default:
+		chain := append(append([]string{"you"}, detail.Jumps...), host.Name)
+		b.WriteString(lipgloss.NewStyle().Width(inner).Render(strings.Join(chain, " → ")) + "\n")
+	}
+
+	if tags := service.ParseTags(host.Tags); len(tags) > 0 {
+		section("Tags")
+		b.WriteString(lipgloss.NewStyle().Foreground(secondaryColor).Width(inner).Render(strings.Join(tags, " · ")) + "\n")
+	}
+
+	section("Activity")
+	field("Created", formatDate(host.CreatedAt))
+	field("Updated", formatDate(host.UpdatedAt))
+	field("Last used", formatDate(host.LastUsed))
+	field("Sessions", fmt.Sprintf("%d", host.UseCount))
+	for _, session := range detail.Sessions {
+		line := "  " + formatDate(session.ConnectedAt)
+		if session.RecordingPath != "" {
+			line += " ● recorded"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return style.Render(b.String())
+}
+
+// familyLabel names an address family limit for display
+func familyLabel(family string) string {
+	switch family {
+	case domain.FamilyInet:
+		return "IPv4 only"
+	case domain.FamilyInet6:
+		return "IPv6 only"
+	}
+	return ""
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -9,6 +11,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/levanduy/ssh_management/internal/dedupe"
 	"github.com/levanduy/ssh_management/internal/domain"
 	"github.com/levanduy/ssh_management/internal/service"
 	"github.com/levanduy/ssh_management/pkg/ssh"
@@ -21,6 +24,10 @@ const (
 	searchView
 	connectingView
 	confirmDeleteView
+	keyPickerView
+	agentPromptView
+	mergeView
+	discoveryView
 )
 
 type Model struct {
@@ -33,6 +40,19 @@ type Model struct {
 	height       int
 	message      string
 	hostToDelete *domain.Host // Host pending deletion
+	keyPicker    list.Model
+	keyTarget    *domain.Host              // Host whose key is being chosen
+	workspace    string                    // Shown in headers so inventories are not mixed up
+	agentKeys    map[string]bool           // Key path -> loaded in ssh-agent; nil without an agent
+	agentTarget  *domain.Host              // Host waiting for its key to be added to the agent
+	clusters     []*dedupe.Cluster         // Duplicate groups left to review; the first is shown
+	mergeChoices []int                     // Option picked per dedupe.Fields entry
+	mergeField   int                       // Field under the cursor
+	discovery    []*service.DiscoveredHost // Discovery changes under review
+	discoveryPos int                       // Change under the cursor
+	showDetail   bool                      // Detail pane toggled on; it also needs a wide terminal
+	detail       *service.HostDetail       // Detail of detailFor once loaded
+	detailFor    *domain.Host              // Host the detail pane follows
 }
 
 type hostItem struct {
@@ -46,9 +66,12 @@ func (h hostItem) FilterValue() string {
 func (h hostItem) Title() string {
 	// Host name in white
 	name := h.host.Name
+	if h.host.Favorite {
+		name = "★ " + name
+	}
 
 	// Connection info in cyan (like in image)
-	connInfo := fmt.Sprintf("(%s@%s:%d)", h.host.Username, h.host.Hostname, h.host.Port)
+	connInfo := "(" + h.host.Endpoint() + ")"
 
 	// IP address in green (like in image) if available and different from hostname
 	if h.host.IPAddress != "" && h.host.IPAddress != h.host.Hostname {
@@ -66,6 +89,16 @@ func (h hostItem) Description() string {
 		parts = append(parts, h.host.Description)
 	}
 
+	// Shared hosts come from the team inventory and are read-only
+	if h.host.IsShared() {
+		parts = append(parts, "shared")
+	}
+
+	// Group
+	if h.host.Group != "" {
+		parts = append(parts, "["+h.host.Group+"]")
+	}
+
 	// Tags
 	if h.host.Tags != "" {
 		parts = append(parts, h.host.Tags)
@@ -79,24 +112,105 @@ func (h hostItem) Description() string {
 	return strings.Join(parts, " • ")
 }
 
+type keyItem struct {
+	entry *service.KeyEntry // nil means "no key"
+}
+
+func (k keyItem) FilterValue() string {
+	if k.entry == nil {
+		return "none"
+	}
+	return k.entry.Key.Name + " " + k.entry.Key.Comment
+}
+
+func (k keyItem) Title() string {
+	if k.entry == nil {
+		return "(no key - use ssh defaults)"
+	}
+	key := k.entry.Key
+	title := fmt.Sprintf("%s (%s", key.Name, key.Type)
+	if key.Bits > 0 {
+		title += fmt.Sprintf("-%d", key.Bits)
+	}
+	title += ")"
+	if key.Encrypted {
+		title += " 🔒"
+	}
+	return title
+}
+
+func (k keyItem) Description() string {
+	if k.entry == nil {
+		return "Clear the key path for this host"
+	}
+	var parts []string
+	if k.entry.Key.Comment != "" {
+		parts = append(parts, k.entry.Key.Comment)
+	}
+	parts = append(parts, k.entry.Key.Fingerprint)
+	if n := len(k.entry.Hosts); n > 0 {
+		parts = append(parts, fmt.Sprintf("Used by %d host(s)", n))
+	}
+	return strings.Join(parts, " • ")
+}
+
 type keyMap struct {
-	Search  key.Binding
-	Connect key.Binding
-	Delete  key.Binding
-	Refresh key.Binding
-	Back    key.Binding
-	Quit    key.Binding
+	Search   key.Binding
+	Connect  key.Binding
+	Delete   key.Binding
+	SetKey   key.Binding
+	Favorite key.Binding
+	Merge    key.Binding
+	Discover key.Binding
+	Refresh  key.Binding
+	Details  key.Binding
+	Back     key.Binding
+	Quit     key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Search, k.Connect, k.Delete, k.Refresh, k.Quit}
+	return []key.Binding{k.Search, k.Connect, k.Delete, k.SetKey, k.Refresh, k.Quit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Search, k.Connect, k.Delete},
-		{k.Refresh, k.Back, k.Quit},
+		{k.Search, k.Connect, k.Delete, k.SetKey},
+		{k.Favorite, k.Merge, k.Discover, k.Refresh, k.Details, k.Back, k.Quit},
+	}
+}
+
+// applyKeymap replaces the keys of actions configured in the keymap setting
+func applyKeymap(keymap map[string][]string) {
+	bindings := map[string]*key.Binding{
+		"search":   &keys.Search,
+		"connect":  &keys.Connect,
+		"delete":   &keys.Delete,
+		"set_key":  &keys.SetKey,
+		"favorite": &keys.Favorite,
+		"merge":    &keys.Merge,
+		"discover": &keys.Discover,
+		"refresh":  &keys.Refresh,
+		"details":  &keys.Details,
+		"back":     &keys.Back,
+		"quit":     &keys.Quit,
+	}
+	for action, keyNames := range keymap {
+		binding, ok := bindings[action]
+		if !ok || len(keyNames) == 0 {
+			continue
+		}
+		binding.SetKeys(keyNames...)
+		binding.SetHelp(keyNames[0], binding.Help().Desc)
+	}
+}
+
+// helpLine renders the list view help from the current bindings
+func (k keyMap) helpLine() string {
+	parts := []string{"↑/k up", "↓/j down"}
+	for _, binding := range []key.Binding{k.Search, k.Connect, k.Delete, k.SetKey, k.Favorite, k.Merge, k.Discover, k.Refresh, k.Details, k.Quit} {
+		parts = append(parts, binding.Help().Key+" "+binding.Help().Desc)
 	}
+	return strings.Join(parts, " • ")
 }
 
 var keys = keyMap{
@@ -112,10 +226,30 @@ var keys = keyMap{
 		key.WithKeys("x"),
 		key.WithHelp("x", "delete"),
 	),
+	SetKey: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "set key"),
+	),
+	Favorite: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "favorite"),
+	),
+	Merge: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "merge duplicates"),
+	),
+	Discover: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "review discovery"),
+	),
 	Refresh: key.NewBinding(
 		key.WithKeys("r"),
 		key.WithHelp("r", "refresh"),
 	),
+	Details: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "details"),
+	),
 	Back: key.NewBinding(
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "back"),
@@ -126,7 +260,11 @@ var keys = keyMap{
 	),
 }
 
-func NewModel(hostService *service.HostService) Model {
+func NewModel(hostService *service.HostService, workspace string) Model {
+	cfg := hostService.Config()
+	applyTheme(cfg.Theme, cfg.Themes)
+	applyKeymap(cfg.Keymap)
+
 	// Create search input
 	searchInput := textinput.New()
 	searchInput.Placeholder = "Search hosts..."
@@ -142,7 +280,7 @@ func NewModel(hostService *service.HostService) Model {
 		Background(lipgloss.Color("")). // No background
 		Bold(true)
 	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.
-		Foreground(cyanColor).         // Cyan for selected description
+		Foreground(secondaryColor).    // Cyan for selected description
 		Background(lipgloss.Color("")) // No background
 
 	// Normal items
@@ -177,72 +315,103 @@ func NewModel(hostService *service.HostService) Model {
 	l.KeyMap.ShowFullHelp.SetEnabled(false)
 	l.KeyMap.CloseFullHelp.SetEnabled(false)
 
+	// Key picker reuses the host list styling
+	kp := list.New([]list.Item{}, delegate, 0, 0)
+	kp.Title = "Select SSH Key"
+	kp.SetShowStatusBar(false)
+	kp.SetFilteringEnabled(false)
+	kp.SetShowHelp(false)
+	kp.Styles.Title = titleStyle
+	kp.KeyMap.Quit.SetEnabled(false)
+	kp.KeyMap.ForceQuit.SetEnabled(false)
+
 	m := Model{
 		state:       listView,
 		list:        l,
 		searchInput: searchInput,
 		hostService: hostService,
+		keyPicker:   kp,
+		workspace:   workspace,
+		showDetail:  true,
 	}
 
 	return m
 }
 
 var (
-	// Color scheme matching the image - keep green and cyan
-	primaryColor = lipgloss.Color("#5B21B6") // Purple for headers only
-	accentColor  = lipgloss.Color("#10B981") // Green (like in image)
-	cyanColor    = lipgloss.Color("#06B6D4") // Cyan (like in image)
-	warningColor = lipgloss.Color("#F59E0B") // Orange
-	errorColor   = lipgloss.Color("#DC2626") // Red
-	mutedColor   = lipgloss.Color("#6B7280") // Gray
-	textColor    = lipgloss.Color("#F3F4F6") // Light gray
-	dimTextColor = lipgloss.Color("#9CA3AF") // Dimmed gray
+	// Colors of the current theme, set by applyTheme
+	primaryColor    lipgloss.TerminalColor // Header background
+	headerTextColor lipgloss.TerminalColor
+	accentColor     lipgloss.TerminalColor
+	secondaryColor  lipgloss.TerminalColor
+	warningColor    lipgloss.TerminalColor
+	errorColor      lipgloss.TerminalColor
+	mutedColor      lipgloss.TerminalColor
+	textColor       lipgloss.TerminalColor
+
+	titleStyle        lipgloss.Style
+	messageStyle      lipgloss.Style
+	errorStyle        lipgloss.Style
+	warningStyle      lipgloss.Style
+	helpStyle         lipgloss.Style
+	searchTitleStyle  lipgloss.Style
+	confirmTitleStyle lipgloss.Style
+)
+
+func init() {
+	usePalette(darkPalette)
+	buildStyles()
+}
 
+func buildStyles() {
 	// Clean header styles
 	titleStyle = lipgloss.NewStyle().
-			Foreground(textColor).
-			Background(primaryColor).
-			Padding(0, 1).
-			Bold(true)
+		Foreground(headerTextColor).
+		Background(primaryColor).
+		Padding(0, 1).
+		Bold(true)
 
 	// Simple message styles
 	messageStyle = lipgloss.NewStyle().
-			Foreground(accentColor).
-			MarginTop(1)
+		Foreground(accentColor).
+		MarginTop(1)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			MarginTop(1)
+		Foreground(errorColor).
+		MarginTop(1)
 
 	warningStyle = lipgloss.NewStyle().
-			Foreground(warningColor).
-			MarginTop(1)
+		Foreground(warningColor).
+		MarginTop(1)
 
 	// Clean help style
 	helpStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			MarginTop(1)
+		Foreground(mutedColor).
+		MarginTop(1)
 
 	// Simple search styles
-	searchTitleStyle = lipgloss.NewStyle().
-				Foreground(textColor).
-				Background(primaryColor).
-				Padding(0, 1).
-				Bold(true)
+	searchTitleStyle = titleStyle
 
-	// Simple confirmation styles
+	// Confirmations reverse the warning color, which reads on any background
 	confirmTitleStyle = lipgloss.NewStyle().
-				Foreground(textColor).
-				Background(warningColor).
-				Padding(0, 1).
-				Bold(true)
-)
+		Foreground(warningColor).
+		Reverse(true).
+		Padding(0, 1).
+		Bold(true)
+}
 
 func (m Model) Init() tea.Cmd {
-	return m.refreshWithDiscovery()
+	return tea.Sequence(m.refreshWithDiscovery(), m.refreshAddresses())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	// Whatever moved the cursor or reloaded the hosts, the detail pane follows
+	next, detailCmd := updated.followSelection()
+	return next, tea.Batch(cmd, detailCmd)
+}
+
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
@@ -250,9 +419,106 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.list.SetSize(msg.Width, msg.Height-4)
+		m.layout()
+		return m, nil
+
+	case detailLoadedMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		if msg.detail.Host == m.detailFor {
+			m.detail = msg.detail
+		}
+		return m, nil
+
+	case keysLoadedMsg:
+		items := []list.Item{keyItem{}}
+		selected := 0
+		for i, entry := range msg.entries {
+			items = append(items, keyItem{entry: entry})
+			if m.keyTarget != nil && m.keyTarget.KeyPath != "" {
+				for _, host := range entry.Hosts {
+					if host.ID == m.keyTarget.ID {
+						selected = i + 1
+					}
+				}
+			}
+		}
+		m.keyPicker.SetItems(items)
+		m.keyPicker.Select(selected)
+		m.state = keyPickerView
+		return m, nil
+
+	case agentStatusMsg:
+		m.agentKeys = msg.loaded
+		return m, nil
+
+	case agentAddedMsg:
+		m.state = listView
+		m.agentTarget = nil
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Warning: ssh-add failed: %v", msg.err)
+		}
+		return m, tea.Batch(m.loadAgentStatus(m.hosts), m.connectToHost(msg.host))
+
+	case favoriteToggledMsg:
+		m.message = msg.message
+		return m, nil
+
+	case duplicatesFoundMsg:
+		if len(msg.clusters) == 0 {
+			m.message = "No duplicate hosts found"
+			return m, nil
+		}
+		m.message = ""
+		m.clusters = msg.clusters
+		m.showCluster()
+		return m, nil
+
+	case discoveryPlanMsg:
+		if msg.plan.IsEmpty() {
+			m.message = "🔍 Discovery found nothing new"
+			if len(msg.plan.Errors) > 0 {
+				m.message = fmt.Sprintf("Warning: discovery found nothing new; %v", msg.plan.Errors[0])
+			}
+			return m, nil
+		}
+		m.message = ""
+		m.discovery = append(append([]*service.DiscoveredHost{}, msg.plan.Add...), msg.plan.Update...)
+		m.discoveryPos = 0
+		m.state = discoveryView
 		return m, nil
 
+	case discoveryAppliedMsg:
+		m.hosts = msg.hosts
+		items := make([]list.Item, len(m.hosts))
+		for i, host := range m.hosts {
+			items[i] = hostItem{host: host}
+		}
+		m.list.SetItems(items)
+		m.message = "🔍 Discovery: " + msg.summary
+		m.discovery = nil
+		m.state = listView
+		return m, m.loadAgentStatus(m.hosts)
+
+	case hostsMergedMsg:
+		m.hosts = msg.hosts
+		items := make([]list.Item, len(m.hosts))
+		for i, host := range m.hosts {
+			items[i] = hostItem{host: host}
+		}
+		m.list.SetItems(items)
+		m.message = msg.message
+		m.nextCluster()
+		return m, nil
+
+	case keyAssignedMsg:
+		m.state = listView
+		m.keyTarget = nil
+		m.message = msg.message
+		return m, m.loadHosts()
+
 	case hostsLoadedMsg:
 		m.hosts = msg.hosts
 		items := make([]list.Item, len(m.hosts))
@@ -261,7 +527,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.list.SetItems(items)
 		m.message = fmt.Sprintf("Loaded %d host(s)", len(m.hosts))
-		return m, nil
+		return m, m.loadAgentStatus(m.hosts)
 
 	case hostConnectedMsg:
 		m.message = fmt.Sprintf("Connected to %s", msg.hostName)
@@ -280,7 +546,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.list.SetItems(items)
 		m.hosts = hosts
-		m.message = fmt.Sprintf("🔍 Auto-discovered %d new host(s)", msg.newHostsCount)
+		m.message = "🔍 Auto-discovery: " + msg.summary
+		return m, m.loadAgentStatus(m.hosts)
+
+	case addressesRefreshedMsg:
+		// Lookups run in the background; only moved or new addresses are worth a message
+		if msg.err != nil || !msg.report.HasChanges() {
+			return m, nil
+		}
+		m.message = "🌐 Addresses: " + msg.report.Summary()
+		return m, m.reloadHosts()
+
+	case hostsReloadedMsg:
+		m.hosts = msg.hosts
+		items := make([]list.Item, len(m.hosts))
+		for i, host := range m.hosts {
+			items[i] = hostItem{host: host}
+		}
+		m.list.SetItems(items)
 		return m, nil
 
 	case errorMsg:
@@ -303,6 +586,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				selected := m.list.SelectedItem()
 				if selected != nil {
 					host := selected.(hostItem).host
+					if m.offerAgentAdd(host) {
+						m.agentTarget = host
+						m.state = agentPromptView
+						return m, nil
+					}
 					return m, m.connectToHost(host)
 				}
 
@@ -314,8 +602,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 
+			case key.Matches(msg, keys.Favorite):
+				selected := m.list.SelectedItem()
+				if selected != nil {
+					return m, m.toggleFavorite(selected.(hostItem).host)
+				}
+
+			case key.Matches(msg, keys.SetKey):
+				selected := m.list.SelectedItem()
+				if selected != nil {
+					m.keyTarget = selected.(hostItem).host
+					return m, m.loadKeys()
+				}
+
+			case key.Matches(msg, keys.Discover):
+				m.message = "Running discovery..."
+				return m, m.planDiscovery()
+
+			case key.Matches(msg, keys.Merge):
+				m.message = "Looking for duplicate hosts..."
+				return m, m.findDuplicates()
+
 			case key.Matches(msg, keys.Refresh):
-				return m, m.refreshWithDiscovery()
+				return m, tea.Sequence(m.refreshWithDiscovery(), m.refreshAddresses())
+
+			case key.Matches(msg, keys.Details):
+				m.showDetail = !m.showDetail
+				m.detailFor = nil // Reload when shown again
+				m.layout()
+				if m.showDetail && m.width < detailMinWidth {
+					m.message = fmt.Sprintf("Warning: the detail pane needs a terminal at least %d columns wide", detailMinWidth)
+				}
+				return m, nil
 			}
 
 			// Update list only if we're in listView and key wasn't handled above
@@ -356,7 +674,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					host := m.hostToDelete
 					m.hostToDelete = nil
 					m.state = listView
-					return m, m.deleteHost(host)
+					return m, m.deleteHost(host, false)
+				}
+				m.state = listView
+				return m, nil
+
+			case msg.String() == "i", msg.String() == "I":
+				// Delete and keep discovery from adding it back
+				if m.hostToDelete != nil {
+					host := m.hostToDelete
+					m.hostToDelete = nil
+					m.state = listView
+					return m, m.deleteHost(host, true)
 				}
 				m.state = listView
 				return m, nil
@@ -367,6 +696,113 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.hostToDelete = nil
 				return m, nil
 			}
+
+		case agentPromptView:
+			switch {
+			case key.Matches(msg, keys.Back), msg.String() == "q":
+				m.state = listView
+				m.agentTarget = nil
+				return m, nil
+
+			case msg.Type == tea.KeyEnter, msg.String() == "y", msg.String() == "Y":
+				if m.agentTarget != nil {
+					return m, m.addToAgent(m.agentTarget)
+				}
+				m.state = listView
+				return m, nil
+
+			case msg.String() == "n", msg.String() == "N":
+				host := m.agentTarget
+				m.state = listView
+				m.agentTarget = nil
+				if host != nil {
+					return m, m.connectToHost(host)
+				}
+				return m, nil
+			}
+
+		case discoveryView:
+			switch msg.String() {
+			case "esc", "q":
+				m.discovery = nil
+				m.state = listView
+				m.message = "Discovery cancelled"
+				return m, nil
+			case "up", "k":
+				if m.discoveryPos > 0 {
+					m.discoveryPos--
+				}
+			case "down", "j":
+				if m.discoveryPos < len(m.discovery)-1 {
+					m.discoveryPos++
+				}
+			case " ", "x":
+				change := m.discovery[m.discoveryPos]
+				change.Skip = !change.Skip
+			case "a":
+				for _, change := range m.discovery {
+					change.Skip = false
+				}
+			case "n":
+				for _, change := range m.discovery {
+					change.Skip = true
+				}
+			case "enter":
+				return m, m.applyDiscovery(m.discovery)
+			}
+			return m, nil
+
+		case mergeView:
+			if len(m.clusters) == 0 {
+				m.state = listView
+				return m, nil
+			}
+			cluster := m.clusters[0]
+			switch msg.String() {
+			case "esc", "q":
+				m.clusters = nil
+				m.state = listView
+				return m, nil
+			case "up", "k":
+				if m.mergeField > 0 {
+					m.mergeField--
+				}
+			case "down", "j", "tab":
+				if m.mergeField < len(dedupe.Fields)-1 {
+					m.mergeField++
+				}
+			case "left", "h":
+				options := cluster.Options(dedupe.Fields[m.mergeField])
+				m.mergeChoices[m.mergeField] = (m.mergeChoices[m.mergeField] + len(options) - 1) % len(options)
+			case "right", "l":
+				options := cluster.Options(dedupe.Fields[m.mergeField])
+				m.mergeChoices[m.mergeField] = (m.mergeChoices[m.mergeField] + 1) % len(options)
+			case "enter", "y":
+				keep, remove := cluster.Merge(m.mergeChoices)
+				return m, m.mergeHosts(keep, remove)
+			case "s", "n":
+				m.nextCluster()
+			}
+			return m, nil
+
+		case keyPickerView:
+			switch {
+			case key.Matches(msg, keys.Back), msg.String() == "q":
+				m.state = listView
+				m.keyTarget = nil
+				return m, nil
+
+			case msg.Type == tea.KeyEnter:
+				selected := m.keyPicker.SelectedItem()
+				if selected != nil && m.keyTarget != nil {
+					return m, m.assignKey(m.keyTarget, selected.(keyItem).entry)
+				}
+				m.state = listView
+				return m, nil
+			}
+
+			m.keyPicker, cmd = m.keyPicker.Update(msg)
+			cmds = append(cmds, cmd)
 		}
 	}
 
@@ -376,7 +812,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) View() string {
 	switch m.state {
 	case searchView:
-		header := searchTitleStyle.Render("Search SSH Hosts")
+		header := searchTitleStyle.Render("Search SSH Hosts · " + m.workspace)
 		input := m.searchInput.View()
 		help := helpStyle.Render("Press Enter to search • Esc to cancel")
 
@@ -388,40 +824,85 @@ func (m Model) View() string {
 
 			hostInfo := fmt.Sprintf(
 				"Host: %s\n"+
-					"Connection: %s@%s:%d\n"+
+					"Connection: %s\n"+
 					"IP: %s",
 				m.hostToDelete.Name,
-				m.hostToDelete.Username,
-				m.hostToDelete.Hostname,
-				m.hostToDelete.Port,
+				m.hostToDelete.Endpoint(),
 				m.hostToDelete.IPAddress,
 			)
 
 			warning := warningStyle.Render(
 				"This will remove the host from:\n" +
 					"• SSH Manager database\n" +
-					"• ~/.ssh/known_hosts file",
+					"• ~/.ssh/known_hosts file\n" +
+					"A backup is saved to ~/.sshm/backups first.",
 			)
 
-			help := helpStyle.Render("Press 'y' to confirm • 'n' or 'Esc' to cancel")
+			help := helpStyle.Render("Press 'y' to confirm • 'i' to delete and ignore in discovery • 'n' or 'Esc' to cancel")
 
 			return fmt.Sprintf("%s\n\n%s\n\n%s\n\nContinue? (y/N)\n\n%s", title, hostInfo, warning, help)
 		}
 		return errorStyle.Render("Error: No host selected for deletion")
 
+	case agentPromptView:
+		if m.agentTarget == nil {
+			return errorStyle.Render("Error: No host selected")
+		}
+		title := confirmTitleStyle.Render("Key Not Loaded in ssh-agent")
+
+		agent := m.hostService.Config().Agent
+		command := ssh.AddToAgentCommand(m.agentTarget.KeyPath, agent.LifetimeDuration(), agent.Confirm)
+		info := fmt.Sprintf(
+			"Host: %s\n"+
+				"Key: %s\n\n"+
+				"Load the key before connecting so you are asked for its passphrase once:\n"+
+				"  %s",
+			m.agentTarget.Name,
+			m.agentTarget.KeyPath,
+			strings.Join(command.Args, " "),
+		)
+
+		help := helpStyle.Render("Press 'y' to add and connect • 'n' to connect without • 'Esc' to cancel")
+		return fmt.Sprintf("%s\n\n%s\n\nAdd key? (Y/n)\n\n%s", title, info, help)
+
+	case mergeView:
+		return m.mergeViewContent()
+
+	case discoveryView:
+		return m.discoveryViewContent()
+
+	case keyPickerView:
+		var current string
+		if m.keyTarget != nil {
+			current = fmt.Sprintf("Host: %s • Current key: %s", m.keyTarget.Name, m.keyTarget.KeyPath)
+			if m.keyTarget.KeyPath == "" {
+				current = fmt.Sprintf("Host: %s • Current key: none", m.keyTarget.Name)
+			}
+		}
+		help := helpStyle.Render("↑/↓ select • enter assign • esc cancel")
+		return helpStyle.Render(current) + "\n\n" + m.keyPicker.View() + "\n\n" + help
+
 	default:
 		// Main list view
-		header := titleStyle.Render("SSH Manager")
+		header := titleStyle.Render("SSH Manager · " + m.workspace)
 
 		// Status bar
 		statusText := fmt.Sprintf("Total hosts: %d", len(m.hosts))
 		if len(m.hosts) > 0 {
 			statusText += fmt.Sprintf(" • Selected: %d", m.list.Index()+1)
 		}
+		if selected := m.list.SelectedItem(); selected != nil {
+			statusText += m.agentStatusText(selected.(hostItem).host)
+		}
 		statusBar := helpStyle.Render(statusText)
 
-		// Main content
+		// Main content, with the selected host's detail beside it when there is room
 		content := m.list.View()
+		if m.detailVisible() {
+			content = lipgloss.JoinHorizontal(lipgloss.Top,
+				lipgloss.NewStyle().Width(m.listWidth()).Render(content),
+				m.detailPane(m.width-m.listWidth(), m.height-4))
+		}
 
 		// Message display
 		var message string
@@ -438,9 +919,7 @@ func (m Model) View() string {
 		}
 
 		// Help text
-		helpText := helpStyle.Render(
-			"↑/k up • ↓/j down • / search • enter connect • x delete • r refresh • q quit",
-		)
+		helpText := helpStyle.Render(keys.helpLine())
 
 		// Combine elements
 		result := header + "\n" + statusBar + "\n\n" + content
@@ -469,7 +948,56 @@ type errorMsg struct {
 }
 
 type discoveryMsg struct {
-	newHostsCount int
+	summary string
+}
+
+type keysLoadedMsg struct {
+	entries []*service.KeyEntry
+}
+
+type keyAssignedMsg struct {
+	message string
+}
+
+type favoriteToggledMsg struct {
+	message string
+}
+
+type agentStatusMsg struct {
+	loaded map[string]bool
+}
+
+type duplicatesFoundMsg struct {
+	clusters []*dedupe.Cluster
+}
+
+type discoveryPlanMsg struct {
+	plan *service.DiscoveryPlan
+}
+
+type discoveryAppliedMsg struct {
+	summary string
+	hosts   []*domain.Host
+}
+
+type hostsMergedMsg struct {
+	message string
+	hosts   []*domain.Host
+}
+
+type agentAddedMsg struct {
+	host *domain.Host
+	err  error
+}
+
+type addressesRefreshedMsg struct {
+	report *service.AddressReport
+	err    error
+}
+
+// hostsReloadedMsg updates the list without replacing the status message
+type hostsReloadedMsg struct {
+	hosts []*domain.Host
 }
 
 func (m Model) loadHosts() tea.Cmd {
@@ -485,7 +1013,7 @@ func (m Model) loadHosts() tea.Cmd {
 func (m Model) refreshWithDiscovery() tea.Cmd {
 	return func() tea.Msg {
 		// First run auto-discovery
-		newHostsCount, err := m.hostService.AutoDiscoverFromKnownHosts()
+		report, err := m.hostService.AutoDiscover()
 		if err != nil {
 			return errorMsg{error: fmt.Sprintf("Auto-discovery failed: %v", err)}
 		}
@@ -496,15 +1024,33 @@ func (m Model) refreshWithDiscovery() tea.Cmd {
 			return errorMsg{error: err.Error()}
 		}
 
-		// If new hosts were discovered, show discovery message
-		if newHostsCount > 0 {
-			return discoveryMsg{newHostsCount: newHostsCount}
+		// If hosts were added or updated, show what discovery did
+		if report.HasChanges() {
+			return discoveryMsg{summary: report.Summary()}
 		}
 
 		return hostsLoadedMsg{hosts: hosts}
 	}
 }
 
+// refreshAddresses looks up hostnames whose cached addresses expired
+func (m Model) refreshAddresses() tea.Cmd {
+	return func() tea.Msg {
+		report, err := m.hostService.RefreshAddresses(false)
+		return addressesRefreshedMsg{report: report, err: err}
+	}
+}
+
+func (m Model) reloadHosts() tea.Cmd {
+	return func() tea.Msg {
+		hosts, err := m.hostService.GetAllHosts()
+		if err != nil {
+			return errorMsg{error: err.Error()}
+		}
+		return hostsReloadedMsg{hosts: hosts}
+	}
+}
+
 func (m Model) searchHosts(query string) tea.Cmd {
 	return func() tea.Msg {
 		hosts, err := m.hostService.SearchHosts(query)
@@ -517,13 +1063,12 @@ func (m Model) searchHosts(query string) tea.Cmd {
 
 func (m Model) connectToHost(host *domain.Host) tea.Cmd {
 	return func() tea.Msg {
-		// Update usage stats
-		if err := m.hostService.ConnectToHost(host.ID); err != nil {
-			return errorMsg{error: fmt.Sprintf("Failed to update stats: %v", err)}
-		}
-
-		// Connect via SSH
-		if err := ssh.ConnectToHost(host); err != nil {
+		// Connect via SSH, running hooks and logging and recording the session
+		if err := m.hostService.Connect(host); err != nil {
+			var hookErr *service.HookError
+			if errors.As(err, &hookErr) {
+				return errorMsg{error: err.Error()}
+			}
 			return errorMsg{error: fmt.Sprintf("SSH connection failed: %v", err)}
 		}
 
@@ -531,9 +1076,14 @@ func (m Model) connectToHost(host *domain.Host) tea.Cmd {
 	}
 }
 
-func (m Model) deleteHost(host *domain.Host) tea.Cmd {
+// deleteHost deletes a host; with ignore, discovery will not add it back
+func (m Model) deleteHost(host *domain.Host, ignore bool) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.hostService.DeleteHostFromBoth(host.ID); err != nil {
+		remove := m.hostService.DeleteHostFromBoth
+		if ignore {
+			remove = m.hostService.DeleteAndIgnore
+		}
+		if err := remove(host.ID); err != nil {
 			return errorMsg{error: fmt.Sprintf("Failed to delete host: %v", err)}
 		}
 
@@ -545,3 +1095,253 @@ func (m Model) deleteHost(host *domain.Host) tea.Cmd {
 		return hostsLoadedMsg{hosts: hosts}
 	}
 }
+
+// loadAgentStatus checks which of the hosts' keys are loaded in ssh-agent
+func (m Model) loadAgentStatus(hosts []*domain.Host) tea.Cmd {
+	return func() tea.Msg {
+		loaded, err := m.hostService.AgentKeyStatus(hosts)
+		if err != nil {
+			return agentStatusMsg{} // No agent: nothing to show or offer
+		}
+		return agentStatusMsg{loaded: loaded}
+	}
+}
+
+// offerAgentAdd reports whether to offer loading the host's key before connecting
+func (m Model) offerAgentAdd(host *domain.Host) bool {
+	if !m.hostService.Config().Agent.OfferAdd || host.KeyPath == "" {
+		return false
+	}
+	loaded, known := m.agentKeys[host.KeyPath]
+	return known && !loaded
+}
+
+// addToAgent runs ssh-add in the terminal, suspending the TUI for the passphrase prompt
+func (m Model) addToAgent(host *domain.Host) tea.Cmd {
+	agent := m.hostService.Config().Agent
+	cmd := ssh.AddToAgentCommand(host.KeyPath, agent.LifetimeDuration(), agent.Confirm)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return agentAddedMsg{host: host, err: err}
+	})
+}
+
+// agentStatusText describes whether the selected host's key is in ssh-agent
+func (m Model) agentStatusText(host *domain.Host) string {
+	loaded, known := m.agentKeys[host.KeyPath]
+	if host.KeyPath == "" || !known {
+		return ""
+	}
+	name := filepath.Base(host.KeyPath)
+	if loaded {
+		return fmt.Sprintf(" • 🔑 %s in agent", name)
+	}
+	return fmt.Sprintf(" • 🔑 %s not in agent", name)
+}
+
+// toggleFavorite flips the host's favorite flag; the list item shares the host pointer
+func (m Model) toggleFavorite(host *domain.Host) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.hostService.ToggleFavorite(host); err != nil {
+			return errorMsg{error: fmt.Sprintf("Failed to update favorite: %v", err)}
+		}
+		if host.Favorite {
+			return favoriteToggledMsg{message: fmt.Sprintf("★ %s added to favorites", host.Name)}
+		}
+		return favoriteToggledMsg{message: fmt.Sprintf("%s removed from favorites", host.Name)}
+	}
+}
+
+func (m Model) loadKeys() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := m.hostService.ListKeys()
+		if err != nil {
+			return errorMsg{error: fmt.Sprintf("Failed to scan keys: %v", err)}
+		}
+		return keysLoadedMsg{entries: entries}
+	}
+}
+
+func (m Model) assignKey(host *domain.Host, entry *service.KeyEntry) tea.Cmd {
+	return func() tea.Msg {
+		if entry == nil {
+			if err := m.hostService.SetHostKey(host, nil); err != nil {
+				return errorMsg{error: fmt.Sprintf("Failed to clear key: %v", err)}
+			}
+			return keyAssignedMsg{message: fmt.Sprintf("Cleared key for %s", host.Name)}
+		}
+
+		if err := m.hostService.SetHostKey(host, entry.Key); err != nil {
+			return errorMsg{error: fmt.Sprintf("Failed to set key: %v", err)}
+		}
+		return keyAssignedMsg{message: fmt.Sprintf("Using %s for %s", entry.Key.Name, host.Name)}
+	}
+}
+
+func (m Model) findDuplicates() tea.Cmd {
+	return func() tea.Msg {
+		clusters, err := m.hostService.FindDuplicates()
+		if err != nil {
+			return errorMsg{error: fmt.Sprintf("Failed to look for duplicates: %v", err)}
+		}
+		return duplicatesFoundMsg{clusters: clusters}
+	}
+}
+
+func (m Model) mergeHosts(keep *domain.Host, remove []*domain.Host) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.hostService.MergeHosts(keep, remove); err != nil {
+			return errorMsg{error: fmt.Sprintf("Failed to merge hosts: %v", err)}
+		}
+		hosts, err := m.hostService.GetAllHosts()
+		if err != nil {
+			return errorMsg{error: err.Error()}
+		}
+		message := fmt.Sprintf("🔀 Merged %d host(s) into %s", len(remove), keep.Name)
+		return hostsMergedMsg{message: message, hosts: hosts}
+	}
+}
+
+// showCluster opens the merge view on the first remaining duplicate group
+func (m *Model) showCluster() {
+	if len(m.clusters) == 0 {
+		m.state = listView
+		return
+	}
+	m.mergeChoices = m.clusters[0].Defaults()
+	m.mergeField = 0
+	m.state = mergeView
+}
+
+// nextCluster moves on to the next duplicate group, or back to the list
+func (m *Model) nextCluster() {
+	if len(m.clusters) > 0 {
+		m.clusters = m.clusters[1:]
+	}
+	m.showCluster()
+}
+
+// mergeViewContent shows the duplicates side by side and the value picked
+// for each field of the merged host
+func (m Model) mergeViewContent() string {
+	if len(m.clusters) == 0 {
+		return errorStyle.Render("Error: No duplicates to merge")
+	}
+	cluster := m.clusters[0]
+	title := confirmTitleStyle.Render(fmt.Sprintf("Merge Duplicates (%d left)", len(m.clusters)))
+	reasons := helpStyle.Render(fmt.Sprintf("%s confidence (%d): %s",
+		dedupe.Confidence(cluster.Score), cluster.Score, strings.Join(cluster.Reasons, "; ")))
+
+	var b strings.Builder
+	for _, host := range cluster.Hosts {
+		fmt.Fprintf(&b, "  %s (%s) • used %d times\n", host.Name, host.Endpoint(), host.UseCount)
+	}
+
+	b.WriteString("\nMerged host:\n")
+	selected := lipgloss.NewStyle().Foreground(accentColor).Bold(true)
+	for i, field := range dedupe.Fields {
+		options := cluster.Options(field)
+		value := options[m.mergeChoices[i]]
+		if value == "" {
+			value = "-"
+		}
+		if len(options) > 1 {
+			value = fmt.Sprintf("◀ %s ▶  (%d/%d)", value, m.mergeChoices[i]+1, len(options))
+		}
+		line := fmt.Sprintf("%-12s %s", field.Label, value)
+		if i == m.mergeField {
+			b.WriteString(selected.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	keep, remove := cluster.Merge(m.mergeChoices)
+	summary := messageStyle.Render(fmt.Sprintf("Keeps %s with %d combined uses; %d host(s) and their history are folded into it",
+		keep.Name, keep.UseCount, len(remove)))
+	help := helpStyle.Render("↑/↓ field • ←/→ pick value • enter merge • s skip • esc cancel")
+
+	result := fmt.Sprintf("%s\n%s\n\n%s%s", title, reasons, b.String(), summary)
+	if strings.HasPrefix(m.message, "Error") {
+		result += "\n" + errorStyle.Render(m.message)
+	} else if m.message != "" {
+		result += "\n" + messageStyle.Render(m.message)
+	}
+	return result + "\n\n" + help
+}
+
+// planDiscovery runs the enabled discovery sources without changing anything
+func (m Model) planDiscovery() tea.Cmd {
+	return func() tea.Msg {
+		plan, err := m.hostService.PlanDiscovery(nil)
+		if err != nil {
+			return errorMsg{error: fmt.Sprintf("Discovery failed: %v", err)}
+		}
+		return discoveryPlanMsg{plan: plan}
+	}
+}
+
+// applyDiscovery stores the accepted discovery changes
+func (m Model) applyDiscovery(changes []*service.DiscoveredHost) tea.Cmd {
+	plan := &service.DiscoveryPlan{}
+	for _, change := range changes {
+		if len(change.Changes) > 0 {
+			plan.Update = append(plan.Update, change)
+		} else {
+			plan.Add = append(plan.Add, change)
+		}
+	}
+	return func() tea.Msg {
+		report := m.hostService.ApplyDiscovery(plan)
+		hosts, err := m.hostService.GetAllHosts()
+		if err != nil {
+			return errorMsg{error: err.Error()}
+		}
+		return discoveryAppliedMsg{summary: report.Summary(), hosts: hosts}
+	}
+}
+
+// discoveryViewContent lists the discovery changes to accept or reject
+func (m Model) discoveryViewContent() string {
+	title := titleStyle.Render("Review Discovery · " + m.workspace)
+
+	accepted := 0
+	var b strings.Builder
+	selected := lipgloss.NewStyle().Foreground(accentColor).Bold(true)
+	skipped := lipgloss.NewStyle().Foreground(mutedColor)
+	for i, change := range m.discovery {
+		mark := "[x]"
+		if change.Skip {
+			mark = "[ ]"
+		} else {
+			accepted++
+		}
+
+		host := change.Host
+		var line string
+		if len(change.Changes) == 0 {
+			line = fmt.Sprintf("%s + %s (%s) • %s", mark, host.Name, host.Endpoint(), strings.Join(change.Sources, ", "))
+			if change.Wanted != "" {
+				line += fmt.Sprintf(" • %s clashes with another host", change.Wanted)
+			}
+		} else {
+			var fields []string
+			for _, field := range change.Changes {
+				fields = append(fields, fmt.Sprintf("%s %q → %q", field.Field, field.Old, field.New))
+			}
+			line = fmt.Sprintf("%s ~ %s: %s", mark, host.Name, strings.Join(fields, ", "))
+		}
+
+		switch {
+		case i == m.discoveryPos:
+			b.WriteString(selected.Render("> "+line) + "\n")
+		case change.Skip:
+			b.WriteString(skipped.Render("  "+line) + "\n")
+		default:
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	status := helpStyle.Render(fmt.Sprintf("%d of %d change(s) accepted", accepted, len(m.discovery)))
+	help := helpStyle.Render("↑/↓ move • space accept/reject • a accept all • n reject all • enter apply • esc cancel")
+	return fmt.Sprintf("%s\n%s\n\n%s%s", title, status, b.String(), help)
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/muesli/termenv"
+)
+
+// palette is the set of colors a theme gives the TUI
+type palette struct {
+	header     lipgloss.TerminalColor // Title background
+	headerText lipgloss.TerminalColor
+	accent     lipgloss.TerminalColor // Selected items and messages
+	secondary  lipgloss.TerminalColor // Selected descriptions and tags
+	warning    lipgloss.TerminalColor
+	err        lipgloss.TerminalColor
+	muted      lipgloss.TerminalColor // Descriptions, help and labels
+	text       lipgloss.TerminalColor
+}
+
+var (
+	darkPalette = palette{
+		header:     lipgloss.Color("#5B21B6"), // Purple
+		headerText: lipgloss.Color("#F3F4F6"), // Light gray
+		accent:     lipgloss.Color("#10B981"), // Green
+		secondary:  lipgloss.Color("#06B6D4"), // Cyan
+		warning:    lipgloss.Color("#F59E0B"), // Orange
+		err:        lipgloss.Color("#DC2626"), // Red
+		muted:      lipgloss.Color("#6B7280"), // Gray
+		text:       lipgloss.Color("#F3F4F6"), // Light gray
+	}
+
+	lightPalette = palette{
+		header:     lipgloss.Color("#EDE9FE"), // Pale violet, readable on white
+		headerText: lipgloss.Color("#4C1D95"), // Deep purple
+		accent:     lipgloss.Color("#047857"), // Darker green
+		secondary:  lipgloss.Color("#0E7490"), // Darker cyan
+		warning:    lipgloss.Color("#B45309"), // Darker orange
+		err:        lipgloss.Color("#B91C1C"), // Darker red
+		muted:      lipgloss.Color("#4B5563"), // Dark gray
+		text:       lipgloss.Color("#111827"), // Near black
+	}
+
+	// High contrast sticks to the basic ANSI colors, bright on dark
+	// terminals and dark on light ones
+	highContrastPalette = palette{
+		header:     lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+		headerText: lipgloss.AdaptiveColor{Light: "15", Dark: "0"},
+		accent:     lipgloss.AdaptiveColor{Light: "2", Dark: "10"},
+		secondary:  lipgloss.AdaptiveColor{Light: "4", Dark: "14"},
+		warning:    lipgloss.AdaptiveColor{Light: "5", Dark: "11"},
+		err:        lipgloss.AdaptiveColor{Light: "1", Dark: "9"},
+		muted:      lipgloss.AdaptiveColor{Light: "0", Dark: "7"},
+		text:       lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+	}
+)
+
+// applyTheme switches to a built-in theme or one of the user's themes,
+// which start from a built-in theme and replace some of its colors
+func applyTheme(name string, themes map[string]domain.ThemeConfig) {
+	colors := builtinPalette(name)
+	if theme, ok := themes[name]; ok {
+		colors = builtinPalette(theme.Base)
+		override(&colors.header, theme.Header)
+		override(&colors.headerText, theme.HeaderText)
+		override(&colors.accent, theme.Accent)
+		override(&colors.secondary, theme.Secondary)
+		override(&colors.warning, theme.Warning)
+		override(&colors.err, theme.Error)
+		override(&colors.muted, theme.Muted)
+		override(&colors.text, theme.Text)
+	}
+	usePalette(colors)
+	buildStyles()
+}
+
+// builtinPalette returns a built-in theme's colors. auto, or an empty name,
+// asks the terminal for its background and picks dark or light to match;
+// without colors there is nothing to match, so the terminal is not asked.
+func builtinPalette(name string) palette {
+	switch name {
+	case "dark":
+		return darkPalette
+	case "light":
+		return lightPalette
+	case "high-contrast":
+		return highContrastPalette
+	}
+	if noColor() || lipgloss.HasDarkBackground() {
+		return darkPalette
+	}
+	return lightPalette
+}
+
+func override(color *lipgloss.TerminalColor, value string) {
+	if value != "" {
+		*color = lipgloss.Color(value)
+	}
+}
+
+func usePalette(colors palette) {
+	primaryColor = colors.header
+	headerTextColor = colors.headerText
+	accentColor = colors.accent
+	secondaryColor = colors.secondary
+	warningColor = colors.warning
+	errorColor = colors.err
+	mutedColor = colors.muted
+	textColor = colors.text
+}
+
+// noColor reports whether the terminal gets no colors, because NO_COLOR is
+// set or it does not support them. lipgloss then leaves out all styling, and
+// selections stay visible through their markers.
+func noColor() bool {
+	return lipgloss.ColorProfile() == termenv.Ascii
+}
@@ -0,0 +1,123 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// AddressFamilies are the accepted values of a host's AddressFamily; empty
+// means either
+var AddressFamilies = []string{domain.FamilyInet, domain.FamilyInet6}
+
+// IsIPLiteral reports whether host is an IPv4 or IPv6 address, including
+// IPv6 addresses with a zone such as fe80::1%eth0
+func IsIPLiteral(host string) bool {
+	_, err := netip.ParseAddr(host)
+	return err == nil
+}
+
+// IsIPv6Literal reports whether host is an IPv6 address, with or without a zone
+func IsIPv6Literal(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.Is6() && !addr.Is4In6()
+}
+
+// CheckAddressFamily returns an error unless family is empty, inet or inet6
+func CheckAddressFamily(family string) error {
+	if family == "" {
+		return nil
+	}
+	for _, f := range AddressFamilies {
+		if family == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown address family %q (available: %s)", family, strings.Join(AddressFamilies, ", "))
+}
+
+// Network returns the network to dial for an address family: tcp4, tcp6 or tcp
+func Network(family string) string {
+	switch family {
+	case domain.FamilyInet:
+		return "tcp4"
+	case domain.FamilyInet6:
+		return "tcp6"
+	}
+	return "tcp"
+}
+
+// ResolveAddress returns an IP address of hostname in the given family.
+// With no family, the resolver's preferred address is used.
+func ResolveAddress(hostname, family string) (string, error) {
+	if addr, err := netip.ParseAddr(hostname); err == nil {
+		if !familyMatches(addr, family) {
+			return "", fmt.Errorf("%s is not an %s address", hostname, family)
+		}
+		return hostname, nil
+	}
+
+	ips, err := net.LookupIP(hostname)
+	if err != nil {
+		return "", err
+	}
+	var addresses []string
+	for _, ip := range ips {
+		addresses = append(addresses, ip.String())
+	}
+	if address := PickAddress(addresses, family); address != "" {
+		return address, nil
+	}
+	if family == "" {
+		return "", fmt.Errorf("%s has no address", hostname)
+	}
+	return "", fmt.Errorf("%s has no %s address", hostname, family)
+}
+
+// PickAddress returns the first of addresses in the given family, or "" if
+// there is none. IPv4-mapped IPv6 addresses count as IPv4.
+func PickAddress(addresses []string, family string) string {
+	for _, address := range addresses {
+		if addr, err := netip.ParseAddr(address); err == nil && familyMatches(addr.Unmap(), family) {
+			return addr.Unmap().String()
+		}
+	}
+	return ""
+}
+
+// familyMatches reports whether addr belongs to family; any address
+// matches an empty family
+func familyMatches(addr netip.Addr, family string) bool {
+	switch family {
+	case domain.FamilyInet:
+		return addr.Unmap().Is4()
+	case domain.FamilyInet6:
+		return addr.Is6() && !addr.Is4In6()
+	}
+	return true
+}
+
+// SplitKnownHostsPattern splits a known_hosts host pattern, "host" or
+// "[host]:port", into host and port. Plain IPv6 addresses are hosts on
+// port 22.
+func SplitKnownHostsPattern(pattern string) (string, int) {
+	if !strings.HasPrefix(pattern, "[") {
+		return pattern, 22
+	}
+	end := strings.Index(pattern, "]")
+	if end < 0 {
+		return "", 0
+	}
+	host := pattern[1:end]
+	port := 22
+	if rest := pattern[end+1:]; strings.HasPrefix(rest, ":") {
+		if p, err := strconv.Atoi(rest[1:]); err == nil && p > 0 && p <= 65535 {
+			port = p
+		}
+	}
+	return host, port
+}
@@ -0,0 +1,108 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrNoAgent is returned when no ssh-agent can be reached through SSH_AUTH_SOCK
+var ErrNoAgent = errors.New("no ssh-agent available (SSH_AUTH_SOCK is not set)")
+
+// AgentKey is an identity loaded in ssh-agent
+type AgentKey struct {
+	Type        string
+	Bits        int
+	Fingerprint string
+	Comment     string
+}
+
+// dialAgent connects to the agent named by SSH_AUTH_SOCK
+func dialAgent() (net.Conn, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, ErrNoAgent
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach ssh-agent at %s: %w", socket, err)
+	}
+	return conn, nil
+}
+
+// AgentKeys lists the identities loaded in ssh-agent
+func AgentKeys() ([]*AgentKey, error) {
+	conn, err := dialAgent()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	identities, err := agent.NewClient(conn).List()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list ssh-agent keys: %w", err)
+	}
+
+	var keys []*AgentKey
+	for _, identity := range identities {
+		pub, err := gossh.ParsePublicKey(identity.Blob)
+		if err != nil {
+			continue
+		}
+		key := &AgentKey{Fingerprint: gossh.FingerprintSHA256(pub), Comment: identity.Comment}
+		key.Type, key.Bits = describePublicKey(pub)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// AgentFingerprints returns the set of fingerprints loaded in ssh-agent
+func AgentFingerprints() (map[string]bool, error) {
+	keys, err := AgentKeys()
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[string]bool)
+	for _, key := range keys {
+		loaded[key.Fingerprint] = true
+	}
+	return loaded, nil
+}
+
+// AddToAgentCommand returns the ssh-add command that loads a key. A zero
+// lifetime keeps the key until the agent exits; confirm makes the agent ask
+// before each use.
+func AddToAgentCommand(keyPath string, lifetime time.Duration, confirm bool) *exec.Cmd {
+	var args []string
+	if lifetime > 0 {
+		args = append(args, "-t", strconv.Itoa(int(lifetime.Seconds())))
+	}
+	if confirm {
+		args = append(args, "-c")
+	}
+	args = append(args, keyPath)
+	return exec.Command("ssh-add", args...)
+}
+
+// AddToAgent loads a key into ssh-agent, prompting for its passphrase if needed
+func AddToAgent(keyPath string, lifetime time.Duration, confirm bool) error {
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		return ErrNoAgent
+	}
+
+	cmd := AddToAgentCommand(keyPath, lifetime, confirm)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ssh-add failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+const (
+	// ConnectorSystem runs the ssh binary found in PATH
+	ConnectorSystem = "system"
+	// ConnectorNative connects in-process with golang.org/x/crypto/ssh
+	ConnectorNative = "native"
+)
+
+// Connectors are the available SSH client backends
+var Connectors = []string{ConnectorSystem, ConnectorNative}
+
+// Connector opens SSH connections to hosts. Hosts passed in must have their
+// ProxyJump already resolved to ssh's [user@]host[:port] form.
+type Connector interface {
+	// Connect opens an interactive shell on the host. A non-nil recorder
+	// receives everything shown in the terminal.
+	Connect(host *domain.Host, rec Recorder) error
+	// Run executes a command on the host, writing its output to stdout.
+	// The user may be prompted for a password.
+	Run(host *domain.Host, command string, stdout io.Writer) error
+	// Test checks that the host accepts a login without any prompt
+	Test(host *domain.Host) error
+	// TestKey checks that the host accepts keyPath on its own, without a password
+	TestKey(host *domain.Host, keyPath string) error
+}
+
+// NewConnector returns the named connector
+func NewConnector(name string) (Connector, error) {
+	switch name {
+	case ConnectorSystem, "":
+		return SystemConnector{}, nil
+	case ConnectorNative:
+		return NewNativeConnector(), nil
+	}
+	return nil, fmt.Errorf("unknown connector %q (available: %s)", name, strings.Join(Connectors, ", "))
+}
+
+// SystemConnector shells out to the system ssh client, so ~/.ssh/config and
+// all of OpenSSH's options apply
+type SystemConnector struct{}
+
+func (SystemConnector) Connect(host *domain.Host, rec Recorder) error {
+	if rec == nil {
+		return ConnectToHost(host)
+	}
+	return connectRecorded(buildSSHArgs(host), rec)
+}
+
+func (SystemConnector) Run(host *domain.Host, command string, stdout io.Writer) error {
+	args := append(buildSSHArgs(host), command)
+
+	cmd := exec.Command("ssh", args...)
+	cmd.Stdin = os.Stdin // Allow password prompts
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (SystemConnector) Test(host *domain.Host) error {
+	return TestConnection(host)
+}
+
+func (SystemConnector) TestKey(host *domain.Host, keyPath string) error {
+	keyHost := *host
+	keyHost.KeyPath = keyPath
+
+	args := buildSSHArgs(&keyHost)
+	args = append(args,
+		"-o", "ConnectTimeout=5",
+		"-o", "BatchMode=yes",
+		"-o", "IdentitiesOnly=yes",
+		"-o", "PasswordAuthentication=no",
+		"-o", "KbdInteractiveAuthentication=no",
+		"exit")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("key-based login failed: %s", msg)
+		}
+		return fmt.Errorf("key-based login failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+)
+
+// DeployPublicKey appends publicKey to ~/.ssh/authorized_keys on the host.
+// It is idempotent: a key that is already authorized is left alone. The
+// connection uses the host's current authentication, so the user may be
+// prompted for a password. Returns true if the key was added.
+func DeployPublicKey(conn Connector, host *domain.Host, publicKey string) (bool, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return false, fmt.Errorf("invalid public key")
+	}
+	keyLine := strings.Join(fields, " ")
+	keyMatch := fields[0] + " " + fields[1] // Ignore comments when checking for an existing entry
+
+	script := strings.Join([]string{
+		"umask 077",
+		"mkdir -p ~/.ssh && chmod 700 ~/.ssh",
+		"touch ~/.ssh/authorized_keys && chmod 600 ~/.ssh/authorized_keys",
+		"if grep -qF " + shellQuote(keyMatch) + " ~/.ssh/authorized_keys; then echo sshm:present; else " +
+			`if [ -s ~/.ssh/authorized_keys ] && [ "$(tail -c1 ~/.ssh/authorized_keys)" != "" ]; then echo >> ~/.ssh/authorized_keys; fi; ` +
+			"echo " + shellQuote(keyLine) + " >> ~/.ssh/authorized_keys && echo sshm:added; fi",
+	}, "; ")
+
+	var stdout bytes.Buffer
+	if err := conn.Run(host, script, &stdout); err != nil {
+		return false, fmt.Errorf("failed to update authorized_keys: %w", err)
+	}
+
+	output := stdout.String()
+	switch {
+	case strings.Contains(output, "sshm:added"):
+		return true, nil
+	case strings.Contains(output, "sshm:present"):
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected output from remote host: %s", strings.TrimSpace(output))
+}
+
+// shellQuote quotes s for safe use in a POSIX shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,321 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// KeyInfo describes an SSH key pair found on disk
+type KeyInfo struct {
+	Name          string `json:"name"`            // File name of the private key (or public key if no private key)
+	Path          string `json:"path"`            // Private key path, empty for public-only keys
+	PublicKeyPath string `json:"public_key_path"` // Public key path, empty if no .pub file
+	Type          string `json:"type"`            // ed25519, rsa, ecdsa, dsa
+	Bits          int    `json:"bits"`
+	Comment       string `json:"comment"`
+	Fingerprint   string `json:"fingerprint"` // SHA256:...
+	Encrypted     bool   `json:"encrypted"`   // Private key is passphrase-protected
+}
+
+// HasPrivate reports whether the private half of the key is present
+func (k *KeyInfo) HasPrivate() bool {
+	return k.Path != ""
+}
+
+// HasPublic reports whether the public half of the key is present
+func (k *KeyInfo) HasPublic() bool {
+	return k.PublicKeyPath != ""
+}
+
+// IdentityPath returns the path that should be passed to ssh -i
+func (k *KeyInfo) IdentityPath() string {
+	if k.Path != "" {
+		return k.Path
+	}
+	return k.PublicKeyPath
+}
+
+// GetDefaultKeyDir returns ~/.ssh
+func GetDefaultKeyDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".ssh"
+	}
+	return filepath.Join(homeDir, ".ssh")
+}
+
+// ExpandPath expands a leading ~ and cleans the path so key paths can be compared
+func ExpandPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Clean(path)
+}
+
+// ScanKeys finds private and public key pairs in dir (non-recursive)
+func ScanKeys(dir string) ([]*KeyInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read key directory: %w", err)
+	}
+
+	var keys []*KeyInfo
+	seenPublic := make(map[string]bool)
+
+	// Private keys first, pairing each with its .pub file
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".pub") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if !isPrivateKeyFile(path) {
+			continue
+		}
+		info, err := InspectKey(path)
+		if err != nil {
+			continue // Unreadable or unsupported key, skip it
+		}
+		if info.PublicKeyPath != "" {
+			seenPublic[info.PublicKeyPath] = true
+		}
+		keys = append(keys, info)
+	}
+
+	// Then public keys whose private half is missing
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".pub") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if seenPublic[path] {
+			continue
+		}
+		info, err := InspectKey(path)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, info)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+// InspectKey parses a private or public key file and its counterpart if present
+func InspectKey(path string) (*KeyInfo, error) {
+	path = ExpandPath(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file: %w", err)
+	}
+
+	info := &KeyInfo{Name: filepath.Base(path)}
+
+	if strings.HasSuffix(path, ".pub") {
+		info.PublicKeyPath = path
+		if err := info.applyPublicKey(data); err != nil {
+			return nil, err
+		}
+		privatePath := strings.TrimSuffix(path, ".pub")
+		if isPrivateKeyFile(privatePath) {
+			info.Path = privatePath
+			info.Name = filepath.Base(privatePath)
+			info.Encrypted = isEncryptedPrivateKey(privatePath)
+		}
+		return info, nil
+	}
+
+	info.Path = path
+	pub, encrypted, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	info.Encrypted = encrypted
+	if pub != nil {
+		info.applyKeyDetails(pub)
+	}
+
+	// The .pub file provides the comment and, for encrypted legacy PEM keys, the key itself
+	if pubData, err := os.ReadFile(path + ".pub"); err == nil {
+		info.PublicKeyPath = path + ".pub"
+		if pub == nil {
+			if err := info.applyPublicKey(pubData); err != nil {
+				return nil, err
+			}
+		} else if _, comment, _, _, err := gossh.ParseAuthorizedKey(pubData); err == nil {
+			info.Comment = comment
+		}
+	}
+
+	return info, nil
+}
+
+// ReadPublicKey returns the authorized_keys line for a key
+func (k *KeyInfo) ReadPublicKey() (string, error) {
+	if k.PublicKeyPath != "" {
+		data, err := os.ReadFile(k.PublicKeyPath)
+		if err != nil {
+			return "", fmt.Errorf("cannot read public key: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	data, err := os.ReadFile(k.Path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read private key: %w", err)
+	}
+	pub, _, err := parsePrivateKey(data)
+	if err != nil {
+		return "", err
+	}
+	if pub == nil {
+		return "", fmt.Errorf("public key for %s is not available without its .pub file", k.Name)
+	}
+	line := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(pub)))
+	if k.Comment != "" {
+		line += " " + k.Comment
+	}
+	return line, nil
+}
+
+// GenerateEd25519Key writes a new ed25519 key pair to path and path.pub
+func GenerateEd25519Key(path, comment, passphrase string) (*KeyInfo, error) {
+	path = ExpandPath(path)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("key file already exists: %s", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = gossh.MarshalPrivateKeyWithPassphrase(priv, comment, []byte(passphrase))
+	} else {
+		block, err = gossh.MarshalPrivateKey(priv, comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	sshPub, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	pubLine := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(sshPub)))
+	if comment != "" {
+		pubLine += " " + comment
+	}
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := os.WriteFile(path+".pub", []byte(pubLine+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	return InspectKey(path)
+}
+
+func (k *KeyInfo) applyPublicKey(data []byte) error {
+	pub, comment, _, _, err := gossh.ParseAuthorizedKey(data)
+	if err != nil {
+		return fmt.Errorf("cannot parse public key: %w", err)
+	}
+	k.Comment = comment
+	k.applyKeyDetails(pub)
+	return nil
+}
+
+func (k *KeyInfo) applyKeyDetails(pub gossh.PublicKey) {
+	k.Fingerprint = gossh.FingerprintSHA256(pub)
+	k.Type, k.Bits = describePublicKey(pub)
+}
+
+// describePublicKey returns a short algorithm name and key size
+func describePublicKey(pub gossh.PublicKey) (string, int) {
+	cryptoKey, ok := pub.(gossh.CryptoPublicKey)
+	if !ok {
+		return strings.TrimPrefix(pub.Type(), "ssh-"), 0
+	}
+
+	switch key := cryptoKey.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return "rsa", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ecdsa", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "ed25519", 256
+	case *dsa.PublicKey:
+		return "dsa", key.P.BitLen()
+	}
+
+	return strings.TrimPrefix(pub.Type(), "ssh-"), 0
+}
+
+// parsePrivateKey returns the public half of a private key and whether it is encrypted.
+// The public key is nil for encrypted keys in formats that do not expose it.
+func parsePrivateKey(data []byte) (gossh.PublicKey, bool, error) {
+	signer, err := gossh.ParsePrivateKey(data)
+	if err == nil {
+		return signer.PublicKey(), false, nil
+	}
+
+	var missing *gossh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return missing.PublicKey, true, nil
+	}
+
+	return nil, false, fmt.Errorf("cannot parse private key: %w", err)
+}
+
+func isPrivateKeyFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, 64)
+	n, _ := file.Read(header)
+	header = header[:n]
+	return bytes.HasPrefix(header, []byte("-----BEGIN ")) && bytes.Contains(header, []byte("PRIVATE KEY"))
+}
+
+func isEncryptedPrivateKey(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	_, encrypted, _ := parsePrivateKey(data)
+	return encrypted
+}
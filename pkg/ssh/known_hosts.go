@@ -2,76 +2,170 @@ package ssh
 
 import (
 	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-// RemoveFromKnownHosts removes a host from ~/.ssh/known_hosts file
-func RemoveFromKnownHosts(hostname string, port int) error {
+// KnownHostsEntry is a single parsed line of a known_hosts file
+type KnownHostsEntry struct {
+	Line     int      // 1-based line number
+	Marker   string   // @cert-authority or @revoked, if present
+	Patterns []string // Host patterns, e.g. example.com or [example.com]:2222
+	KeyType  string
+	Key      string
+	Hashed   bool
+}
+
+// GetKnownHostsPath returns the path of the user's known_hosts file
+func GetKnownHostsPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("cannot access home directory: %v", err)
-	}
-
-	knownHostsPath := filepath.Join(homeDir, ".ssh", "known_hosts")
-	if _, err := os.Stat(knownHostsPath); err != nil {
-		return nil // File doesn't exist, nothing to remove
+		return "", fmt.Errorf("cannot access home directory: %v", err)
 	}
+	return filepath.Join(homeDir, ".ssh", "known_hosts"), nil
+}
 
-	// Read the file
-	file, err := os.Open(knownHostsPath)
+// ReadKnownHosts parses a known_hosts file. Lines that cannot be parsed are
+// returned by number in malformed rather than failing the whole read.
+func ReadKnownHosts(path string) (entries []KnownHostsEntry, malformed []int, err error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("cannot open known_hosts: %v", err)
+		return nil, nil, err
 	}
 	defer file.Close()
 
-	var lines []string
 	scanner := bufio.NewScanner(file)
-
-	// Create patterns to match the hostname
-	patterns := []string{
-		hostname,                               // hostname
-		fmt.Sprintf("[%s]:%d", hostname, port), // [hostname]:port
-	}
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		shouldRemove := false
-
-		// Check if this line contains our hostname
-		for _, pattern := range patterns {
-			if strings.Contains(line, pattern) {
-				shouldRemove = true
-				break
-			}
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
 
-		// Keep the line if it doesn't match our hostname
-		if !shouldRemove {
-			lines = append(lines, line)
+		fields := strings.Fields(line)
+		entry := KnownHostsEntry{Line: lineNumber}
+		if strings.HasPrefix(fields[0], "@") {
+			entry.Marker = fields[0]
+			fields = fields[1:]
+		}
+		if len(fields) < 3 {
+			malformed = append(malformed, lineNumber)
+			continue
 		}
+
+		entry.Patterns = strings.Split(fields[0], ",")
+		entry.Hashed = strings.HasPrefix(fields[0], "|1|")
+		entry.KeyType = fields[1]
+		entry.Key = fields[2]
+		entries = append(entries, entry)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading known_hosts: %v", err)
+		return nil, nil, fmt.Errorf("error reading known_hosts: %v", err)
 	}
 
-	// Write back to file
-	file.Close() // Close the read handle
+	return entries, malformed, nil
+}
+
+// KnownHostsPattern returns the known_hosts pattern ssh uses for a host and port
+func KnownHostsPattern(hostname string, port int) string {
+	if port == 0 || port == 22 {
+		return hostname
+	}
+	return fmt.Sprintf("[%s]:%d", hostname, port)
+}
 
-	writeFile, err := os.Create(knownHostsPath)
+// RemoveFromKnownHosts removes a host from ~/.ssh/known_hosts file. Lines
+// naming the host exactly, as hostname or [hostname]:port, are removed,
+// hashed ones included; other hosts sharing a prefix, such as 10.0.0.10
+// for 10.0.0.1, are kept.
+func RemoveFromKnownHosts(hostname string, port int) error {
+	knownHostsPath, err := GetKnownHostsPath()
 	if err != nil {
-		return fmt.Errorf("cannot write to known_hosts: %v", err)
+		return err
+	}
+
+	data, err := os.ReadFile(knownHostsPath)
+	if os.IsNotExist(err) {
+		return nil // File doesn't exist, nothing to remove
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open known_hosts: %v", err)
 	}
-	defer writeFile.Close()
 
-	for _, line := range lines {
-		if _, err := writeFile.WriteString(line + "\n"); err != nil {
-			return fmt.Errorf("error writing to known_hosts: %v", err)
+	var kept []string
+	removed := false
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line != "" && knownHostsLineMatches(line, hostname, port) {
+			removed = true
+			continue
 		}
+		kept = append(kept, line)
+	}
+	if !removed {
+		return nil
 	}
 
+	info, err := os.Stat(knownHostsPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(knownHostsPath, []byte(strings.Join(kept, "")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("cannot write to known_hosts: %v", err)
+	}
 	return nil
 }
+
+// knownHostsLineMatches reports whether a known_hosts line lists the host
+func knownHostsLineMatches(line, hostname string, port int) bool {
+	fields := strings.Fields(line)
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+		return false
+	}
+
+	want := KnownHostsPattern(hostname, port)
+	for _, pattern := range strings.Split(fields[0], ",") {
+		if strings.HasPrefix(pattern, "|1|") {
+			if hashedPatternMatches(pattern, want) {
+				return true
+			}
+			continue
+		}
+		host, p := SplitKnownHostsPattern(pattern)
+		if strings.EqualFold(host, hostname) && (p == port || port == 0 && p == 22) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashedPatternMatches checks a HashKnownHosts entry, |1|salt|hash, where
+// hash is the HMAC-SHA1 of the host pattern keyed with salt
+func hashedPatternMatches(hashed, pattern string) bool {
+	parts := strings.Split(hashed, "|")
+	if len(parts) != 4 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	sum, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(pattern))
+	return hmac.Equal(mac.Sum(nil), sum)
+}
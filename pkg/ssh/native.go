@@ -0,0 +1,715 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/levanduy/ssh_management/internal/domain"
+	"github.com/muesli/cancelreader"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+)
+
+// Reasons a host key is rejected
+const (
+	HostKeyUnknown = "unknown"
+	HostKeyChanged = "changed"
+	HostKeyRevoked = "revoked"
+)
+
+// defaultIdentities are tried, like ssh does, when a host has no key configured
+var defaultIdentities = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// HostKeyError reports a host key that is not accepted by known_hosts
+type HostKeyError struct {
+	Host        string // known_hosts form, e.g. [example.com]:2222
+	Fingerprint string
+	Reason      string
+}
+
+func (e *HostKeyError) Error() string {
+	switch e.Reason {
+	case HostKeyChanged:
+		return fmt.Sprintf("host key for %s has CHANGED (now %s); someone may be intercepting the connection. "+
+			"If the change is expected, remove the old key from known_hosts", e.Host, e.Fingerprint)
+	case HostKeyRevoked:
+		return fmt.Sprintf("host key %s for %s is revoked in known_hosts", e.Fingerprint, e.Host)
+	}
+	return fmt.Sprintf("host key for %s is not in known_hosts (%s)", e.Host, e.Fingerprint)
+}
+
+// AuthError reports that the server rejected every authentication method
+type AuthError struct {
+	User string
+	Host string
+	Err  error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed for %s@%s: %v", e.User, e.Host, e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// NativeConnector is an SSH client built on golang.org/x/crypto/ssh. It
+// authenticates with ssh-agent, the host's key or the default identities and
+// finally a password, and verifies host keys against ~/.ssh/known_hosts.
+// ~/.ssh/config is not read.
+type NativeConnector struct {
+	Timeout           time.Duration // Connection and handshake timeout per hop
+	KeepaliveInterval time.Duration // Zero disables keepalives
+	KeepaliveMax      int           // Unanswered keepalives before the connection is dropped
+	KnownHostsPath    string
+}
+
+// NewNativeConnector returns a native connector with ssh-like defaults
+func NewNativeConnector() *NativeConnector {
+	knownHosts, _ := GetKnownHostsPath()
+	return &NativeConnector{
+		Timeout:           10 * time.Second,
+		KeepaliveInterval: 30 * time.Second,
+		KeepaliveMax:      3,
+		KnownHostsPath:    knownHosts,
+	}
+}
+
+// dialOptions controls how a connection may authenticate
+type dialOptions struct {
+	interactive bool   // May prompt for passwords, passphrases and unknown host keys
+	onlyKey     string // Authenticate with this key alone
+}
+
+// endpoint is one hop of a connection
+type endpoint struct {
+	user           string
+	host           string
+	port           int
+	keyPath        string
+	identitiesOnly bool
+	family         string // Address family to dial, empty for either
+}
+
+func (e endpoint) address() string {
+	return net.JoinHostPort(e.host, strconv.Itoa(e.port))
+}
+
+// connection is a client to the target host and the jump hosts it runs through
+type connection struct {
+	client *gossh.Client
+	hops   []*gossh.Client
+	agent  net.Conn
+}
+
+func (c *connection) Close() {
+	if c.client != nil {
+		c.client.Close()
+	}
+	for i := len(c.hops) - 1; i >= 0; i-- {
+		c.hops[i].Close()
+	}
+	if c.agent != nil {
+		c.agent.Close()
+	}
+}
+
+func (c *NativeConnector) Connect(host *domain.Host, rec Recorder) error {
+	conn, err := c.dial(host, dialOptions{interactive: true})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	session, err := conn.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("cannot open session: %w", err)
+	}
+	defer session.Close()
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		termType := os.Getenv("TERM")
+		if termType == "" {
+			termType = "xterm-256color"
+		}
+		modes := gossh.TerminalModes{
+			gossh.ECHO:          1,
+			gossh.TTY_OP_ISPEED: 14400,
+			gossh.TTY_OP_OSPEED: 14400,
+		}
+		if err := session.RequestPty(termType, height, width, modes); err != nil {
+			return fmt.Errorf("cannot allocate terminal: %w", err)
+		}
+
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("cannot switch terminal to raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+
+		stop := watchWindowSize(fd, func(width, height int) {
+			session.WindowChange(height, width)
+			if rec != nil {
+				rec.Resize(width, height)
+			}
+		})
+		defer stop()
+	}
+
+	if host.ForwardAgent {
+		if err := forwardAgent(conn.client, session); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: agent forwarding unavailable: %v\r\n", err)
+		}
+	}
+
+	// The session keeps reading stdin after the shell exits; cancel the read
+	// so the next keystroke reaches whatever runs next, such as the TUI
+	stdin, err := cancelreader.NewReader(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("cannot read the terminal: %w", err)
+	}
+	defer stdin.Close()
+	defer stdin.Cancel()
+
+	session.Stdin = stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+	if rec != nil {
+		session.Stdout = io.MultiWriter(os.Stdout, rec)
+		session.Stderr = io.MultiWriter(os.Stderr, rec)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go c.keepalive(conn.client, done)
+
+	if err := session.Shell(); err != nil {
+		return fmt.Errorf("cannot start shell: %w", err)
+	}
+	return sessionError(host, session.Wait())
+}
+
+func (c *NativeConnector) Run(host *domain.Host, command string, stdout io.Writer) error {
+	conn, err := c.dial(host, dialOptions{interactive: true})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	session, err := conn.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("cannot open session: %w", err)
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = os.Stderr
+	return sessionError(host, session.Run(command))
+}
+
+func (c *NativeConnector) Test(host *domain.Host) error {
+	conn, err := c.dial(host, dialOptions{})
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+func (c *NativeConnector) TestKey(host *domain.Host, keyPath string) error {
+	conn, err := c.dial(host, dialOptions{onlyKey: keyPath})
+	if err != nil {
+		return fmt.Errorf("key-based login failed: %w", err)
+	}
+	conn.Close()
+	return nil
+}
+
+// dial connects to the host, hopping through its ProxyJump hosts in order
+func (c *NativeConnector) dial(host *domain.Host, opts dialOptions) (*connection, error) {
+	var targets []endpoint
+	if host.ProxyJump != "" {
+		for _, spec := range strings.Split(host.ProxyJump, ",") {
+			hop, err := parseJumpHop(strings.TrimSpace(spec), host.Username)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, hop)
+		}
+	}
+	targets = append(targets, endpoint{
+		user:           host.Username,
+		host:           host.Hostname,
+		port:           host.Port,
+		keyPath:        host.KeyPath,
+		identitiesOnly: host.IdentitiesOnly && host.KeyPath != "",
+	})
+	for i := range targets {
+		targets[i].family = host.AddressFamily // Like ssh -4 and -6, for every hop
+	}
+
+	conn := &connection{}
+	if opts.onlyKey == "" {
+		// A missing agent is not an error; other methods are still tried
+		if agentConn, err := dialAgent(); err == nil {
+			conn.agent = agentConn
+		}
+	}
+
+	var via *gossh.Client
+	for i, target := range targets {
+		hopOpts := opts
+		isJump := i < len(targets)-1
+		if isJump {
+			// Jump hosts use the agent and default identities
+			target.keyPath = ""
+			target.identitiesOnly = false
+			hopOpts.onlyKey = ""
+		}
+
+		client, err := c.connect(via, target, hopOpts, conn.agent)
+		if err != nil {
+			conn.Close()
+			if isJump {
+				return nil, fmt.Errorf("jump host %s: %w", target.address(), err)
+			}
+			return nil, err
+		}
+		if isJump {
+			conn.hops = append(conn.hops, client)
+		} else {
+			conn.client = client
+		}
+		via = client
+	}
+	return conn, nil
+}
+
+// connect opens an SSH client to target, directly or through via
+func (c *NativeConnector) connect(via *gossh.Client, target endpoint, opts dialOptions, agentConn net.Conn) (*gossh.Client, error) {
+	hostKeyCallback, algorithms, err := c.hostKeyCallback(target.address(), opts.interactive)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &gossh.ClientConfig{
+		User:              target.user,
+		Auth:              c.authMethods(target, opts, agentConn),
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: algorithms,
+		Timeout:           c.Timeout,
+	}
+
+	var tcp net.Conn
+	if via == nil {
+		tcp, err = net.DialTimeout(Network(target.family), target.address(), c.Timeout)
+	} else {
+		tcp, err = via.Dial("tcp", target.address())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", target.address(), err)
+	}
+
+	// The handshake has no timeout of its own; prompts are excluded by only
+	// setting a deadline for non-interactive connections
+	if !opts.interactive && c.Timeout > 0 {
+		tcp.SetDeadline(time.Now().Add(c.Timeout))
+	}
+	sshConn, chans, reqs, err := gossh.NewClientConn(tcp, target.address(), config)
+	if err != nil {
+		tcp.Close()
+		var keyErr *HostKeyError
+		if errors.As(err, &keyErr) {
+			return nil, keyErr
+		}
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return nil, &AuthError{User: target.user, Host: target.address(), Err: err}
+		}
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", target.address(), err)
+	}
+	tcp.SetDeadline(time.Time{})
+
+	return gossh.NewClient(sshConn, chans, reqs), nil
+}
+
+// authMethods returns publickey (agent and key files), then password and
+// keyboard-interactive methods when prompting is allowed
+func (c *NativeConnector) authMethods(target endpoint, opts dialOptions, agentConn net.Conn) []gossh.AuthMethod {
+	// The client tries each method type once, so all keys share one publickey method
+	publicKeys := gossh.PublicKeysCallback(func() ([]gossh.Signer, error) {
+		var signers []gossh.Signer
+		if opts.onlyKey != "" {
+			signer, err := loadSigner(opts.onlyKey, false)
+			if err != nil {
+				return nil, err
+			}
+			return []gossh.Signer{signer}, nil
+		}
+
+		paths := []string{target.keyPath}
+		if target.keyPath == "" {
+			paths = nil
+			for _, name := range defaultIdentities {
+				paths = append(paths, filepath.Join(GetDefaultKeyDir(), name))
+			}
+		}
+		var fileSigners []gossh.Signer
+		for _, path := range paths {
+			if signer, err := loadSigner(path, opts.interactive); err == nil {
+				fileSigners = append(fileSigners, signer)
+			}
+		}
+
+		// Agent keys go first so an encrypted key file is only unlocked when
+		// the agent does not hold it. With IdentitiesOnly, only agent copies
+		// of the host's own key are offered, which avoids "too many
+		// authentication failures" from servers with a low MaxAuthTries.
+		if agentConn != nil {
+			if agentSigners, err := agent.NewClient(agentConn).Signers(); err == nil {
+				for _, signer := range agentSigners {
+					if !target.identitiesOnly || containsKey(fileSigners, signer.PublicKey()) {
+						signers = append(signers, signer)
+					}
+				}
+			}
+		}
+		return append(signers, fileSigners...), nil
+	})
+
+	methods := []gossh.AuthMethod{publicKeys}
+	if opts.interactive && opts.onlyKey == "" {
+		prompt := fmt.Sprintf("%s@%s's password: ", target.user, target.host)
+		methods = append(methods,
+			gossh.RetryableAuthMethod(gossh.PasswordCallback(func() (string, error) {
+				return readPassword(prompt)
+			}), 3),
+			gossh.RetryableAuthMethod(gossh.KeyboardInteractive(keyboardInteractive), 3),
+		)
+	}
+	return methods
+}
+
+// hostKeyCallback verifies host keys against known_hosts. Unknown keys are
+// offered for confirmation and saved when prompting is allowed. It also
+// returns the algorithms of the keys already known for addr, so the server
+// presents one of those rather than a different, unknown type.
+func (c *NativeConnector) hostKeyCallback(addr string, interactive bool) (gossh.HostKeyCallback, []string, error) {
+	if err := ensureFile(c.KnownHostsPath); err != nil {
+		return nil, nil, fmt.Errorf("cannot create known_hosts: %w", err)
+	}
+	check, err := knownhosts.New(c.KnownHostsPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read known_hosts: %w", err)
+	}
+
+	callback := func(hostname string, remote net.Addr, key gossh.PublicKey) error {
+		err := check(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		keyErr := &HostKeyError{Host: knownhosts.Normalize(hostname), Fingerprint: gossh.FingerprintSHA256(key)}
+		var revoked *knownhosts.RevokedError
+		if errors.As(err, &revoked) {
+			keyErr.Reason = HostKeyRevoked
+			return keyErr
+		}
+		var mismatch *knownhosts.KeyError
+		if !errors.As(err, &mismatch) {
+			return err
+		}
+		if len(mismatch.Want) > 0 {
+			keyErr.Reason = HostKeyChanged
+			return keyErr
+		}
+
+		keyErr.Reason = HostKeyUnknown
+		if !interactive {
+			return keyErr
+		}
+		fmt.Fprintf(os.Stderr, "The authenticity of host '%s' can't be established.\n", keyErr.Host)
+		fmt.Fprintf(os.Stderr, "%s key fingerprint is %s.\n", key.Type(), keyErr.Fingerprint)
+		answer, err := readLine("Are you sure you want to continue connecting (yes/no)? ")
+		if err != nil || answer != "yes" {
+			return keyErr
+		}
+		return appendKnownHost(c.KnownHostsPath, keyErr.Host, key)
+	}
+
+	return callback, knownAlgorithms(check, addr), nil
+}
+
+// keepalive pings the server and closes the client once KeepaliveMax pings
+// in a row go unanswered, so a dead connection does not hang the terminal
+func (c *NativeConnector) keepalive(client *gossh.Client, done <-chan struct{}) {
+	if c.KeepaliveInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.KeepaliveInterval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+
+		select {
+		case <-done:
+			return
+		case err := <-reply:
+			if err != nil {
+				missed++
+			} else {
+				missed = 0
+			}
+		case <-time.After(c.KeepaliveInterval):
+			missed++
+		}
+
+		if missed >= c.KeepaliveMax {
+			client.Close()
+			return
+		}
+	}
+}
+
+// forwardAgent makes the local ssh-agent available to the remote session
+func forwardAgent(client *gossh.Client, session *gossh.Session) error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return ErrNoAgent
+	}
+	if err := agent.ForwardToRemote(client, socket); err != nil {
+		return err
+	}
+	return agent.RequestAgentForwarding(session)
+}
+
+// parseJumpHop parses one [user@]host[:port] ProxyJump entry
+func parseJumpHop(spec, defaultUser string) (endpoint, error) {
+	hop := endpoint{user: defaultUser, port: 22}
+	if at := strings.LastIndex(spec, "@"); at >= 0 {
+		hop.user, spec = spec[:at], spec[at+1:]
+	}
+
+	host, port, err := net.SplitHostPort(spec)
+	if err != nil {
+		// No port; a bracketed IPv6 address loses its brackets
+		host = strings.TrimSuffix(strings.TrimPrefix(spec, "["), "]")
+		port = ""
+	}
+	if host == "" {
+		return endpoint{}, fmt.Errorf("invalid jump host %q", spec)
+	}
+	hop.host = host
+	if port != "" {
+		if hop.port, err = strconv.Atoi(port); err != nil || hop.port < 1 || hop.port > 65535 {
+			return endpoint{}, fmt.Errorf("invalid port in jump host %q", spec)
+		}
+	}
+	return hop, nil
+}
+
+// loadSigner reads a private key. Encrypted keys are only usable when
+// prompting is allowed; the passphrase is asked for when the server accepts
+// the key, not before.
+func loadSigner(path string, interactive bool) (gossh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := gossh.ParsePrivateKey(data)
+	if err == nil {
+		return signer, nil
+	}
+	var missing *gossh.PassphraseMissingError
+	if !errors.As(err, &missing) || missing.PublicKey == nil {
+		return nil, fmt.Errorf("cannot use key %s: %w", path, err)
+	}
+	if !interactive {
+		return nil, fmt.Errorf("key %s is encrypted and needs a passphrase", path)
+	}
+	return &passphraseSigner{path: path, data: data, public: missing.PublicKey}, nil
+}
+
+// passphraseSigner decrypts an encrypted key the first time it is used
+type passphraseSigner struct {
+	path   string
+	data   []byte
+	public gossh.PublicKey
+	signer gossh.Signer
+}
+
+func (s *passphraseSigner) PublicKey() gossh.PublicKey {
+	return s.public
+}
+
+func (s *passphraseSigner) Sign(rand io.Reader, data []byte) (*gossh.Signature, error) {
+	if s.signer == nil {
+		passphrase, err := readPassword(fmt.Sprintf("Enter passphrase for key '%s': ", s.path))
+		if err != nil {
+			return nil, err
+		}
+		signer, err := gossh.ParsePrivateKeyWithPassphrase(s.data, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decrypt key %s: %w", s.path, err)
+		}
+		s.signer = signer
+	}
+	return s.signer.Sign(rand, data)
+}
+
+func containsKey(signers []gossh.Signer, key gossh.PublicKey) bool {
+	for _, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholderKey matches no known_hosts entry; checking it lists the known keys
+type placeholderKey struct{}
+
+func (placeholderKey) Type() string    { return "sshm-placeholder" }
+func (placeholderKey) Marshal() []byte { return []byte("sshm-placeholder") }
+func (placeholderKey) Verify(data []byte, sig *gossh.Signature) error {
+	return errors.New("placeholder key")
+}
+
+// knownAlgorithms returns the host key algorithms known_hosts holds for addr
+func knownAlgorithms(check gossh.HostKeyCallback, addr string) []string {
+	var keyErr *knownhosts.KeyError
+	if err := check(addr, &net.TCPAddr{IP: net.IPv4zero}, placeholderKey{}); !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	var algorithms []string
+	for _, known := range keyErr.Want {
+		if known.Key.Type() == gossh.KeyAlgoRSA {
+			// RSA keys are used with SHA-2 signatures by current servers
+			algorithms = append(algorithms, gossh.KeyAlgoRSASHA512, gossh.KeyAlgoRSASHA256)
+		}
+		algorithms = append(algorithms, known.Key.Type())
+	}
+	return algorithms
+}
+
+func appendKnownHost(path, host string, key gossh.PublicKey) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintln(file, knownhosts.Line([]string{host}, key))
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "Warning: Permanently added '%s' to the list of known hosts.\n", host)
+	}
+	return err
+}
+
+func ensureFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// sessionError turns an unexpected end of a session into a readable error
+func sessionError(host *domain.Host, err error) error {
+	var missing *gossh.ExitMissingError
+	if errors.As(err, &missing) {
+		return fmt.Errorf("connection to %s closed unexpectedly", host.Hostname)
+	}
+	return err
+}
+
+func keyboardInteractive(name, instruction string, questions []string, echos []bool) ([]string, error) {
+	if name != "" {
+		fmt.Fprintln(os.Stderr, name)
+	}
+	if instruction != "" {
+		fmt.Fprintln(os.Stderr, instruction)
+	}
+
+	answers := make([]string, len(questions))
+	for i, question := range questions {
+		var err error
+		if echos[i] {
+			answers[i], err = readLine(question)
+		} else {
+			answers[i], err = readPassword(question)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return answers, nil
+}
+
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("cannot read password: %w", err)
+	}
+	return string(password), nil
+}
+
+// readLine reads a line from stdin byte by byte, so no input meant for the
+// remote session is buffered away
+func readLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				break
+			}
+			return "", err
+		}
+	}
+	return strings.TrimSpace(string(line)), nil
+}
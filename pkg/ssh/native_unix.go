@@ -0,0 +1,37 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// watchWindowSize calls resize with the terminal's new size whenever it
+// changes, until the returned stop function is called
+func watchWindowSize(fd int, resize func(width, height int)) (stop func()) {
+	changes := make(chan os.Signal, 1)
+	signal.Notify(changes, syscall.SIGWINCH)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-changes:
+				if width, height, err := term.GetSize(fd); err == nil {
+					resize(width, height)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(changes)
+		close(done)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package ssh
+
+import (
+	"time"
+
+	"golang.org/x/term"
+)
+
+// watchWindowSize polls the console size, as Windows has no SIGWINCH, and
+// calls resize when it changes until the returned stop function is called
+func watchWindowSize(fd int, resize func(width, height int)) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		width, height, _ := term.GetSize(fd)
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				w, h, err := term.GetSize(fd)
+				if err == nil && (w != width || h != height) {
+					width, height = w, h
+					resize(width, height)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,75 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+	"github.com/muesli/cancelreader"
+	"golang.org/x/term"
+)
+
+// Recorder receives the terminal output of an interactive session
+type Recorder interface {
+	io.Writer
+	Resize(width, height int)
+}
+
+// TerminalSize returns the size of the terminal on stdin, or 80x24
+func TerminalSize() (width, height int) {
+	width, height, err := term.GetSize(int(os.Stdin.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// connectRecorded runs ssh in a pseudo-terminal so that everything it shows
+// can be copied to the recorder as well as the real terminal
+func connectRecorded(args []string, rec Recorder) error {
+	width, height := TerminalSize()
+	cmd := exec.Command("ssh", args...)
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(width), Rows: uint16(height)})
+	if err != nil {
+		return fmt.Errorf("cannot start recorded session: %w", err)
+	}
+	defer ptmx.Close()
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("cannot switch terminal to raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+
+		stop := watchWindowSize(fd, func(width, height int) {
+			pty.Setsize(ptmx, &pty.Winsize{Cols: uint16(width), Rows: uint16(height)})
+			rec.Resize(width, height)
+		})
+		defer stop()
+	}
+
+	stdin, err := cancelreader.NewReader(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("cannot read the terminal: %w", err)
+	}
+	defer stdin.Close()
+
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(ptmx, stdin)
+		close(copied)
+	}()
+	io.Copy(io.MultiWriter(os.Stdout, rec), ptmx) // Ends with an error once ssh exits and the pty closes
+	err = cmd.Wait()
+
+	// Stop reading stdin, or the next keystroke would go to the closed pty
+	// instead of whatever runs next, such as the TUI
+	if stdin.Cancel() {
+		<-copied
+	}
+	return err
+}
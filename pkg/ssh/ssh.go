@@ -1,6 +1,7 @@
 package ssh
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -32,6 +33,14 @@ func BuildSSHCommand(host *domain.Host) string {
 func buildSSHArgs(host *domain.Host) []string {
 	var args []string
 
+	// Use only IPv4 or IPv6 if the host asks for it
+	switch host.AddressFamily {
+	case domain.FamilyInet:
+		args = append(args, "-4")
+	case domain.FamilyInet6:
+		args = append(args, "-6")
+	}
+
 	// Add port if not default
 	if host.Port != 22 {
 		args = append(args, "-p", strconv.Itoa(host.Port))
@@ -42,9 +51,27 @@ func buildSSHArgs(host *domain.Host) []string {
 		args = append(args, "-i", host.KeyPath)
 	}
 
-	// Add the connection string
-	connectionString := fmt.Sprintf("%s@%s", host.Username, host.Hostname)
-	args = append(args, connectionString)
+	// Offer only the host's key instead of every key in the agent
+	if host.IdentitiesOnly && host.KeyPath != "" {
+		args = append(args, "-o", "IdentitiesOnly=yes")
+	}
+
+	if host.ForwardAgent {
+		args = append(args, "-A")
+	}
+
+	// Connect through jump host(s) if specified
+	if host.ProxyJump != "" {
+		args = append(args, "-J", host.ProxyJump)
+	}
+
+	// Add the connection string. ssh cannot take user@ before an IPv6
+	// address, so those are given the user with -l.
+	if IsIPv6Literal(host.Hostname) {
+		args = append(args, "-l", host.Username, host.Hostname)
+	} else {
+		args = append(args, fmt.Sprintf("%s@%s", host.Username, host.Hostname))
+	}
 
 	return args
 }
@@ -66,15 +93,38 @@ func ValidateSSHKey(keyPath string) error {
 	}
 
 	// Check permissions (should be readable by owner only for private keys)
-	mode := info.Mode()
-	if mode&0077 != 0 {
-		fmt.Printf("Warning: SSH key file %s has overly permissive permissions (%o)\n", keyPath, mode&0777)
+	var permErr *KeyPermissionError
+	if err := CheckKeyPermissions(keyPath); errors.As(err, &permErr) {
+		fmt.Printf("Warning: %v\n", permErr)
 		fmt.Printf("Consider running: chmod 600 %s\n", keyPath)
 	}
 
 	return nil
 }
 
+// KeyPermissionError reports a private key readable by group or others
+type KeyPermissionError struct {
+	Path string
+	Mode os.FileMode
+}
+
+func (e *KeyPermissionError) Error() string {
+	return fmt.Sprintf("SSH key file %s has overly permissive permissions (%o)", e.Path, e.Mode)
+}
+
+// CheckKeyPermissions returns a *KeyPermissionError if the key is accessible by group or others
+func CheckKeyPermissions(keyPath string) error {
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		return fmt.Errorf("SSH key file does not exist: %s", keyPath)
+	}
+
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		return &KeyPermissionError{Path: keyPath, Mode: mode}
+	}
+	return nil
+}
+
 // TestConnection tests if we can connect to the host without executing commands
 func TestConnection(host *domain.Host) error {
 	args := buildSSHArgs(host)